@@ -0,0 +1,67 @@
+-- =====================================================
+-- MIGRACIÓN: Notificaciones en tiempo real de espacios
+-- Fecha: 2026-10-17
+-- Descripción: Crea triggers sobre ticket y espacio que publican
+--   un NOTIFY en el canal 'estacionamiento_eventos'. El servidor
+--   WebSocket (MODE=database) escucha este canal con LISTEN y
+--   emite espacio_ocupado / espacio_liberado de inmediato.
+-- =====================================================
+
+-- =====================================================
+-- 1. FUNCIÓN PARA CAMBIOS DE ESTADO EN ESPACIO
+-- =====================================================
+
+-- Solo los cambios reales de estado: un espacio recién creado no se ocupó ni se liberó
+CREATE OR REPLACE FUNCTION public.notify_espacio_estado()
+RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('estacionamiento_eventos', json_build_object(
+        'tabla', TG_TABLE_NAME,
+        'operacion', TG_OP,
+        'espacio_id', NEW.id,
+        'disponible', NEW.estado
+    )::text);
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_notify_espacio_estado ON public.espacio;
+CREATE TRIGGER trg_notify_espacio_estado
+AFTER UPDATE OF estado ON public.espacio
+FOR EACH ROW
+WHEN (OLD.estado IS DISTINCT FROM NEW.estado)
+EXECUTE FUNCTION public.notify_espacio_estado();
+
+
+-- =====================================================
+-- 2. FUNCIÓN PARA INGRESOS Y SALIDAS DE TICKETS
+-- =====================================================
+
+CREATE OR REPLACE FUNCTION public.notify_ticket_movimiento()
+RETURNS trigger AS $$
+BEGIN
+    -- Solo interesan los ingresos y el momento en que se registra la salida
+    IF TG_OP = 'UPDATE' AND NOT (OLD."fechaSalida" IS NULL AND NEW."fechaSalida" IS NOT NULL) THEN
+        RETURN NEW;
+    END IF;
+
+    PERFORM pg_notify('estacionamiento_eventos', json_build_object(
+        'tabla', TG_TABLE_NAME,
+        'operacion', TG_OP,
+        'espacio_id', NEW."espacioId",
+        'ticket_id', NEW.id,
+        'disponible', NEW."fechaSalida" IS NOT NULL
+    )::text);
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_notify_ticket_movimiento ON public.ticket;
+CREATE TRIGGER trg_notify_ticket_movimiento
+AFTER INSERT OR UPDATE OF "fechaSalida" ON public.ticket
+FOR EACH ROW EXECUTE FUNCTION public.notify_ticket_movimiento();
+
+COMMENT ON FUNCTION public.notify_espacio_estado() IS 'Publica cambios de estado de espacio en el canal estacionamiento_eventos';
+COMMENT ON FUNCTION public.notify_ticket_movimiento() IS 'Publica ingresos y salidas de tickets en el canal estacionamiento_eventos';
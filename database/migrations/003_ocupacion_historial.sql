@@ -0,0 +1,23 @@
+-- =====================================================
+-- MIGRACIÓN: Historial de ocupación
+-- Fecha: 2026-10-17
+-- Descripción: Crea la tabla donde el servidor WebSocket
+--   (MODE=database / hybrid) acumula por minuto la ocupación
+--   de cada snapshot del dashboard, en total y por sección.
+--   Se consulta con el mensaje get_occupancy_history.
+-- =====================================================
+
+CREATE TABLE IF NOT EXISTS public.ocupacion_historial (
+    seccion   text             NOT NULL,  -- letra de la sección, '' = todo el estacionamiento
+    minuto    timestamptz      NOT NULL,  -- inicio del minuto acumulado
+    minimo    double precision NOT NULL,  -- ocupación mínima (0 a 1)
+    maximo    double precision NOT NULL,  -- ocupación máxima (0 a 1)
+    suma      double precision NOT NULL,  -- suma de las muestras, promedio = suma / muestras
+    muestras  integer          NOT NULL,
+    PRIMARY KEY (seccion, minuto)
+);
+
+CREATE INDEX IF NOT EXISTS idx_ocupacion_historial_minuto
+    ON public.ocupacion_historial (minuto);
+
+COMMENT ON TABLE public.ocupacion_historial IS 'Ocupación por minuto registrada por el servidor WebSocket';
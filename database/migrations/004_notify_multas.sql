@@ -0,0 +1,35 @@
+-- =====================================================
+-- MIGRACIÓN: Notificaciones en tiempo real de multas
+-- Fecha: 2026-10-17
+-- Descripción: Crea un trigger sobre multa que publica un NOTIFY
+--   en el canal 'estacionamiento_eventos' (el mismo de la
+--   migración 002) cuando se registra una multa o pasa a estado
+--   'pagada'. El servidor WebSocket (MODE=database) emite
+--   multa_registrada / multa_pagada de inmediato.
+-- =====================================================
+
+CREATE OR REPLACE FUNCTION public.notify_multa_estado()
+RETURNS trigger AS $$
+BEGIN
+    -- Solo interesan las multas nuevas y el momento en que se pagan
+    IF TG_OP = 'UPDATE' AND NOT (NEW.estado = 'pagada' AND OLD.estado IS DISTINCT FROM 'pagada') THEN
+        RETURN NEW;
+    END IF;
+
+    PERFORM pg_notify('estacionamiento_eventos', json_build_object(
+        'tabla', TG_TABLE_NAME,
+        'operacion', TG_OP,
+        'multa_id', NEW.id,
+        'estado', NEW.estado
+    )::text);
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_notify_multa_estado ON public.multa;
+CREATE TRIGGER trg_notify_multa_estado
+AFTER INSERT OR UPDATE OF estado ON public.multa
+FOR EACH ROW EXECUTE FUNCTION public.notify_multa_estado();
+
+COMMENT ON FUNCTION public.notify_multa_estado() IS 'Publica multas registradas y pagadas en el canal estacionamiento_eventos';
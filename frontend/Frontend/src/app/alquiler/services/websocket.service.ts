@@ -2,6 +2,7 @@ import { Injectable, signal, inject, DestroyRef } from '@angular/core';
 import { takeUntilDestroyed } from '@angular/core/rxjs-interop';
 import { Subject, timer, switchMap, tap, catchError, of, retry, delay } from 'rxjs';
 import { environment } from '../../../environments/environment';
+import { AUTH_TOKEN_KEY } from '../../core/auth/models/auth.models';
 
 // Interfaces basadas en el servidor Go
 export interface DashboardData {
@@ -12,6 +13,18 @@ export interface DashboardData {
   dinero_recaudado_mes: number;
   vehiculos_activos: number;
   timestamp: string;
+  version?: number;
+  stale?: boolean; // el servidor no pudo consultar la fuente y envía el último dato válido
+  multas_pendientes: number;
+  monto_multas_pendientes?: number; // solo personal
+  pronostico?: Pronostico; // solo con FORECAST_IN_DASHBOARD=true
+}
+
+export interface DashboardDelta {
+  version: number;
+  base_version: number;
+  changes: Partial<DashboardData>;
+  timestamp: string;
 }
 
 export interface EspacioDetalle {
@@ -21,6 +34,73 @@ export interface EspacioDetalle {
   seccion_letra: string;
   vehiculo_placa?: string;
   hora_ingreso?: string;
+  minutos_transcurridos?: number;
+  monto_acumulado?: number; // cobro si el vehículo saliera ahora
+}
+
+export interface TicketActivo {
+  id: string;
+  fecha_ingreso: string;
+  vehiculo_id: string;
+  espacio_id: string;
+  vehiculo_placa?: string;
+  vehiculo_marca?: string;
+  vehiculo_modelo?: string;
+  vehiculo_categoria?: string;
+  espacio_numero?: string;
+  seccion_letra?: string;
+  minutos_transcurridos?: number;
+  monto_acumulado?: number;
+  multas_pendientes?: number;
+  monto_multas_pendientes?: number;
+}
+
+export interface VisitaTicket extends TicketActivo {
+  fecha_salida: string;
+}
+
+export interface ResultadoPlaca {
+  vehiculo_id: string;
+  placa: string;
+  marca?: string;
+  modelo?: string;
+  categoria?: string;
+  estacionado: boolean;
+  ticket_activo?: TicketActivo; // espacio, sección, ingreso y cobro acumulado
+  visitas: VisitaTicket[]; // de la más reciente a la más antigua
+}
+
+export interface BusquedaPlaca {
+  placa: string; // búsqueda normalizada
+  resultados: ResultadoPlaca[];
+  timestamp: string;
+}
+
+export interface BusquedaTicketsFiltro {
+  desde?: string; // RFC 3339
+  hasta?: string;
+  campo?: 'ingreso' | 'salida'; // fecha a la que se aplica el rango (por defecto ingreso)
+  seccion?: string;
+  placa?: string; // completa o parcial
+  pagado?: boolean;
+  min_minutos?: number;
+  max_minutos?: number;
+  limite?: number; // 50 por defecto, máximo 200
+  cursor?: string; // siguiente_cursor de la página anterior
+}
+
+export interface PaginaTickets {
+  tickets: (TicketActivo & { fecha_salida?: string; detalle_pago_id?: string })[];
+  limite: number;
+  siguiente_cursor?: string; // ausente en la última página
+  filtro: BusquedaTicketsFiltro;
+}
+
+export interface TicketsActivosFiltro {
+  seccion?: string;
+  placa?: string; // prefijo
+  orden?: 'ingreso' | 'placa' | 'seccion';
+  desc?: boolean;
 }
 
 export interface EspaciosPorSeccion {
@@ -34,6 +114,7 @@ export interface EspaciosPorSeccion {
 export interface EspacioOcupadoEvent {
   espacio_id: string;
   numero: string;
+  seccion_letra: string;
   vehiculo_placa: string;
   hora_ingreso: string;
 }
@@ -41,12 +122,89 @@ export interface EspacioOcupadoEvent {
 export interface EspacioLiberadoEvent {
   espacio_id: string;
   numero: string;
+  seccion_letra: string;
   monto_pagado: number;
   hora_salida: string;
 }
 
+export interface MultaEvent {
+  multa_id: string;
+  estado: 'pendiente' | 'pagada' | 'anulada';
+  fecha_multa: string;
+  ticket_id?: string;
+  espacio_id?: string; // espacio del vehículo si está estacionado
+  seccion_letra?: string;
+  // Solo personal
+  descripcion?: string;
+  monto_multa?: number;
+  vehiculo_id?: string;
+  vehiculo_placa?: string;
+  multas_pendientes?: number;
+  monto_multas_pendientes?: number;
+}
+
+export interface OverstayAlert {
+  ticket_id: string;
+  espacio_id: string;
+  espacio_numero: string;
+  seccion_letra: string;
+  maximo_minutos: number;
+  minutos_excedidos: number;
+  nivel: number; // 0 = superó el límite; +1 por cada paso de escalamiento
+  regla: string; // "global", "seccion:<letra>" o "categoria:<nombre>"
+  timestamp: string;
+  // Solo personal
+  vehiculo_placa?: string;
+  hora_ingreso?: string;
+}
+
+export interface CapacidadAlerta {
+  seccion_letra: string;
+  nivel: 'normal' | 'advertencia' | 'lleno';
+  nivel_anterior: 'normal' | 'advertencia' | 'lleno';
+  porcentaje: number;
+  espacios_ocupados: number;
+  espacios_disponibles: number;
+  total_espacios: number;
+  umbral_advertencia: number;
+  umbral_lleno: number;
+  timestamp: string;
+}
+
+export interface PronosticoHora {
+  hora: string; // fin de la hora pronosticada
+  ingresos_esperados: number;
+  salidas_esperadas: number;
+  ocupados: number;
+  porcentaje: number;
+}
+
+export interface PronosticoSeccion {
+  seccion_letra: string;
+  total_espacios: number;
+  espacios_ocupados: number;
+  horas: PronosticoHora[];
+}
+
+export interface Pronostico {
+  generado_en: string;
+  modelo: string;
+  semanas_historial: number;
+  secciones: PronosticoSeccion[];
+}
+
+export interface WebSocketError {
+  code: 'UNKNOWN_TYPE' | 'INVALID_PAYLOAD' | 'FORBIDDEN' | 'UPSTREAM_UNAVAILABLE' | 'UNSUPPORTED';
+  message: string;
+  request_type?: string;
+  retryable: boolean;
+}
+
 interface WebSocketMessage {
   type: string;
+  id?: string;
+  seq?: number;
+  freshness?: { as_of: string; age_ms: number; stale: boolean };
   data?: any;
 }
 
@@ -60,6 +218,10 @@ export class WebSocketService {
   private readonly WS_URL = environment.websocketUrl;
   private ws: WebSocket | null = null;
   private reconnectAttempts = 0;
+
+  // Posición en el stream de eventos para reanudar tras una reconexión
+  private streamId: string | null = null;
+  private lastSeq = 0;
   private readonly MAX_RECONNECT_ATTEMPTS = environment.websocketReconnectAttempts;
   private readonly RECONNECT_DELAY = environment.websocketReconnectDelay;
 
@@ -67,6 +229,9 @@ export class WebSocketService {
   readonly dashboardData = signal<DashboardData | null>(null);
   readonly espaciosPorSeccion = signal<EspaciosPorSeccion[]>([]);
   readonly espaciosDisponibles = signal<EspacioDetalle[]>([]);
+  readonly pronostico = signal<Pronostico | null>(null);
+  readonly busquedaPlaca = signal<BusquedaPlaca | null>(null);
+  readonly busquedaTickets = signal<PaginaTickets | null>(null);
   readonly isConnected = signal(false);
   readonly isReconnecting = signal(false);
   readonly connectionError = signal<string | null>(null);
@@ -74,9 +239,17 @@ export class WebSocketService {
   // Subjects para eventos
   private readonly espacioOcupadoSubject = new Subject<EspacioOcupadoEvent>();
   private readonly espacioLiberadoSubject = new Subject<EspacioLiberadoEvent>();
+  private readonly multaRegistradaSubject = new Subject<MultaEvent>();
+  private readonly multaPagadaSubject = new Subject<MultaEvent>();
+  private readonly overstayAlertSubject = new Subject<OverstayAlert>();
+  private readonly capacidadAlertaSubject = new Subject<CapacidadAlerta>();
   
   readonly espacioOcupado$ = this.espacioOcupadoSubject.asObservable();
   readonly espacioLiberado$ = this.espacioLiberadoSubject.asObservable();
+  readonly multaRegistrada$ = this.multaRegistradaSubject.asObservable();
+  readonly multaPagada$ = this.multaPagadaSubject.asObservable();
+  readonly overstayAlert$ = this.overstayAlertSubject.asObservable();
+  readonly capacidadAlerta$ = this.capacidadAlertaSubject.asObservable();
 
   constructor() {
     this.connect();
@@ -97,7 +270,11 @@ export class WebSocketService {
 
     try {
       console.log('🔌 Conectando a WebSocket:', this.WS_URL);
-      this.ws = new WebSocket(this.WS_URL);
+      // El servidor valida el access token en el handshake (subprotocolo "bearer")
+      const token = localStorage.getItem(AUTH_TOKEN_KEY);
+      this.ws = token
+        ? new WebSocket(this.WS_URL, ['bearer', token])
+        : new WebSocket(this.WS_URL);
       
       this.ws.onopen = () => {
         console.log('✅ WebSocket conectado');
@@ -177,10 +354,31 @@ export class WebSocketService {
    * Maneja mensajes recibidos del servidor
    */
   private handleMessage(message: WebSocketMessage): void {
+    if (message.seq && message.seq > this.lastSeq) {
+      this.lastSeq = message.seq;
+    }
+
     switch (message.type) {
+      case 'session':
+        this.handleSession(message.data);
+        break;
+
+      case 'resume_result':
+        this.streamId = message.data.stream_id;
+        if (message.data.resync_required) {
+          this.lastSeq = message.data.to_seq;
+          this.requestDashboardData();
+          this.requestEspaciosPorSeccion();
+        }
+        break;
+
       case 'dashboard_update':
         this.dashboardData.set(message.data);
         break;
+
+      case 'dashboard_delta':
+        this.applyDashboardDelta(message.data);
+        break;
         
       case 'espacios_por_seccion':
         this.espaciosPorSeccion.set(message.data);
@@ -189,6 +387,18 @@ export class WebSocketService {
       case 'espacios_disponibles':
         this.espaciosDisponibles.set(message.data);
         break;
+
+      case 'forecast':
+        this.pronostico.set(message.data);
+        break;
+
+      case 'placa_resultado':
+        this.busquedaPlaca.set(message.data);
+        break;
+
+      case 'tickets_resultado':
+        this.busquedaTickets.set(message.data);
+        break;
         
       case 'espacio_ocupado':
         this.espacioOcupadoSubject.next(message.data);
@@ -202,6 +412,24 @@ export class WebSocketService {
         this.requestDashboardData();
         break;
         
+      case 'multa_registrada':
+        this.multaRegistradaSubject.next(message.data);
+        this.requestDashboardData();
+        break;
+
+      case 'multa_pagada':
+        this.multaPagadaSubject.next(message.data);
+        this.requestDashboardData();
+        break;
+
+      case 'overstay_alert':
+        this.overstayAlertSubject.next(message.data);
+        break;
+
+      case 'capacidad_alerta':
+        this.capacidadAlertaSubject.next(message.data);
+        break;
+
       case 'error':
         console.error('Error del servidor:', message.data);
         break;
@@ -211,6 +439,37 @@ export class WebSocketService {
     }
   }
 
+  /**
+   * Al (re)conectar pide los eventos perdidos desde la última secuencia vista
+   */
+  private handleSession(session: { stream_id: string; seq: number }): void {
+    if (this.streamId && this.lastSeq > 0) {
+      this.sendMessage('resume', { stream_id: this.streamId, last_seq: this.lastSeq });
+      return;
+    }
+
+    this.streamId = session.stream_id;
+    this.lastSeq = session.seq;
+  }
+
+  /**
+   * Aplica un delta del dashboard o pide resincronizar si se perdió una versión
+   */
+  private applyDashboardDelta(delta: DashboardDelta): void {
+    const current = this.dashboardData();
+    if (!current || current.version !== delta.base_version) {
+      this.sendMessage('dashboard_resync');
+      return;
+    }
+
+    this.dashboardData.set({
+      ...current,
+      ...delta.changes,
+      version: delta.version,
+      timestamp: delta.timestamp,
+    });
+  }
+
   /**
    * Envía un mensaje al servidor WebSocket
    */
@@ -245,9 +504,31 @@ export class WebSocketService {
   }
 
   /**
-   * Solicita tickets activos
+   * Solicita tickets activos, opcionalmente filtrados por sección o prefijo de placa
+   */
+  requestTicketsActivos(filtro?: TicketsActivosFiltro): void {
+    this.sendMessage('get_tickets_activos', filtro);
+  }
+
+  /**
+   * Solicita el pronóstico de ocupación por sección para las próximas horas (1 a 3)
+   */
+  requestForecast(horas?: number): void {
+    this.sendMessage('get_forecast', horas ? { horas } : undefined);
+  }
+
+  /**
+   * Busca un vehículo por placa completa o parcial (solo personal)
+   */
+  buscarPlaca(placa: string, visitas?: number): void {
+    this.sendMessage('buscar_placa', { placa, visitas });
+  }
+
+  /**
+   * Busca tickets históricos (solo personal). Para la página siguiente se repiten los
+   * filtros con el siguiente_cursor de la respuesta anterior
    */
-  requestTicketsActivos(): void {
-    this.sendMessage('get_tickets_activos');
+  buscarTickets(filtro: BusquedaTicketsFiltro = {}): void {
+    this.sendMessage('buscar_tickets', filtro);
   }
 }
@@ -18,4 +18,11 @@ class EspacioType:
 class EstadoEspacioType:
     letraSeccion: str
     espacios: list[EspacioType]
-    total: int = 0
\ No newline at end of file
+    total: int = 0
+
+@strawberry.type
+class SeccionType:
+    """Sección con todos sus espacios ordenados por número"""
+    id: UUID
+    letraSeccion: str
+    espacios: list[EspacioType]
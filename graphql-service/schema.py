@@ -8,10 +8,10 @@ from graphtypes.clientesdiarios_type import ClientesDiariosType
 from services.detallepago_services import get_todos_detallepago
 from graphtypes.ticket_type import TicketType
 from graphtypes.detallepago_type import DetallePagoType
-from graphtypes.espacios_type import EspacioType, EstadoEspacioType
+from graphtypes.espacios_type import EspacioType, EstadoEspacioType, SeccionType
 from graphtypes.cliente_type import ClienteType
 from graphtypes.tipoTarifa_type import TipoTarifaType
-from services.espacios_services import filtrar_espacios_por_seccion_y_estado, get_todos_espacios
+from services.espacios_services import filtrar_espacios_por_seccion_y_estado, get_todos_espacios, get_secciones_con_espacios
 from services.cliente_services import get_all_clientes
 from services.tipoTarifa_services import get_all_tipo_tarifas
 
@@ -64,6 +64,20 @@ class Query:
             total=len(espacios_lista)
         )
 
+    @strawberry.field
+    def secciones(self) -> List[SeccionType]:
+        return [
+            SeccionType(
+                id=s["id"],
+                letraSeccion=s["letraSeccion"],
+                espacios=[
+                    EspacioType(id=e["id"], numero=e["numero"], _estado=e["estado"])
+                    for e in sorted(s.get("espacios", []), key=lambda e: e["numero"])
+                ]
+            )
+            for s in sorted(get_secciones_con_espacios(), key=lambda s: s["letraSeccion"])
+        ]
+
     @strawberry.field
     def tickets(self) -> List[TicketType]:
         tickets = get_todos_tickets()
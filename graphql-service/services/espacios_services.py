@@ -16,6 +16,11 @@ def get_todos_secciones():
     response.raise_for_status()
     return response.json()
 
+def get_secciones_con_espacios():
+    response = requests.get(f"{API_URL}/secciones/with-espacios")
+    response.raise_for_status()
+    return response.json()
+
 def filtrar_secciones_por_letra(letra_seccion: str):
     secciones = get_todos_secciones()
     return [s for s in secciones if s.get("letraSeccion") == letra_seccion]
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"database/sql"
 	"log"
 	"net/http"
 	"os"
@@ -10,10 +11,22 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/josedavid1945/estacionamiento-websocket/internal/auth"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/backplane"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/client"
 	"github.com/josedavid1945/estacionamiento-websocket/internal/config"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/handler/api"
 	wsHandler "github.com/josedavid1945/estacionamiento-websocket/internal/handler/websocket"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/metrics"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/repository/interfaces"
 	"github.com/josedavid1945/estacionamiento-websocket/internal/repository/postgres"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/service/capacidad"
 	"github.com/josedavid1945/estacionamiento-websocket/internal/service/dashboard"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/service/eventos"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/service/ocupacion"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/service/permanencia"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/service/pronostico"
 	"github.com/josedavid1945/estacionamiento-websocket/pkg/database"
 )
 
@@ -28,49 +41,199 @@ func main() {
 	log.Printf("📍 Puerto: %s", cfg.WSPort)
 	log.Printf("📍 Path: %s", cfg.WSPath)
 	log.Printf("🔄 Intervalo de actualización: %d segundos", cfg.UpdateInterval)
+	log.Printf("🗃️  TTL de snapshots: %d segundos", cfg.SnapshotTTL)
 	log.Printf("🔌 Modo: %s", cfg.Mode)
-	if cfg.Mode == "rest" {
+	if cfg.Mode == "rest" || cfg.Mode == "hybrid" {
 		log.Printf("🌐 REST API URL: %s", cfg.RestAPIURL)
 	}
+	if cfg.Mode == "graphql" {
+		log.Printf("🌐 GraphQL URL: %s", cfg.GraphQLURL)
+	}
+
+	var source interfaces.DataSource
+	var eventoRepo *postgres.EventoRepository
+	var listener *database.Listener
+	var db *sql.DB
+
+	// Fuente de datos PostgreSQL con sus repositorios
+	newDatabaseSource := func() *postgres.Source {
+		dashboardRepo := metrics.InstrumentDashboardRepository(postgres.NewDashboardRepository(db))
+		return postgres.NewSource(dashboardRepo, postgres.NewTicketRepository(db), postgres.NewVehiculoRepository(db), postgres.NewIngresosRepository(db), postgres.NewMultaRepository(db))
+	}
 
-	var dashboardService *dashboard.Service
+	// El catálogo y las multas del REST API se descargan una vez por ciclo de actualización
+	restCacheTTL := time.Duration(cfg.UpdateInterval) * time.Second
 
-	// Decidir si usar REST API o base de datos directa
-	if cfg.Mode == "rest" {
+	// Decidir la fuente de datos: REST API, GraphQL, base de datos directa o ambas con failover
+	switch cfg.Mode {
+	case "rest":
 		// Modo REST: obtener datos del REST API vía HTTP
 		log.Println("✅ Configurado para usar REST API")
-		dashboardService = dashboard.NewServiceWithRestAPI(cfg.RestAPIURL)
-	} else {
+		source = client.NewRestClient(cfg.RestAPIURL, restCacheTTL)
+
+	case "graphql":
+		// Modo GRAPHQL: cada vista en una sola consulta al graphql-service
+		log.Println("✅ Configurado para usar graphql-service")
+		source = client.NewGraphQLClient(cfg.GraphQLURL)
+
+	case "hybrid":
+		// Modo HYBRID: la base de datos puede estar caída al arrancar, se usa el REST API mientras tanto
+		var err error
+		db, err = database.Open(cfg.DatabaseURL)
+		if err != nil {
+			log.Fatalf("Error al abrir la base de datos: %v", err)
+		}
+		defer database.Close(db)
+		if err := db.Ping(); err != nil {
+			log.Printf("⚠️  Base de datos no disponible al iniciar: %v", err)
+		}
+
+		restSource := client.NewRestClient(cfg.RestAPIURL, restCacheTTL)
+		if cfg.HybridPrimary == "rest" {
+			source = dashboard.NewFailoverSource(restSource, newDatabaseSource())
+		} else {
+			source = dashboard.NewFailoverSource(newDatabaseSource(), restSource)
+		}
+		log.Printf("✅ Configurado en modo híbrido: %s", source.Name())
+
+	default:
 		// Modo DATABASE: consultar directamente PostgreSQL
 		log.Println("✅ Configurado para consultar base de datos directamente")
 		
 		// Conectar a la base de datos
-		db, err := database.Connect(cfg.DatabaseURL)
+		var err error
+		db, err = database.Connect(cfg.DatabaseURL)
 		if err != nil {
 			log.Fatalf("Error al conectar a la base de datos: %v", err)
 		}
 		defer database.Close(db)
 
-		// Inicializar repositorios
-		dashboardRepo := postgres.NewDashboardRepository(db)
-		ticketRepo := postgres.NewTicketRepository(db)
-		vehiculoRepo := postgres.NewVehiculoRepository(db)
+		source = newDatabaseSource()
+	}
 
-		// Inicializar servicio con repositorios
-		dashboardService = dashboard.NewService(dashboardRepo, ticketRepo, vehiculoRepo)
+	dashboardService := dashboard.NewService(source, time.Duration(cfg.SnapshotTTL)*time.Second)
+
+	if cfg.Mode != "rest" && cfg.Mode != "graphql" {
+		// Escuchar eventos de espacios publicados por los triggers (LISTEN/NOTIFY)
+		var err error
+		listener, err = database.NewListener(cfg.DatabaseURL, cfg.NotifyChannel)
+		if err != nil {
+			log.Printf("⚠️  Eventos en tiempo real deshabilitados: %v", err)
+		} else {
+			defer listener.Close()
+			eventoRepo = postgres.NewEventoRepository(db)
+		}
+	}
+
+	// Backplane para distribuir eventos entre réplicas
+	var bp backplane.Backplane
+	if cfg.Backplane == "postgres" {
+		if db == nil {
+			var err error
+			db, err = database.Connect(cfg.DatabaseURL)
+			if err != nil {
+				log.Fatalf("Error al conectar a la base de datos para el backplane: %v", err)
+			}
+			defer database.Close(db)
+		}
+
+		pgBackplane, err := backplane.NewPostgres(db, cfg.DatabaseURL, cfg.BackplaneChannel)
+		if err != nil {
+			log.Fatalf("Error al iniciar backplane PostgreSQL: %v", err)
+		}
+		defer pgBackplane.Close()
+		bp = pgBackplane
+		log.Printf("🔀 Backplane PostgreSQL en canal %s", cfg.BackplaneChannel)
+	} else {
+		bp = backplane.NewMemory()
+	}
+
+	// Historial de ocupación: tabla en PostgreSQL si hay base de datos, si no en memoria
+	historyRetention := time.Duration(cfg.HistoryDays) * 24 * time.Hour
+	var historyRepo interfaces.OcupacionRepository
+	if cfg.Mode != "rest" && cfg.Mode != "graphql" {
+		historyRepo = postgres.NewOcupacionRepository(db, historyRetention)
+		log.Printf("📈 Historial de ocupación en PostgreSQL (%d días)", cfg.HistoryDays)
+	} else {
+		historyRepo = ocupacion.NewMemoryStore(historyRetention)
+		log.Printf("📈 Historial de ocupación en memoria (%d días)", cfg.HistoryDays)
 	}
+	history := ocupacion.NewService(historyRepo, func(ctx context.Context) ([]models.EspaciosPorSeccion, error) {
+		snapshot, err := dashboardService.GetEspaciosPorSeccion(ctx)
+		return snapshot.Data, err
+	})
 
 	// Inicializar Hub WebSocket
-	hub := wsHandler.NewHub(dashboardService, time.Duration(cfg.UpdateInterval))
+	hub := wsHandler.NewHub(dashboardService, time.Duration(cfg.UpdateInterval), cfg.EventBuffer, bp, history)
+
+	// Pronóstico de ocupación a partir del flujo histórico de tickets, solo si lo
+	// usa get_forecast o el dashboard
+	if cfg.ForecastEnabled || cfg.ForecastInDashboard {
+		hub.Pronostico = pronostico.NewService(dashboardService.GetFlujoHorario, func(ctx context.Context) ([]models.EspaciosPorSeccion, error) {
+			snapshot, err := dashboardService.GetEspaciosPorSeccion(ctx)
+			return snapshot.Data, err
+		}, cfg.ForecastWeeks)
+		hub.PronosticoEnDashboard = cfg.ForecastInDashboard
+		log.Printf("🔮 Pronóstico de ocupación con %d semanas de historial (en dashboard: %t)", cfg.ForecastWeeks, cfg.ForecastInDashboard)
+	} else {
+		log.Println("🔮 Pronóstico de ocupación deshabilitado (FORECAST_ENABLED=false)")
+	}
+
+	// Alertas de permanencia máxima evaluadas en cada ciclo del Hub
+	reglasPermanencia, err := permanencia.ParseReglas(cfg.OverstayMaxStay, cfg.OverstayRules)
+	if err != nil {
+		log.Fatalf("❌ OVERSTAY_MAX_STAY / OVERSTAY_RULES inválido: %v", err)
+	}
+	escalamiento, err := permanencia.ParseEscalamiento(cfg.OverstayEscalation)
+	if err != nil {
+		log.Fatalf("❌ OVERSTAY_ESCALATION inválido: %v", err)
+	}
+	if !reglasPermanencia.Vacias() {
+		hub.Overstay = permanencia.NewMonitor(reglasPermanencia, escalamiento)
+		log.Printf("⏰ Alertas de permanencia habilitadas (escalamiento: %v)", escalamiento)
+	}
+
+	// Alertas de capacidad por sección con histéresis
+	if cfg.CapacityAlertsEnabled {
+		umbrales, err := capacidad.ParseUmbrales(capacidad.Umbral{Advertencia: cfg.CapacityWarning, Lleno: cfg.CapacityFull}, cfg.CapacityClearMargin, cfg.CapacityRules)
+		if err != nil {
+			log.Fatalf("❌ CAPACITY_WARNING / CAPACITY_FULL / CAPACITY_RULES inválido: %v", err)
+		}
+		hub.Capacidad = capacidad.NewMonitor(umbrales)
+		log.Printf("🚦 Alertas de capacidad habilitadas (%.0f%% advertencia, %.0f%% lleno, margen %.0f)", cfg.CapacityWarning, cfg.CapacityFull, cfg.CapacityClearMargin)
+	}
+
 	go hub.Run()
 
+	// Publicar eventos de espacios a través del Hub
+	ctxEventos, cancelEventos := context.WithCancel(context.Background())
+	defer cancelEventos()
+	if listener != nil {
+		eventosService := eventos.NewService(listener, eventoRepo, hub)
+		go eventosService.Run(ctxEventos)
+	}
+
+	// Validador de access tokens para el handshake
+	var validator *auth.Validator
+	switch {
+	case !cfg.AuthEnabled:
+		log.Println("⚠️  Autenticación WebSocket deshabilitada (WS_AUTH_ENABLED=false)")
+	case cfg.JWTSecret != "":
+		validator = auth.NewValidator(cfg.JWTSecret)
+		log.Println("🔒 Autenticación WebSocket con JWT_SECRET")
+	default:
+		validator = auth.NewValidatorFromAuthService(cfg.AuthServiceURL, cfg.InternalServiceKey, 24*time.Hour)
+		log.Printf("🔒 Autenticación WebSocket con secreto de %s", cfg.AuthServiceURL)
+	}
+
 	// Inicializar handler WebSocket
-	handler := wsHandler.NewHandler(hub, dashboardService)
+	handler := wsHandler.NewHandler(hub, dashboardService, validator)
 
 	// Configurar rutas
 	mux := http.NewServeMux()
 	mux.HandleFunc(cfg.WSPath, handler.ServeWS)
 	mux.HandleFunc("/health", handler.HealthCheck)
+	api.NewHandler(dashboardService, validator).Register(mux)
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		w.Write([]byte(`
@@ -98,6 +261,12 @@ func main() {
     <div class="endpoint">
         <strong>Health Check:</strong> <code>http://localhost:` + cfg.WSPort + `/health</code>
     </div>
+    <div class="endpoint">
+        <strong>API JSON (ETag / If-None-Match):</strong> <code>http://localhost:` + cfg.WSPort + `/api/v1/dashboard</code>, <code>/api/v1/secciones</code>, <code>/api/v1/espacios/disponibles</code>, <code>/api/v1/tickets/activos</code>
+    </div>
+    <div class="endpoint">
+        <strong>Desglose de ingresos:</strong> <code>http://localhost:` + cfg.WSPort + `/api/v1/ingresos?desde=2026-01-01&amp;hasta=2026-01-31</code>
+    </div>
     <h2>Clientes conectados:</h2>
     <p id="clients">Cargando...</p>
     <script>
@@ -121,6 +290,30 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// Métricas en un listener aparte, solo si se configuró METRICS_PORT
+	var metricsServer *http.Server
+	if cfg.MetricsPort != "" {
+		if cfg.MetricsPort == cfg.WSPort {
+			log.Fatalf("❌ METRICS_PORT debe ser distinto de WS_PORT para no exponer /metrics")
+		}
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", metrics.Handler())
+		metricsServer = &http.Server{
+			Addr:         ":" + cfg.MetricsPort,
+			Handler:      metricsMux,
+			ReadTimeout:  15 * time.Second,
+			WriteTimeout: 15 * time.Second,
+		}
+		go func() {
+			log.Printf("📊 Métricas de Prometheus en http://localhost:%s/metrics", cfg.MetricsPort)
+			if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Error al iniciar servidor de métricas: %v", err)
+			}
+		}()
+	} else {
+		log.Println("📊 Métricas deshabilitadas (METRICS_PORT vacío)")
+	}
+
 	// Canal para señales de sistema
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
@@ -138,8 +331,11 @@ func main() {
 	<-stop
 	log.Println("\n🛑 Señal de apagado recibida, cerrando servidor...")
 
-	// Apagar el Hub
+	// Detener eventos y el refresco del secreto JWT, y apagar el Hub
+	cancelEventos()
+	validator.Stop()
 	hub.Shutdown()
+	history.Flush(context.Background())
 
 	// Apagar servidor con timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -148,6 +344,11 @@ func main() {
 	if err := server.Shutdown(ctx); err != nil {
 		log.Printf("Error al apagar servidor: %v", err)
 	}
+	if metricsServer != nil {
+		if err := metricsServer.Shutdown(ctx); err != nil {
+			log.Printf("Error al apagar servidor de métricas: %v", err)
+		}
+	}
 
 	log.Println("👋 Servidor cerrado correctamente")
 }
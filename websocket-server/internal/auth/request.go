@@ -0,0 +1,60 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+)
+
+const (
+	// TokenQueryParam parámetro de query con el access token (?token=...)
+	TokenQueryParam = "token"
+
+	// TokenCookieName cookie con el access token (mismo nombre que usa el frontend)
+	TokenCookieName = "access_token"
+
+	// BearerSubprotocol subprotocolo que precede al token en Sec-WebSocket-Protocol.
+	// El navegador no permite headers propios en el handshake, por eso el frontend
+	// abre el socket con new WebSocket(url, ["bearer", token]).
+	BearerSubprotocol = "bearer"
+)
+
+// TokenFromRequest extrae el access token del handshake. Devuelve además el
+// subprotocolo que debe repetirse en la respuesta cuando el token llegó por
+// Sec-WebSocket-Protocol (si no, el navegador cierra la conexión).
+func TokenFromRequest(r *http.Request) (token, subprotocol string) {
+	if token = r.URL.Query().Get(TokenQueryParam); token != "" {
+		return token, ""
+	}
+
+	if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(header, "Bearer ")), ""
+	}
+
+	protocols := splitProtocols(r.Header.Get("Sec-WebSocket-Protocol"))
+	for i, protocol := range protocols {
+		if strings.EqualFold(protocol, BearerSubprotocol) && i+1 < len(protocols) {
+			return protocols[i+1], BearerSubprotocol
+		}
+	}
+
+	if cookie, err := r.Cookie(TokenCookieName); err == nil && cookie.Value != "" {
+		return cookie.Value, ""
+	}
+
+	return "", ""
+}
+
+// splitProtocols separa la lista de subprotocolos del header
+func splitProtocols(header string) []string {
+	if header == "" {
+		return nil
+	}
+	parts := strings.Split(header, ",")
+	protocols := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			protocols = append(protocols, part)
+		}
+	}
+	return protocols
+}
@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Roles emitidos por el auth-service
+const (
+	RoleAdmin    = "admin"
+	RoleOperator = "operator"
+	RoleUser     = "user"
+)
+
+// clockSkew tolerancia de reloj entre servidores al verificar exp / iat
+const clockSkew = 30 * time.Second
+
+var (
+	ErrTokenMissing   = errors.New("token no proporcionado")
+	ErrTokenMalformed = errors.New("token con formato inválido")
+	ErrTokenSignature = errors.New("firma del token inválida")
+	ErrTokenExpired   = errors.New("token expirado")
+	ErrTokenClaims    = errors.New("claims del token inválidos")
+	ErrSecretMissing  = errors.New("secreto JWT no configurado")
+)
+
+// Claims representa el payload del access token emitido por el auth-service
+type Claims struct {
+	Sub       string `json:"sub"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	JTI       string `json:"jti"`
+	Type      string `json:"type"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// HasRole indica si el token tiene alguno de los roles indicados
+func (c *Claims) HasRole(roles ...string) bool {
+	if c == nil {
+		return false
+	}
+	for _, role := range roles {
+		if c.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Validator valida access tokens localmente (HS256) sin llamar al auth-service
+// en cada conexión. El secreto puede venir de configuración o descargarse de
+// /auth/validation-secret con la clave de servicio interna.
+type Validator struct {
+	secret      []byte
+	secretMutex sync.RWMutex
+	authURL     string
+	serviceKey  string
+	httpClient  *http.Client
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewValidator crea un validador con un secreto fijo (JWT_SECRET)
+func NewValidator(secret string) *Validator {
+	return &Validator{
+		secret:     []byte(secret),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// NewValidatorFromAuthService crea un validador que obtiene el secreto del auth-service
+// al iniciar y lo refresca periódicamente
+func NewValidatorFromAuthService(authURL, serviceKey string, refreshEvery time.Duration) *Validator {
+	v := &Validator{
+		authURL:    strings.TrimRight(authURL, "/"),
+		serviceKey: serviceKey,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		stop:       make(chan struct{}),
+	}
+
+	if err := v.fetchSecret(); err != nil {
+		log.Printf("⚠️  No se pudo obtener el secreto JWT del auth-service: %v", err)
+	}
+
+	go func() {
+		ticker := time.NewTicker(refreshEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-v.stop:
+				return
+			case <-ticker.C:
+				if err := v.fetchSecret(); err != nil {
+					log.Printf("Error refrescando secreto JWT: %v", err)
+				}
+			}
+		}
+	}()
+
+	return v
+}
+
+// Stop detiene el refresco periódico del secreto. Se puede llamar más de una vez y
+// sobre validadores con secreto fijo o nil.
+func (v *Validator) Stop() {
+	if v == nil || v.stop == nil {
+		return
+	}
+	v.stopOnce.Do(func() { close(v.stop) })
+}
+
+// fetchSecret descarga el secreto de validación del auth-service
+func (v *Validator) fetchSecret() error {
+	req, err := http.NewRequest(http.MethodGet, v.authURL+"/auth/validation-secret", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Service-Key", v.serviceKey)
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("auth-service respondió con status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Secret    string `json:"secret"`
+		Algorithm string `json:"algorithm"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+	if result.Algorithm != "" && result.Algorithm != "HS256" {
+		return fmt.Errorf("algoritmo no soportado: %s", result.Algorithm)
+	}
+
+	v.secretMutex.Lock()
+	v.secret = []byte(result.Secret)
+	v.secretMutex.Unlock()
+
+	log.Println("🔑 Secreto JWT obtenido del auth-service")
+	return nil
+}
+
+// ValidateToken verifica firma, expiración y claims obligatorios (sub, role, type, jti)
+func (v *Validator) ValidateToken(token string) (*Claims, error) {
+	if token == "" {
+		return nil, ErrTokenMissing
+	}
+
+	v.secretMutex.RLock()
+	secret := v.secret
+	v.secretMutex.RUnlock()
+
+	if len(secret) == 0 {
+		return nil, ErrSecretMissing
+	}
+
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrTokenMalformed
+	}
+
+	headerBytes, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return nil, ErrTokenMalformed
+	}
+	if header.Alg != "HS256" {
+		return nil, fmt.Errorf("%w: algoritmo %q no permitido", ErrTokenMalformed, header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil, ErrTokenSignature
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, ErrTokenMalformed
+	}
+
+	now := time.Now()
+	if claims.ExpiresAt == 0 || now.After(time.Unix(claims.ExpiresAt, 0).Add(clockSkew)) {
+		return nil, ErrTokenExpired
+	}
+	if claims.IssuedAt != 0 && time.Unix(claims.IssuedAt, 0).After(now.Add(clockSkew)) {
+		return nil, fmt.Errorf("%w: emitido en el futuro", ErrTokenClaims)
+	}
+
+	if claims.Type != "access" {
+		return nil, fmt.Errorf("%w: tipo %q", ErrTokenClaims, claims.Type)
+	}
+	if claims.Sub == "" || claims.JTI == "" {
+		return nil, fmt.Errorf("%w: faltan sub o jti", ErrTokenClaims)
+	}
+	if !claims.HasRole(RoleAdmin, RoleOperator, RoleUser) {
+		return nil, fmt.Errorf("%w: rol %q", ErrTokenClaims, claims.Role)
+	}
+
+	return &claims, nil
+}
@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const secretoPrueba = "secreto-de-prueba"
+
+// firmar arma un JWT con el header y los claims indicados, firmado con HS256
+func firmar(t *testing.T, secret string, header, claims map[string]interface{}) string {
+	t.Helper()
+	segmento := func(v interface{}) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	unsigned := segmento(header) + "." + segmento(claims)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// claimsValidos claims de un access token vigente emitido hace un minuto
+func claimsValidos() map[string]interface{} {
+	ahora := time.Now()
+	return map[string]interface{}{
+		"sub":   "u1",
+		"email": "u1@example.com",
+		"role":  RoleOperator,
+		"jti":   "jti-1",
+		"type":  "access",
+		"iat":   ahora.Add(-time.Minute).Unix(),
+		"exp":   ahora.Add(15 * time.Minute).Unix(),
+	}
+}
+
+var hs256 = map[string]interface{}{"alg": "HS256", "typ": "JWT"}
+
+func TestValidateToken(t *testing.T) {
+	ahora := time.Now()
+	con := func(cambios map[string]interface{}) map[string]interface{} {
+		claims := claimsValidos()
+		for k, v := range cambios {
+			if v == nil {
+				delete(claims, k)
+			} else {
+				claims[k] = v
+			}
+		}
+		return claims
+	}
+
+	casos := []struct {
+		nombre string
+		token  func(t *testing.T) string
+		err    error
+	}{
+		{"válido", func(t *testing.T) string {
+			return firmar(t, secretoPrueba, hs256, claimsValidos())
+		}, nil},
+		{"vacío", func(*testing.T) string { return "" }, ErrTokenMissing},
+		{"firma con otro secreto", func(t *testing.T) string {
+			return firmar(t, "otro-secreto", hs256, claimsValidos())
+		}, ErrTokenSignature},
+		{"payload alterado", func(t *testing.T) string {
+			partes := strings.Split(firmar(t, secretoPrueba, hs256, claimsValidos()), ".")
+			otro := strings.Split(firmar(t, secretoPrueba, hs256, con(map[string]interface{}{"role": RoleAdmin})), ".")
+			return partes[0] + "." + otro[1] + "." + partes[2]
+		}, ErrTokenSignature},
+		{"alg none sin firma", func(t *testing.T) string {
+			partes := strings.Split(firmar(t, secretoPrueba, map[string]interface{}{"alg": "none"}, claimsValidos()), ".")
+			return partes[0] + "." + partes[1] + "."
+		}, ErrTokenMalformed},
+		{"alg none firmado", func(t *testing.T) string {
+			return firmar(t, secretoPrueba, map[string]interface{}{"alg": "none"}, claimsValidos())
+		}, ErrTokenMalformed},
+		{"alg HS512", func(t *testing.T) string {
+			return firmar(t, secretoPrueba, map[string]interface{}{"alg": "HS512"}, claimsValidos())
+		}, ErrTokenMalformed},
+		{"alg RS256", func(t *testing.T) string {
+			return firmar(t, secretoPrueba, map[string]interface{}{"alg": "RS256"}, claimsValidos())
+		}, ErrTokenMalformed},
+		{"expirado", func(t *testing.T) string {
+			return firmar(t, secretoPrueba, hs256, con(map[string]interface{}{"exp": ahora.Add(-clockSkew - time.Minute).Unix()}))
+		}, ErrTokenExpired},
+		{"expirado dentro del margen de reloj", func(t *testing.T) string {
+			return firmar(t, secretoPrueba, hs256, con(map[string]interface{}{"exp": ahora.Add(-clockSkew / 2).Unix()}))
+		}, nil},
+		{"sin exp", func(t *testing.T) string {
+			return firmar(t, secretoPrueba, hs256, con(map[string]interface{}{"exp": nil}))
+		}, ErrTokenExpired},
+		{"iat en el futuro", func(t *testing.T) string {
+			return firmar(t, secretoPrueba, hs256, con(map[string]interface{}{"iat": ahora.Add(clockSkew + time.Minute).Unix()}))
+		}, ErrTokenClaims},
+		{"iat adelantado dentro del margen de reloj", func(t *testing.T) string {
+			return firmar(t, secretoPrueba, hs256, con(map[string]interface{}{"iat": ahora.Add(clockSkew / 2).Unix()}))
+		}, nil},
+		{"tipo refresh", func(t *testing.T) string {
+			return firmar(t, secretoPrueba, hs256, con(map[string]interface{}{"type": "refresh"}))
+		}, ErrTokenClaims},
+		{"sin tipo", func(t *testing.T) string {
+			return firmar(t, secretoPrueba, hs256, con(map[string]interface{}{"type": nil}))
+		}, ErrTokenClaims},
+		{"sin sub", func(t *testing.T) string {
+			return firmar(t, secretoPrueba, hs256, con(map[string]interface{}{"sub": nil}))
+		}, ErrTokenClaims},
+		{"sin jti", func(t *testing.T) string {
+			return firmar(t, secretoPrueba, hs256, con(map[string]interface{}{"jti": nil}))
+		}, ErrTokenClaims},
+		{"rol desconocido", func(t *testing.T) string {
+			return firmar(t, secretoPrueba, hs256, con(map[string]interface{}{"role": "superuser"}))
+		}, ErrTokenClaims},
+		{"dos segmentos", func(t *testing.T) string {
+			partes := strings.Split(firmar(t, secretoPrueba, hs256, claimsValidos()), ".")
+			return partes[0] + "." + partes[1]
+		}, ErrTokenMalformed},
+		{"cuatro segmentos", func(t *testing.T) string {
+			return firmar(t, secretoPrueba, hs256, claimsValidos()) + ".extra"
+		}, ErrTokenMalformed},
+		{"header que no es base64", func(t *testing.T) string {
+			partes := strings.Split(firmar(t, secretoPrueba, hs256, claimsValidos()), ".")
+			return "%%%." + partes[1] + "." + partes[2]
+		}, ErrTokenMalformed},
+	}
+
+	v := NewValidator(secretoPrueba)
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			claims, err := v.ValidateToken(caso.token(t))
+			if caso.err == nil {
+				if err != nil {
+					t.Fatalf("ValidateToken: %v", err)
+				}
+				if claims.Sub != "u1" || claims.Role != RoleOperator {
+					t.Errorf("claims = %+v", claims)
+				}
+				return
+			}
+			if !errors.Is(err, caso.err) {
+				t.Fatalf("ValidateToken = %v, se esperaba %v", err, caso.err)
+			}
+			if claims != nil {
+				t.Errorf("claims devueltos con error: %+v", claims)
+			}
+		})
+	}
+}
+
+func TestValidateTokenSinSecreto(t *testing.T) {
+	token := firmar(t, secretoPrueba, hs256, claimsValidos())
+	if _, err := NewValidator("").ValidateToken(token); !errors.Is(err, ErrSecretMissing) {
+		t.Fatalf("ValidateToken = %v, se esperaba ErrSecretMissing", err)
+	}
+}
+
+func TestValidatorFromAuthServiceRefrescaYSeDetiene(t *testing.T) {
+	var peticiones atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/auth/validation-secret" || r.Header.Get("X-Service-Key") != "clave" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		peticiones.Add(1)
+		json.NewEncoder(w).Encode(map[string]string{"secret": secretoPrueba, "algorithm": "HS256"})
+	}))
+	defer server.Close()
+
+	v := NewValidatorFromAuthService(server.URL+"/", "clave", 10*time.Millisecond)
+	if _, err := v.ValidateToken(firmar(t, secretoPrueba, hs256, claimsValidos())); err != nil {
+		t.Fatalf("ValidateToken con el secreto del auth-service: %v", err)
+	}
+
+	plazo := time.Now().Add(2 * time.Second)
+	for peticiones.Load() < 3 {
+		if time.Now().After(plazo) {
+			t.Fatalf("el secreto se descargó %d veces, se esperaban refrescos periódicos", peticiones.Load())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	v.Stop()
+	v.Stop()
+	// Un refresco en curso al detener puede terminar; después no hay más
+	time.Sleep(30 * time.Millisecond)
+	tras := peticiones.Load()
+	time.Sleep(50 * time.Millisecond)
+	if n := peticiones.Load(); n != tras {
+		t.Errorf("el secreto se siguió refrescando tras Stop (%d → %d)", tras, n)
+	}
+}
+
+func TestValidatorStopSinRefresco(t *testing.T) {
+	var nilValidator *Validator
+	nilValidator.Stop()
+	NewValidator(secretoPrueba).Stop()
+}
@@ -0,0 +1,71 @@
+package backplane
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// TypeReconnect tipo del envelope que un backplane entrega localmente (sin Origin) al
+// recuperar la conexión: los eventos publicados mientras estuvo caída se perdieron
+const TypeReconnect = "backplane_reconnect"
+
+// Envelope evento difundido entre instancias del servidor WebSocket
+type Envelope struct {
+	Origin  string          `json:"origin"`        // instancia que publicó el evento
+	Type    string          `json:"type"`          // tipo de mensaje WebSocket (espacio_ocupado, ...)
+	Topics  []string        `json:"topics"`        // tópicos a los que se enruta
+	Key     string          `json:"key,omitempty"` // clave de idempotencia para descartar duplicados
+	Payload json.RawMessage `json:"payload"`
+}
+
+// Backplane distribuye los eventos del Hub a todas las instancias. Cada instancia
+// entrega sus propios eventos localmente y solo usa el backplane para las demás,
+// por lo que los suscriptores deben ignorar los envelopes con su propio Origin.
+type Backplane interface {
+	// Publish envía el evento al resto de instancias
+	Publish(ctx context.Context, env Envelope) error
+
+	// Subscribe registra la función que recibe los eventos publicados por cualquier instancia
+	Subscribe(handler func(Envelope))
+
+	// Close libera los recursos del backplane
+	Close() error
+}
+
+// Memory backplane en memoria: distribuye entre los Hubs del mismo proceso.
+// Es el modo por defecto con una sola réplica.
+type Memory struct {
+	mu       sync.RWMutex
+	handlers []func(Envelope)
+}
+
+// NewMemory crea un backplane en memoria
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+// Publish entrega el evento a todos los suscriptores del proceso
+func (m *Memory) Publish(ctx context.Context, env Envelope) error {
+	m.mu.RLock()
+	handlers := make([]func(Envelope), len(m.handlers))
+	copy(handlers, m.handlers)
+	m.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(env)
+	}
+	return nil
+}
+
+// Subscribe registra un suscriptor
+func (m *Memory) Subscribe(handler func(Envelope)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers = append(m.handlers, handler)
+}
+
+// Close no necesita liberar nada
+func (m *Memory) Close() error {
+	return nil
+}
@@ -0,0 +1,97 @@
+package backplane
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/josedavid1945/estacionamiento-websocket/pkg/database"
+)
+
+// maxNotifyPayload límite de PostgreSQL para el payload de NOTIFY (8000 bytes)
+const maxNotifyPayload = 8000
+
+// Postgres backplane sobre PostgreSQL NOTIFY / LISTEN en un canal dedicado.
+// Cualquier instancia publica con pg_notify y todas reciben por su conexión LISTEN.
+type Postgres struct {
+	db       *sql.DB
+	channel  string
+	listener *database.Listener
+	cancel   context.CancelFunc
+
+	mu       sync.RWMutex
+	handlers []func(Envelope)
+}
+
+// NewPostgres abre la conexión LISTEN en el canal y empieza a despachar eventos
+func NewPostgres(db *sql.DB, databaseURL, channel string) (*Postgres, error) {
+	listener, err := database.NewListener(databaseURL, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Postgres{
+		db:       db,
+		channel:  channel,
+		listener: listener,
+		cancel:   cancel,
+	}
+
+	go listener.Listen(ctx, p.dispatch)
+	return p, nil
+}
+
+// Publish envía el evento con pg_notify
+func (p *Postgres) Publish(ctx context.Context, env Envelope) error {
+	payload, err := json.Marshal(env)
+	if err != nil {
+		return fmt.Errorf("error serializando evento %s: %w", env.Type, err)
+	}
+	if len(payload) > maxNotifyPayload {
+		return fmt.Errorf("evento %s de %d bytes excede el límite de NOTIFY", env.Type, len(payload))
+	}
+
+	if _, err := p.db.ExecContext(ctx, "SELECT pg_notify($1, $2)", p.channel, string(payload)); err != nil {
+		return fmt.Errorf("error publicando evento %s en backplane: %w", env.Type, err)
+	}
+	return nil
+}
+
+// Subscribe registra un suscriptor
+func (p *Postgres) Subscribe(handler func(Envelope)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers = append(p.handlers, handler)
+}
+
+// dispatch decodifica una notificación y la entrega a los suscriptores
+func (p *Postgres) dispatch(payload string) {
+	var env Envelope
+	if payload == "" {
+		// Reconexión del LISTEN: los eventos del intervalo se perdieron y los
+		// suscriptores deben pedir a sus clientes que resincronicen
+		env = Envelope{Type: TypeReconnect}
+	} else if err := json.Unmarshal([]byte(payload), &env); err != nil {
+		log.Printf("Error al parsear evento del backplane: %v", err)
+		return
+	}
+
+	p.mu.RLock()
+	handlers := make([]func(Envelope), len(p.handlers))
+	copy(handlers, p.handlers)
+	p.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(env)
+	}
+}
+
+// Close detiene la escucha
+func (p *Postgres) Close() error {
+	p.cancel()
+	return p.listener.Close()
+}
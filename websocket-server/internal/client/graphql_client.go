@@ -0,0 +1,545 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/tarifa"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/metrics"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/repository/interfaces"
+)
+
+// GraphQLClient fuente de datos del dashboard sobre el graphql-service. Cada vista
+// se resuelve con un solo documento que pide todos los recursos que necesita.
+type GraphQLClient struct {
+	endpoint   string
+	httpClient *http.Client
+
+	// historialCache todos los tickets para la búsqueda paginada (busquedaCacheTTL)
+	historialCache *recursoCache[[]models.Ticket]
+}
+
+// GraphQLClient implementa la fuente de datos del dashboard
+var _ interfaces.DataSource = (*GraphQLClient)(nil)
+
+// NewGraphQLClient crea el cliente para el endpoint indicado (por ejemplo http://localhost:8000/graphql)
+func NewGraphQLClient(endpoint string) *GraphQLClient {
+	return &GraphQLClient{
+		endpoint: endpoint,
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+			// Las consultas van por POST: no se reintentan, solo circuit breaker y métricas
+			Transport: &breakerTransport{
+				name: "graphql-service",
+				base: metrics.NewTransport(http.DefaultTransport, ""),
+			},
+		},
+		historialCache: &recursoCache[[]models.Ticket]{ttl: busquedaCacheTTL},
+	}
+}
+
+// Name identifica la fuente en logs
+func (c *GraphQLClient) Name() string {
+	return "graphql"
+}
+
+// graphqlError error reportado por el servidor en el campo errors de la respuesta
+type graphqlError struct {
+	Message string `json:"message"`
+}
+
+// query ejecuta document con sus variables y decodifica el campo data en out
+func (c *GraphQLClient) query(ctx context.Context, document string, variables map[string]interface{}, out interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"query":     document,
+		"variables": variables,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("graphql-service respondió con status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var envelope struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []graphqlError  `json:"errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("error al decodificar respuesta de graphql-service: %w", err)
+	}
+
+	// Un error en cualquier campo invalida la vista completa: no se sirven datos parciales
+	if len(envelope.Errors) > 0 {
+		mensajes := make([]string, 0, len(envelope.Errors))
+		for _, e := range envelope.Errors {
+			mensajes = append(mensajes, e.Message)
+		}
+		return fmt.Errorf("graphql-service: %s", strings.Join(mensajes, "; "))
+	}
+	if len(envelope.Data) == 0 || string(envelope.Data) == "null" {
+		return errors.New("graphql-service respondió sin datos")
+	}
+
+	return json.Unmarshal(envelope.Data, out)
+}
+
+// gqlEspacio espacio del graphql-service; estado es "Disponible" u "Ocupado"
+type gqlEspacio struct {
+	ID     string `json:"id"`
+	Numero string `json:"numero"`
+	Estado string `json:"estado"`
+}
+
+// disponible indica si el espacio está libre
+func (e gqlEspacio) disponible() bool {
+	return e.Estado == "Disponible"
+}
+
+// gqlSeccion sección con sus espacios, ordenados por número
+type gqlSeccion struct {
+	LetraSeccion string       `json:"letraSeccion"`
+	Espacios     []gqlEspacio `json:"espacios"`
+}
+
+// gqlTicket ticket del graphql-service con las relaciones pedidas en cada documento
+type gqlTicket struct {
+	ID           string  `json:"id"`
+	FechaIngreso string  `json:"fechaIngreso"`
+	FechaSalida  *string `json:"fechaSalida"`
+	Vehiculo     struct {
+		ID     string `json:"id"`
+		Placa  string `json:"placa"`
+		Marca  string `json:"marca"`
+		Modelo string `json:"modelo"`
+	} `json:"vehiculo"`
+	Espacio struct {
+		ID     string `json:"id"`
+		Numero string `json:"numero"`
+	} `json:"espacio"`
+	DetallePago *struct {
+		ID string `json:"id"`
+	} `json:"detallePago"`
+}
+
+// dashboardQuery conteos de espacios, tickets abiertos y pagos en una sola petición
+const dashboardQuery = `query Dashboard {
+  secciones { espacios { estado } }
+  tickets { fechaSalida }
+  detallePagos { pagoTotal fechaPago }
+}`
+
+// GetDashboardData calcula las estadísticas del dashboard con una sola consulta.
+// graphql-service no expone multas: multas_pendientes queda en cero en este modo.
+func (c *GraphQLClient) GetDashboardData(ctx context.Context) (*models.DashboardData, error) {
+	var data struct {
+		Secciones []gqlSeccion `json:"secciones"`
+		Tickets   []gqlTicket  `json:"tickets"`
+		Pagos     []struct {
+			PagoTotal float64 `json:"pagoTotal"`
+			FechaPago string  `json:"fechaPago"`
+		} `json:"detallePagos"`
+	}
+	if err := c.query(ctx, dashboardQuery, nil, &data); err != nil {
+		return nil, fmt.Errorf("error al obtener dashboard de graphql-service: %w", err)
+	}
+
+	dashboard := &models.DashboardData{Timestamp: time.Now()}
+	for _, seccion := range data.Secciones {
+		for _, espacio := range seccion.Espacios {
+			dashboard.TotalEspacios++
+			if espacio.disponible() {
+				dashboard.EspaciosDisponibles++
+			} else {
+				dashboard.EspaciosOcupados++
+			}
+		}
+	}
+
+	for _, ticket := range data.Tickets {
+		if ticket.FechaSalida == nil {
+			dashboard.VehiculosActivos++
+		}
+	}
+
+	ahora := time.Now()
+	hoy := time.Date(ahora.Year(), ahora.Month(), ahora.Day(), 0, 0, 0, 0, ahora.Location())
+	inicioMes := time.Date(ahora.Year(), ahora.Month(), 1, 0, 0, 0, 0, ahora.Location())
+	for _, pago := range data.Pagos {
+		fecha, err := parseFechaPago(pago.FechaPago)
+		if err != nil {
+			continue
+		}
+		if !fecha.Before(hoy) {
+			dashboard.DineroRecaudadoHoy += pago.PagoTotal
+		}
+		if !fecha.Before(inicioMes) {
+			dashboard.DineroRecaudadoMes += pago.PagoTotal
+		}
+	}
+
+	return dashboard, nil
+}
+
+// seccionesQuery secciones con sus espacios y los tickets para completar placa y hora de ingreso
+const seccionesQuery = `query EspaciosPorSeccion {
+  secciones { letraSeccion espacios { id numero estado } }
+  tickets { fechaIngreso fechaSalida vehiculo { id placa } espacio { id } }
+}`
+
+// GetEspaciosPorSeccion obtiene la vista por sección, con placa y hora de ingreso de
+// los espacios ocupados, en una consulta. La tarifa de cada vehículo se consulta
+// aparte (getTiposVehiculos): sin ella los espacios se envían sin cobro acumulado.
+func (c *GraphQLClient) GetEspaciosPorSeccion(ctx context.Context) ([]models.EspaciosPorSeccion, error) {
+	var data struct {
+		Secciones []gqlSeccion `json:"secciones"`
+		Tickets   []gqlTicket  `json:"tickets"`
+	}
+	if err := c.query(ctx, seccionesQuery, nil, &data); err != nil {
+		return nil, fmt.Errorf("error al obtener espacios por sección de graphql-service: %w", err)
+	}
+
+	tipos := c.getTiposVehiculos(ctx)
+
+	// Ticket abierto de cada espacio ocupado
+	abiertos := make(map[string]gqlTicket)
+	for _, ticket := range data.Tickets {
+		if ticket.FechaSalida == nil {
+			abiertos[ticket.Espacio.ID] = ticket
+		}
+	}
+
+	secciones := make([]models.EspaciosPorSeccion, 0, len(data.Secciones))
+	for _, seccion := range data.Secciones {
+		agrupada := models.EspaciosPorSeccion{
+			SeccionLetra:  seccion.LetraSeccion,
+			TotalEspacios: len(seccion.Espacios),
+			Espacios:      make([]models.EspacioDetalle, 0, len(seccion.Espacios)),
+		}
+		for _, espacio := range seccion.Espacios {
+			detalle := espacio.toDetalle(seccion.LetraSeccion)
+			if detalle.Estado {
+				agrupada.EspaciosDisponibles++
+			} else {
+				agrupada.EspaciosOcupados++
+				if ticket, ok := abiertos[espacio.ID]; ok {
+					placa := ticket.Vehiculo.Placa
+					detalle.VehiculoPlaca = &placa
+					if fecha, err := parseFechaPago(ticket.FechaIngreso); err == nil {
+						hora := fecha.Format(time.RFC3339)
+						detalle.HoraIngreso = &hora
+					}
+					if tipo, ok := tipos[ticket.Vehiculo.ID]; ok {
+						detalle.Tarifa = &tipo.Tarifa
+					}
+				}
+			}
+			agrupada.Espacios = append(agrupada.Espacios, detalle)
+		}
+		secciones = append(secciones, agrupada)
+	}
+
+	return secciones, nil
+}
+
+// toDetalle convierte el espacio del graphql-service al detalle del dashboard
+func (e gqlEspacio) toDetalle(seccionLetra string) models.EspacioDetalle {
+	return models.EspacioDetalle{
+		ID:           e.ID,
+		Numero:       e.Numero,
+		Estado:       e.disponible(),
+		SeccionLetra: seccionLetra,
+	}
+}
+
+// disponiblesQuery espacios de cada sección con su estado
+const disponiblesQuery = `query EspaciosDisponibles {
+  secciones { letraSeccion espacios { id numero estado } }
+}`
+
+// GetEspaciosDisponibles obtiene los espacios libres con la letra de su sección
+func (c *GraphQLClient) GetEspaciosDisponibles(ctx context.Context) ([]models.EspacioDetalle, error) {
+	var data struct {
+		Secciones []gqlSeccion `json:"secciones"`
+	}
+	if err := c.query(ctx, disponiblesQuery, nil, &data); err != nil {
+		return nil, fmt.Errorf("error al obtener espacios disponibles de graphql-service: %w", err)
+	}
+
+	espacios := []models.EspacioDetalle{}
+	for _, seccion := range data.Secciones {
+		for _, espacio := range seccion.Espacios {
+			if espacio.disponible() {
+				espacios = append(espacios, espacio.toDetalle(seccion.LetraSeccion))
+			}
+		}
+	}
+	sort.Slice(espacios, func(i, j int) bool { return espacios[i].Numero < espacios[j].Numero })
+
+	return espacios, nil
+}
+
+// ticketsQuery tickets con vehículo y espacio, y las secciones para la letra de cada espacio
+const ticketsQuery = `query TicketsActivos {
+  tickets { id fechaIngreso fechaSalida vehiculo { id placa marca modelo } espacio { id numero } detallePago { id } }
+  secciones { letraSeccion espacios { id } }
+}`
+
+// GetTicketsActivos obtiene los tickets sin fecha de salida con su vehículo, su espacio
+// y la sección. Categoría y tarifa del vehículo se consultan aparte (getTiposVehiculos).
+func (c *GraphQLClient) GetTicketsActivos(ctx context.Context) ([]models.Ticket, error) {
+	return c.getTickets(ctx, func(t gqlTicket) bool { return t.FechaSalida == nil })
+}
+
+// getTickets obtiene los tickets que cumplen incluir con la vista enriquecida. Un
+// ticket abierto con fecha de ingreso inválida es un error; uno cerrado se descarta.
+func (c *GraphQLClient) getTickets(ctx context.Context, incluir func(gqlTicket) bool) ([]models.Ticket, error) {
+	var data struct {
+		Tickets   []gqlTicket  `json:"tickets"`
+		Secciones []gqlSeccion `json:"secciones"`
+	}
+	if err := c.query(ctx, ticketsQuery, nil, &data); err != nil {
+		return nil, fmt.Errorf("error al obtener tickets de graphql-service: %w", err)
+	}
+
+	seccionPorEspacio := make(map[string]string)
+	for _, seccion := range data.Secciones {
+		for _, espacio := range seccion.Espacios {
+			seccionPorEspacio[espacio.ID] = seccion.LetraSeccion
+		}
+	}
+	tipos := c.getTiposVehiculos(ctx)
+
+	tickets := []models.Ticket{}
+	for _, t := range data.Tickets {
+		if !incluir(t) {
+			continue
+		}
+		fechaIngreso, err := parseFechaPago(t.FechaIngreso)
+		if err != nil {
+			if t.FechaSalida != nil {
+				continue
+			}
+			return nil, fmt.Errorf("ticket %s: %w", t.ID, err)
+		}
+		ticket := models.Ticket{
+			ID:             t.ID,
+			FechaIngreso:   fechaIngreso,
+			VehiculoID:     t.Vehiculo.ID,
+			EspacioID:      t.Espacio.ID,
+			VehiculoPlaca:  t.Vehiculo.Placa,
+			VehiculoMarca:  t.Vehiculo.Marca,
+			VehiculoModelo: t.Vehiculo.Modelo,
+			EspacioNumero:  t.Espacio.Numero,
+			SeccionLetra:   seccionPorEspacio[t.Espacio.ID],
+		}
+		if t.FechaSalida != nil {
+			fechaSalida, err := parseFechaPago(*t.FechaSalida)
+			if err != nil {
+				continue
+			}
+			ticket.FechaSalida = &fechaSalida
+		}
+		if t.DetallePago != nil {
+			id := t.DetallePago.ID
+			ticket.DetallePagoID = &id
+		}
+		if tipo, ok := tipos[t.Vehiculo.ID]; ok {
+			ticket.VehiculoCategoria = tipo.Categoria
+			ticket.Tarifa = &tipo.Tarifa
+		}
+		tickets = append(tickets, ticket)
+	}
+
+	return tickets, nil
+}
+
+// gqlTipoVehiculo categoría y tarifa del tipo de un vehículo
+type gqlTipoVehiculo struct {
+	Categoria string
+	Tarifa    tarifa.Tarifa
+}
+
+// tiposVehiculosQuery categoría y tarifa del tipo de cada vehículo
+const tiposVehiculosQuery = `query TiposVehiculos {
+  vehiculosCompletos { id tipoVehiculo { categoria tipotarifa { precioHora precioDia } } }
+}`
+
+// getTiposVehiculos obtiene categoría y tarifa de cada vehículo por ID. Se consulta
+// aparte para que un error en vehiculosCompletos solo deje los tickets sin categoría
+// ni cobro acumulado.
+func (c *GraphQLClient) getTiposVehiculos(ctx context.Context) map[string]gqlTipoVehiculo {
+	var data struct {
+		Vehiculos []struct {
+			ID           string `json:"id"`
+			TipoVehiculo struct {
+				Categoria string `json:"categoria"`
+				Tarifa    struct {
+					PrecioHora float64 `json:"precioHora"`
+					PrecioDia  float64 `json:"precioDia"`
+				} `json:"tipotarifa"`
+			} `json:"tipoVehiculo"`
+		} `json:"vehiculosCompletos"`
+	}
+	if err := c.query(ctx, tiposVehiculosQuery, nil, &data); err != nil {
+		log.Printf("⚠️  Sin tipos de vehículo de graphql-service (categoría y cobro acumulado): %v", err)
+		return nil
+	}
+
+	tipos := make(map[string]gqlTipoVehiculo, len(data.Vehiculos))
+	for _, v := range data.Vehiculos {
+		precios := v.TipoVehiculo.Tarifa
+		tipos[v.ID] = gqlTipoVehiculo{
+			Categoria: v.TipoVehiculo.Categoria,
+			Tarifa:    tarifa.Tarifa{PrecioHora: precios.PrecioHora, PrecioDia: precios.PrecioDia},
+		}
+	}
+	return tipos
+}
+
+// vehiculosPlacaQuery vehículos con su placa y categoría para la búsqueda por placa
+const vehiculosPlacaQuery = `query VehiculosPlaca {
+  vehiculosCompletos { id placa marca modelo tipoVehiculo { categoria } }
+}`
+
+// BuscarPlaca busca los vehículos por placa en vehiculosCompletos (incluye los que
+// nunca ingresaron) y reparte los tickets de los encontrados
+func (c *GraphQLClient) BuscarPlaca(ctx context.Context, placa string, limite, visitas int) ([]models.ResultadoPlaca, error) {
+	var data struct {
+		Vehiculos []struct {
+			ID           string `json:"id"`
+			Placa        string `json:"placa"`
+			Marca        string `json:"marca"`
+			Modelo       string `json:"modelo"`
+			TipoVehiculo struct {
+				Categoria string `json:"categoria"`
+			} `json:"tipoVehiculo"`
+		} `json:"vehiculosCompletos"`
+	}
+	if err := c.query(ctx, vehiculosPlacaQuery, nil, &data); err != nil {
+		return nil, fmt.Errorf("error al buscar placa en graphql-service: %w", err)
+	}
+
+	resultados := []models.ResultadoPlaca{}
+	for _, v := range data.Vehiculos {
+		if !models.CoincidePlaca(v.Placa, placa) {
+			continue
+		}
+		resultados = append(resultados, models.ResultadoPlaca{
+			VehiculoID: v.ID,
+			Placa:      v.Placa,
+			Marca:      v.Marca,
+			Modelo:     v.Modelo,
+			Categoria:  v.TipoVehiculo.Categoria,
+		})
+	}
+	models.OrdenarResultadosPlaca(resultados, placa)
+	if len(resultados) > limite {
+		resultados = resultados[:limite]
+	}
+	if len(resultados) == 0 {
+		return resultados, nil
+	}
+
+	encontrados := make(map[string]bool, len(resultados))
+	for _, resultado := range resultados {
+		encontrados[resultado.VehiculoID] = true
+	}
+	tickets, err := c.getTickets(ctx, func(t gqlTicket) bool { return encontrados[t.Vehiculo.ID] })
+	if err != nil {
+		return nil, err
+	}
+
+	models.AsignarTickets(resultados, tickets, visitas)
+	return resultados, nil
+}
+
+// BuscarTickets busca entre todos los tickets del graphql-service y pagina en memoria.
+// Los tickets se reutilizan durante busquedaCacheTTL entre las páginas de una búsqueda.
+func (c *GraphQLClient) BuscarTickets(ctx context.Context, filtro models.FiltroBusquedaTickets, despues *models.CursorTickets, limite int, ahora time.Time) ([]models.Ticket, error) {
+	tickets, err := c.historialCache.get(ctx, func(ctx context.Context) ([]models.Ticket, error) {
+		return c.getTickets(ctx, func(gqlTicket) bool { return true })
+	})
+	if err != nil {
+		return nil, err
+	}
+	return models.PaginarTickets(tickets, filtro, despues, limite, ahora), nil
+}
+
+// GetTicketsActivosByAuthUser no está disponible: el esquema GraphQL no expone el
+// usuario del auth-service vinculado a cada cliente
+func (c *GraphQLClient) GetTicketsActivosByAuthUser(ctx context.Context, authUserID string) ([]models.Ticket, error) {
+	return nil, fmt.Errorf("%w: graphql-service no permite filtrar tickets por usuario", interfaces.ErrNotSupported)
+}
+
+// GetDesgloseIngresos no está disponible: el esquema GraphQL no relaciona los pagos
+// con su tipo de tarifa
+func (c *GraphQLClient) GetDesgloseIngresos(ctx context.Context, desde, hasta time.Time) (*models.DesgloseIngresos, error) {
+	return nil, fmt.Errorf("%w: graphql-service no expone la tarifa de los pagos", interfaces.ErrNotSupported)
+}
+
+const flujoQuery = `query FlujoTickets {
+  secciones { letraSeccion espacios { id } }
+  tickets { fechaIngreso fechaSalida espacio { id } }
+}`
+
+// GetFlujoHorario cuenta ingresos y salidas de [desde, hasta) por sección y hora a
+// partir de todos los tickets
+func (c *GraphQLClient) GetFlujoHorario(ctx context.Context, desde, hasta time.Time) ([]models.FlujoHora, error) {
+	var data struct {
+		Secciones []gqlSeccion `json:"secciones"`
+		Tickets   []gqlTicket  `json:"tickets"`
+	}
+	if err := c.query(ctx, flujoQuery, nil, &data); err != nil {
+		return nil, fmt.Errorf("error al obtener tickets de graphql-service: %w", err)
+	}
+
+	letraEspacio := make(map[string]string)
+	for _, seccion := range data.Secciones {
+		for _, espacio := range seccion.Espacios {
+			letraEspacio[espacio.ID] = seccion.LetraSeccion
+		}
+	}
+
+	movimientos := make([]models.MovimientoTicket, 0, len(data.Tickets))
+	for _, t := range data.Tickets {
+		fechaIngreso, err := parseFechaPago(t.FechaIngreso)
+		if err != nil {
+			continue
+		}
+		movimiento := models.MovimientoTicket{SeccionLetra: letraEspacio[t.Espacio.ID], FechaIngreso: fechaIngreso}
+		if t.FechaSalida != nil {
+			if fechaSalida, err := parseFechaPago(*t.FechaSalida); err == nil {
+				movimiento.FechaSalida = &fechaSalida
+			}
+		}
+		movimientos = append(movimientos, movimiento)
+	}
+
+	return models.AgruparFlujo(movimientos, desde, hasta), nil
+}
@@ -0,0 +1,369 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/repository/interfaces"
+)
+
+// gqlStub graphql-service de prueba. Responde cada operación (el nombre tras "query")
+// con su respuesta en respuestas o, si no tiene, con data completo. Registra las
+// operaciones recibidas en orden.
+type gqlStub struct {
+	*httptest.Server
+
+	mu          sync.Mutex
+	operaciones []string
+}
+
+// gqlRespuesta respuesta de una operación del stub
+type gqlRespuesta struct {
+	status int
+	body   string
+}
+
+func newGQLStub(t *testing.T, data string, respuestas map[string]gqlRespuesta) *gqlStub {
+	t.Helper()
+	stub := &gqlStub{}
+	stub.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("petición %s con Content-Type %q, se esperaba POST JSON", r.Method, r.Header.Get("Content-Type"))
+		}
+		var req struct {
+			Query string `json:"query"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("cuerpo inválido: %v", err)
+		}
+		campos := strings.Fields(req.Query)
+		if len(campos) < 2 || campos[0] != "query" {
+			t.Errorf("documento sin nombre de operación: %q", req.Query)
+			return
+		}
+		operacion := campos[1]
+
+		stub.mu.Lock()
+		stub.operaciones = append(stub.operaciones, operacion)
+		stub.mu.Unlock()
+
+		respuesta, ok := respuestas[operacion]
+		if !ok {
+			respuesta = gqlRespuesta{status: http.StatusOK, body: `{"data":` + data + `}`}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(respuesta.status)
+		w.Write([]byte(respuesta.body))
+	}))
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+// recibidas operaciones recibidas hasta ahora
+func (s *gqlStub) recibidas() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.operaciones...)
+}
+
+// gqlFixture estado del graphql-service: sección A con un espacio libre (1) y uno
+// ocupado (2), sección B con uno ocupado (10); ABC-123 y XYZ-9 estacionados, ABC-999
+// sin tickets; una visita cerrada y pagada de ABC-123
+func gqlFixture(ahora time.Time) string {
+	fecha := func(t time.Time) string { return t.Format(time.RFC3339) }
+	ayer := ahora.AddDate(0, 0, -1)
+	inicioMes := time.Date(ahora.Year(), ahora.Month(), 1, 0, 0, 0, 0, ahora.Location())
+
+	data := map[string]interface{}{
+		"secciones": []interface{}{
+			map[string]interface{}{"letraSeccion": "A", "espacios": []interface{}{
+				map[string]interface{}{"id": "e1", "numero": "1", "estado": "Disponible"},
+				map[string]interface{}{"id": "e2", "numero": "2", "estado": "Ocupado"},
+			}},
+			map[string]interface{}{"letraSeccion": "B", "espacios": []interface{}{
+				map[string]interface{}{"id": "e3", "numero": "10", "estado": "Ocupado"},
+			}},
+		},
+		"tickets": []interface{}{
+			map[string]interface{}{
+				"id": "t1", "fechaIngreso": fecha(ahora.Add(-90 * time.Minute)), "fechaSalida": nil,
+				"vehiculo": map[string]interface{}{"id": "v1", "placa": "ABC-123", "marca": "Toyota", "modelo": "Yaris"},
+				"espacio":  map[string]interface{}{"id": "e2", "numero": "2"},
+			},
+			map[string]interface{}{
+				"id": "t2", "fechaIngreso": fecha(ahora.Add(-30 * time.Minute)), "fechaSalida": nil,
+				"vehiculo": map[string]interface{}{"id": "v2", "placa": "XYZ-9", "marca": "Honda", "modelo": "CB"},
+				"espacio":  map[string]interface{}{"id": "e3", "numero": "10"},
+			},
+			map[string]interface{}{
+				"id": "t3", "fechaIngreso": fecha(ayer), "fechaSalida": fecha(ayer.Add(2 * time.Hour)),
+				"vehiculo":    map[string]interface{}{"id": "v1", "placa": "ABC-123", "marca": "Toyota", "modelo": "Yaris"},
+				"espacio":     map[string]interface{}{"id": "e1", "numero": "1"},
+				"detallePago": map[string]interface{}{"id": "dp1"},
+			},
+		},
+		"detallePagos": []interface{}{
+			map[string]interface{}{"pagoTotal": 5, "fechaPago": fecha(ahora)},
+			map[string]interface{}{"pagoTotal": 7, "fechaPago": fecha(inicioMes.AddDate(0, 0, -1))},
+			map[string]interface{}{"pagoTotal": 100, "fechaPago": "no es fecha"},
+		},
+		"vehiculosCompletos": []interface{}{
+			map[string]interface{}{"id": "v1", "placa": "ABC-123", "marca": "Toyota", "modelo": "Yaris",
+				"tipoVehiculo": map[string]interface{}{"categoria": "Auto", "tipotarifa": map[string]interface{}{"precioHora": 1.5, "precioDia": 10}}},
+			map[string]interface{}{"id": "v2", "placa": "XYZ-9", "marca": "Honda", "modelo": "CB",
+				"tipoVehiculo": map[string]interface{}{"categoria": "Moto", "tipotarifa": map[string]interface{}{"precioHora": 0.5, "precioDia": 4}}},
+			map[string]interface{}{"id": "v3", "placa": "ABC-999", "marca": "Kia", "modelo": "Rio",
+				"tipoVehiculo": map[string]interface{}{"categoria": "Auto", "tipotarifa": map[string]interface{}{"precioHora": 1.5, "precioDia": 10}}},
+		},
+	}
+	raw, _ := json.Marshal(data)
+	return string(raw)
+}
+
+func TestGraphQLDashboard(t *testing.T) {
+	stub := newGQLStub(t, gqlFixture(time.Now()), nil)
+	c := NewGraphQLClient(stub.URL)
+
+	data, err := c.GetDashboardData(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.TotalEspacios != 3 || data.EspaciosDisponibles != 1 || data.EspaciosOcupados != 2 {
+		t.Errorf("espacios = %d total, %d disponibles, %d ocupados; se esperaba 3, 1, 2", data.TotalEspacios, data.EspaciosDisponibles, data.EspaciosOcupados)
+	}
+	if data.VehiculosActivos != 2 {
+		t.Errorf("VehiculosActivos = %d, se esperaba 2", data.VehiculosActivos)
+	}
+	if data.DineroRecaudadoHoy != 5 || data.DineroRecaudadoMes != 5 {
+		t.Errorf("recaudado hoy %v / mes %v, se esperaba 5 / 5", data.DineroRecaudadoHoy, data.DineroRecaudadoMes)
+	}
+	if got := stub.recibidas(); len(got) != 1 || got[0] != "Dashboard" {
+		t.Errorf("operaciones = %v, se esperaba [Dashboard]", got)
+	}
+}
+
+func TestGraphQLEspaciosPorSeccion(t *testing.T) {
+	stub := newGQLStub(t, gqlFixture(time.Now()), nil)
+	c := NewGraphQLClient(stub.URL)
+
+	secciones, err := c.GetEspaciosPorSeccion(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secciones) != 2 {
+		t.Fatalf("%d secciones, se esperaban 2", len(secciones))
+	}
+	a := secciones[0]
+	if a.SeccionLetra != "A" || a.TotalEspacios != 2 || a.EspaciosDisponibles != 1 || a.EspaciosOcupados != 1 {
+		t.Errorf("sección A = %+v", a)
+	}
+	ocupado := a.Espacios[1]
+	if ocupado.VehiculoPlaca == nil || *ocupado.VehiculoPlaca != "ABC-123" || ocupado.HoraIngreso == nil {
+		t.Errorf("espacio ocupado sin placa u hora de ingreso: %+v", ocupado)
+	}
+	if ocupado.Tarifa == nil || ocupado.Tarifa.PrecioHora != 1.5 {
+		t.Errorf("espacio ocupado sin tarifa del vehículo: %+v", ocupado.Tarifa)
+	}
+	if libre := a.Espacios[0]; libre.VehiculoPlaca != nil || !libre.Estado {
+		t.Errorf("espacio libre = %+v", libre)
+	}
+	if got := stub.recibidas(); strings.Join(got, ",") != "EspaciosPorSeccion,TiposVehiculos" {
+		t.Errorf("operaciones = %v", got)
+	}
+}
+
+func TestGraphQLEspaciosPorSeccionSinTipos(t *testing.T) {
+	stub := newGQLStub(t, gqlFixture(time.Now()), map[string]gqlRespuesta{
+		"TiposVehiculos": {http.StatusOK, `{"data":null,"errors":[{"message":"KeyError: cliente"}]}`},
+	})
+	c := NewGraphQLClient(stub.URL)
+
+	secciones, err := c.GetEspaciosPorSeccion(context.Background())
+	if err != nil {
+		t.Fatalf("un error en los tipos no debe invalidar la vista: %v", err)
+	}
+	if ocupado := secciones[0].Espacios[1]; ocupado.VehiculoPlaca == nil || ocupado.Tarifa != nil {
+		t.Errorf("se esperaba placa sin tarifa: %+v", ocupado)
+	}
+}
+
+func TestGraphQLEspaciosDisponibles(t *testing.T) {
+	stub := newGQLStub(t, gqlFixture(time.Now()), nil)
+	c := NewGraphQLClient(stub.URL)
+
+	espacios, err := c.GetEspaciosDisponibles(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(espacios) != 1 || espacios[0].ID != "e1" || espacios[0].SeccionLetra != "A" || !espacios[0].Estado {
+		t.Errorf("disponibles = %+v, se esperaba solo e1 de la sección A", espacios)
+	}
+}
+
+func TestGraphQLTicketsActivos(t *testing.T) {
+	stub := newGQLStub(t, gqlFixture(time.Now()), nil)
+	c := NewGraphQLClient(stub.URL)
+
+	tickets, err := c.GetTicketsActivos(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tickets) != 2 {
+		t.Fatalf("%d tickets activos, se esperaban 2", len(tickets))
+	}
+	t1 := tickets[0]
+	if t1.ID != "t1" || t1.FechaSalida != nil || t1.VehiculoPlaca != "ABC-123" || t1.EspacioNumero != "2" || t1.SeccionLetra != "A" {
+		t.Errorf("t1 = %+v", t1)
+	}
+	if t1.VehiculoCategoria != "Auto" || t1.Tarifa == nil || t1.Tarifa.PrecioDia != 10 {
+		t.Errorf("t1 sin categoría o tarifa: %+v", t1)
+	}
+	if tickets[1].SeccionLetra != "B" || tickets[1].VehiculoCategoria != "Moto" {
+		t.Errorf("t2 = %+v", tickets[1])
+	}
+}
+
+func TestGraphQLTicketsActivosFechaInvalida(t *testing.T) {
+	data := `{"tickets":[{"id":"t1","fechaIngreso":"ayer","fechaSalida":null,"vehiculo":{"id":"v1"},"espacio":{"id":"e1"}}],"secciones":[]}`
+	stub := newGQLStub(t, data, nil)
+	c := NewGraphQLClient(stub.URL)
+
+	if _, err := c.GetTicketsActivos(context.Background()); err == nil {
+		t.Error("se esperaba error por ticket abierto con fecha de ingreso inválida")
+	}
+}
+
+func TestGraphQLBuscarPlaca(t *testing.T) {
+	stub := newGQLStub(t, gqlFixture(time.Now()), nil)
+	c := NewGraphQLClient(stub.URL)
+
+	resultados, err := c.BuscarPlaca(context.Background(), "ABC", 10, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resultados) != 2 {
+		t.Fatalf("%d resultados, se esperaban ABC-123 y ABC-999", len(resultados))
+	}
+	estacionado, sinTickets := resultados[0], resultados[1]
+	if estacionado.Placa != "ABC-123" || !estacionado.Estacionado || estacionado.TicketActivo == nil || estacionado.TicketActivo.ID != "t1" {
+		t.Errorf("ABC-123 = %+v", estacionado)
+	}
+	if len(estacionado.Visitas) != 1 || estacionado.Visitas[0].ID != "t3" {
+		t.Errorf("visitas de ABC-123 = %+v, se esperaba t3", estacionado.Visitas)
+	}
+	if sinTickets.Placa != "ABC-999" || sinTickets.Estacionado || sinTickets.Categoria != "Auto" || len(sinTickets.Visitas) != 0 {
+		t.Errorf("el vehículo sin tickets debe aparecer: %+v", sinTickets)
+	}
+
+	// Coincidencia exacta primero y límite de resultados
+	resultados, err = c.BuscarPlaca(context.Background(), "ABC999", 1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resultados) != 1 || resultados[0].Placa != "ABC-999" {
+		t.Errorf("resultados = %+v, se esperaba solo ABC-999", resultados)
+	}
+}
+
+func TestGraphQLBuscarTickets(t *testing.T) {
+	ahora := time.Now()
+	stub := newGQLStub(t, gqlFixture(ahora), nil)
+	c := NewGraphQLClient(stub.URL)
+	filtro := models.FiltroBusquedaTickets{Campo: models.CampoIngreso}
+
+	pagina, err := c.BuscarTickets(context.Background(), filtro, nil, 2, ahora)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pagina) != 2 || pagina[0].ID != "t2" || pagina[1].ID != "t1" {
+		t.Fatalf("primera página = %+v, se esperaba t2, t1", pagina)
+	}
+
+	cursor := &models.CursorTickets{Fecha: pagina[1].FechaIngreso, ID: pagina[1].ID}
+	pagina, err = c.BuscarTickets(context.Background(), filtro, cursor, 2, ahora)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pagina) != 1 || pagina[0].ID != "t3" || pagina[0].FechaSalida == nil || pagina[0].DetallePagoID == nil {
+		t.Errorf("segunda página = %+v, se esperaba t3 cerrado y pagado", pagina)
+	}
+
+	// Las páginas siguientes reutilizan el historial descargado
+	if got := stub.recibidas(); strings.Join(got, ",") != "TicketsActivos,TiposVehiculos" {
+		t.Errorf("operaciones = %v, se esperaba una sola descarga del historial", got)
+	}
+}
+
+func TestGraphQLFlujoHorario(t *testing.T) {
+	ahora := time.Now()
+	stub := newGQLStub(t, gqlFixture(ahora), nil)
+	c := NewGraphQLClient(stub.URL)
+
+	flujos, err := c.GetFlujoHorario(context.Background(), ahora.Add(-48*time.Hour), ahora.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ingresos, salidas int
+	porSeccion := make(map[string]int)
+	for _, f := range flujos {
+		ingresos += f.Ingresos
+		salidas += f.Salidas
+		porSeccion[f.SeccionLetra] += f.Ingresos + f.Salidas
+	}
+	if ingresos != 3 || salidas != 1 {
+		t.Errorf("ingresos %d, salidas %d; se esperaba 3 y 1", ingresos, salidas)
+	}
+	if porSeccion["A"] != 3 || porSeccion["B"] != 1 {
+		t.Errorf("movimientos por sección = %v", porSeccion)
+	}
+}
+
+func TestGraphQLNoSoportadas(t *testing.T) {
+	stub := newGQLStub(t, gqlFixture(time.Now()), nil)
+	c := NewGraphQLClient(stub.URL)
+
+	if _, err := c.GetTicketsActivosByAuthUser(context.Background(), "u1"); !errors.Is(err, interfaces.ErrNotSupported) {
+		t.Errorf("GetTicketsActivosByAuthUser: %v, se esperaba ErrNotSupported", err)
+	}
+	if _, err := c.GetDesgloseIngresos(context.Background(), time.Now().Add(-time.Hour), time.Now()); !errors.Is(err, interfaces.ErrNotSupported) {
+		t.Errorf("GetDesgloseIngresos: %v, se esperaba ErrNotSupported", err)
+	}
+	if got := stub.recibidas(); len(got) != 0 {
+		t.Errorf("las consultas no soportadas no deben llegar al servicio: %v", got)
+	}
+}
+
+func TestGraphQLErrores(t *testing.T) {
+	casos := []struct {
+		nombre    string
+		respuesta gqlRespuesta
+		contiene  string
+	}{
+		{"arreglo errors", gqlRespuesta{http.StatusOK, `{"data":{"secciones":[]},"errors":[{"message":"campo desconocido"},{"message":"otro"}]}`}, "campo desconocido; otro"},
+		{"data null", gqlRespuesta{http.StatusOK, `{"data":null}`}, "sin datos"},
+		{"sin data", gqlRespuesta{http.StatusOK, `{}`}, "sin datos"},
+		{"status 400", gqlRespuesta{http.StatusBadRequest, `{"errors":[{"message":"sintaxis"}]}`}, "status 400"},
+		{"status 500", gqlRespuesta{http.StatusInternalServerError, `boom`}, "status 500"},
+		{"JSON inválido", gqlRespuesta{http.StatusOK, `{"data":`}, "decodificar"},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			stub := newGQLStub(t, "{}", map[string]gqlRespuesta{"EspaciosDisponibles": caso.respuesta})
+			c := NewGraphQLClient(stub.URL)
+
+			_, err := c.GetEspaciosDisponibles(context.Background())
+			if err == nil || !strings.Contains(err.Error(), caso.contiene) {
+				t.Errorf("error = %v, se esperaba que contenga %q", err, caso.contiene)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// maxRetries reintentos de una petición GET antes de darla por fallida
+	maxRetries = 2
+
+	// retryBaseDelay espera antes del primer reintento; se duplica en cada intento
+	retryBaseDelay = 200 * time.Millisecond
+
+	// breakerThreshold fallos consecutivos que abren el circuito
+	breakerThreshold = 5
+
+	// breakerCooldown tiempo que el circuito permanece abierto antes de probar de nuevo
+	breakerCooldown = 30 * time.Second
+)
+
+// ErrCircuitOpen el servicio falló repetidamente y no se le envían peticiones por ahora
+var ErrCircuitOpen = errors.New("circuito abierto")
+
+// retryTransport reintenta las peticiones idempotentes (GET / HEAD) que fallan por
+// error de red o respuesta 5xx / 429, con backoff exponencial y jitter
+type retryTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip ejecuta la petición con reintentos
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return t.base.RoundTrip(req)
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if attempt >= maxRetries || !retryable(resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		delay := backoff(attempt)
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// retryable indica si vale la pena repetir la petición
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusTooManyRequests
+}
+
+// backoff espera del intento: base * 2^attempt más un jitter de hasta el mismo valor
+func backoff(attempt int) time.Duration {
+	delay := retryBaseDelay << attempt
+	return delay + time.Duration(rand.Int63n(int64(delay)))
+}
+
+// breakerTransport circuit breaker: tras breakerThreshold fallos consecutivos rechaza
+// las peticiones durante breakerCooldown y luego deja pasar una de prueba (half-open)
+type breakerTransport struct {
+	name string // servicio protegido, para logs y errores
+	base http.RoundTripper
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// RoundTrip ejecuta la petición si el circuito lo permite y registra el resultado
+func (t *breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.allow(); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil && errors.Is(req.Context().Err(), context.Canceled) {
+		// Cancelada por quien la hizo: no dice nada de la salud del servicio
+		t.release()
+		return resp, err
+	}
+	t.record(err == nil && resp.StatusCode < http.StatusInternalServerError)
+	return resp, err
+}
+
+// allow decide si la petición puede salir
+func (t *breakerTransport) allow() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.failures < breakerThreshold {
+		return nil
+	}
+	if time.Now().Before(t.openUntil) || t.probing {
+		return fmt.Errorf("%w: %s no disponible (reintento en %s)", ErrCircuitOpen, t.name, time.Until(t.openUntil).Round(time.Second))
+	}
+
+	// Half-open: una sola petición de prueba
+	t.probing = true
+	return nil
+}
+
+// release libera la petición de prueba sin cambiar el estado del circuito
+func (t *breakerTransport) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.probing = false
+}
+
+// record actualiza el estado del circuito con el resultado de una petición
+func (t *breakerTransport) record(success bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	wasOpen := t.failures >= breakerThreshold
+	t.probing = false
+
+	if success {
+		if wasOpen {
+			log.Printf("✅ %s disponible de nuevo, circuito cerrado", t.name)
+		}
+		t.failures = 0
+		return
+	}
+
+	t.failures++
+	if t.failures >= breakerThreshold {
+		t.openUntil = time.Now().Add(breakerCooldown)
+		if !wasOpen {
+			log.Printf("⚠️  %s falló %d veces seguidas, circuito abierto por %s", t.name, t.failures, breakerCooldown)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// servidorFallido responde 503 a las primeras fallas peticiones y 200 al resto
+type servidorFallido struct {
+	*httptest.Server
+	fallas     int32
+	peticiones atomic.Int32
+}
+
+func nuevoServidorFallido(t *testing.T, fallas int32) *servidorFallido {
+	t.Helper()
+	s := &servidorFallido{fallas: fallas}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.peticiones.Add(1) <= s.fallas {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, `{"ok":true}`)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func peticion(t *testing.T, transport http.RoundTripper, method, url string) (*http.Response, error) {
+	t.Helper()
+	req, err := http.NewRequest(method, url, strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("NewRequest: %v", err)
+	}
+	resp, err := transport.RoundTrip(req)
+	if resp != nil {
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	return resp, err
+}
+
+func TestRetryTransportReintentaGET(t *testing.T) {
+	servidor := nuevoServidorFallido(t, maxRetries)
+	transport := &retryTransport{base: http.DefaultTransport}
+
+	resp, err := peticion(t, transport, http.MethodGet, servidor.URL)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET tras %d fallas = %v, %v; se esperaba 200", maxRetries, resp, err)
+	}
+	if n := servidor.peticiones.Load(); n != maxRetries+1 {
+		t.Errorf("%d peticiones, se esperaban %d", n, maxRetries+1)
+	}
+}
+
+func TestRetryTransportAcotado(t *testing.T) {
+	servidor := nuevoServidorFallido(t, 100)
+	transport := &retryTransport{base: http.DefaultTransport}
+
+	inicio := time.Now()
+	resp, err := peticion(t, transport, http.MethodGet, servidor.URL)
+	if err != nil || resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("GET con el servidor caído = %v, %v; se esperaba el último 503", resp, err)
+	}
+	if n := servidor.peticiones.Load(); n != maxRetries+1 {
+		t.Errorf("%d peticiones, se esperaban %d (1 + %d reintentos)", n, maxRetries+1, maxRetries)
+	}
+
+	// Cada espera dura como mucho el doble de su base
+	var maximo time.Duration
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		maximo += 2 * (retryBaseDelay << attempt)
+	}
+	if dur := time.Since(inicio); dur > maximo+time.Second {
+		t.Errorf("los reintentos tardaron %s, el máximo del backoff es %s", dur, maximo)
+	}
+}
+
+func TestRetryTransportNoReintentaNoIdempotentes(t *testing.T) {
+	for _, method := range []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+		t.Run(method, func(t *testing.T) {
+			servidor := nuevoServidorFallido(t, 1)
+			transport := &retryTransport{base: http.DefaultTransport}
+
+			resp, err := peticion(t, transport, method, servidor.URL)
+			if err != nil || resp.StatusCode != http.StatusServiceUnavailable {
+				t.Fatalf("%s = %v, %v; se esperaba el 503 sin reintentar", method, resp, err)
+			}
+			if n := servidor.peticiones.Load(); n != 1 {
+				t.Errorf("%s enviado %d veces, se esperaba 1", method, n)
+			}
+		})
+	}
+}
+
+func TestRetryTransportCancelado(t *testing.T) {
+	servidor := nuevoServidorFallido(t, 100)
+	transport := &retryTransport{base: http.DefaultTransport}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, servidor.URL, nil)
+	time.AfterFunc(retryBaseDelay/2, cancel)
+	if _, err := transport.RoundTrip(req); !errors.Is(err, context.Canceled) {
+		t.Fatalf("GET cancelado durante el backoff = %v, se esperaba context.Canceled", err)
+	}
+	if n := servidor.peticiones.Load(); n != 1 {
+		t.Errorf("%d peticiones tras cancelar, se esperaba 1", n)
+	}
+}
+
+func TestBackoffAcotado(t *testing.T) {
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		base := retryBaseDelay << attempt
+		for i := 0; i < 100; i++ {
+			if d := backoff(attempt); d < base || d >= 2*base {
+				t.Fatalf("backoff(%d) = %s, fuera de [%s, %s)", attempt, d, base, 2*base)
+			}
+		}
+	}
+}
+
+func TestBreakerTransport(t *testing.T) {
+	servidor := nuevoServidorFallido(t, breakerThreshold+1)
+	breaker := &breakerTransport{name: "backend", base: http.DefaultTransport}
+
+	// Se abre tras breakerThreshold fallos consecutivos
+	for i := 0; i < breakerThreshold; i++ {
+		if _, err := peticion(t, breaker, http.MethodGet, servidor.URL); err != nil {
+			t.Fatalf("petición %d con el circuito cerrado: %v", i+1, err)
+		}
+	}
+	if _, err := peticion(t, breaker, http.MethodGet, servidor.URL); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("tras %d fallos = %v, se esperaba ErrCircuitOpen", breakerThreshold, err)
+	}
+	if n := servidor.peticiones.Load(); n != breakerThreshold {
+		t.Fatalf("%d peticiones llegaron al servidor con el circuito abierto, se esperaban %d", n, breakerThreshold)
+	}
+
+	// Vencido el cooldown pasa una sola petición de prueba; si falla se vuelve a abrir
+	vencerCooldown(breaker)
+	if !breakerPermite(breaker) {
+		t.Fatal("vencido el cooldown no se permitió la petición de prueba")
+	}
+	if breakerPermite(breaker) {
+		t.Fatal("se permitió una segunda petición mientras la de prueba está en curso")
+	}
+	breaker.release()
+
+	if _, err := peticion(t, breaker, http.MethodGet, servidor.URL); err != nil {
+		t.Fatalf("petición de prueba: %v", err)
+	}
+	if _, err := peticion(t, breaker, http.MethodGet, servidor.URL); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("tras fallar la prueba = %v, se esperaba el circuito abierto de nuevo", err)
+	}
+
+	// Una prueba exitosa cierra el circuito
+	vencerCooldown(breaker)
+	for i := 0; i < 3; i++ {
+		if resp, err := peticion(t, breaker, http.MethodGet, servidor.URL); err != nil || resp.StatusCode != http.StatusOK {
+			t.Fatalf("petición %d tras recuperarse = %v, %v", i+1, resp, err)
+		}
+	}
+	if n := servidor.peticiones.Load(); n != breakerThreshold+4 {
+		t.Errorf("%d peticiones al servidor, se esperaban %d", n, breakerThreshold+4)
+	}
+}
+
+func TestBreakerTransportIgnoraCancelaciones(t *testing.T) {
+	servidor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer servidor.Close()
+	breaker := &breakerTransport{name: "backend", base: http.DefaultTransport}
+
+	for i := 0; i < breakerThreshold; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, servidor.URL, nil)
+		time.AfterFunc(10*time.Millisecond, cancel)
+		if _, err := breaker.RoundTrip(req); err == nil {
+			t.Fatal("petición cancelada sin error")
+		}
+	}
+	if !breakerPermite(breaker) {
+		t.Error("las peticiones canceladas por el cliente abrieron el circuito")
+	}
+}
+
+// vencerCooldown adelanta el fin del cooldown del circuito abierto
+func vencerCooldown(b *breakerTransport) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.openUntil = time.Now().Add(-time.Millisecond)
+}
+
+// breakerPermite indica si el circuito deja salir una petición (y la reserva si es la de prueba)
+func breakerPermite(b *breakerTransport) bool {
+	return b.allow() == nil
+}
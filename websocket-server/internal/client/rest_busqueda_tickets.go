@@ -0,0 +1,41 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+// BuscarTickets busca en el historial completo de tickets y pagina en memoria: el
+// backend no filtra ni pagina /tickets. El historial se reutiliza durante
+// busquedaCacheTTL para que recorrer las páginas no lo descargue cada vez.
+func (c *RestClient) BuscarTickets(ctx context.Context, filtro models.FiltroBusquedaTickets, despues *models.CursorTickets, limite int, ahora time.Time) ([]models.Ticket, error) {
+	tickets, err := c.historialCache.get(ctx, c.loadHistorial)
+	if err != nil {
+		return nil, err
+	}
+	return models.PaginarTickets(tickets, filtro, despues, limite, ahora), nil
+}
+
+// loadHistorial descarga todos los tickets y los completa con el catálogo. La lista
+// es compartida entre búsquedas: no se modifica.
+func (c *RestClient) loadHistorial(ctx context.Context) ([]models.Ticket, error) {
+	var raw []restTicket
+	if err := c.getJSON(ctx, "/tickets", &raw); err != nil {
+		return nil, fmt.Errorf("error al obtener tickets del REST API: %w", err)
+	}
+	cat, err := c.getCatalogo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error al completar tickets: %w", err)
+	}
+
+	tickets := make([]models.Ticket, 0, len(raw))
+	for _, t := range raw {
+		ticket := t.toModel()
+		cat.completar(&ticket)
+		tickets = append(tickets, ticket)
+	}
+	return tickets, nil
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// busquedaCacheTTL cuánto se reutiliza el historial completo de tickets en la búsqueda
+// paginada de las fuentes que no filtran: las páginas siguientes de una misma búsqueda
+// no vuelven a descargarlo
+const busquedaCacheTTL = time.Minute
+
+// recursoCache último resultado de un recurso completo del backend (catálogo, multas),
+// reutilizado durante ttl para no descargar la tabla entera en cada consulta. Las
+// cargas se serializan: quien llega durante una carga espera y reutiliza su resultado.
+type recursoCache[T any] struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	valor     T
+	cargadoEn time.Time
+	cargado   bool
+}
+
+// get devuelve el valor vigente o lo carga con load. Los errores no se guardan.
+func (c *recursoCache[T]) get(ctx context.Context, load func(context.Context) (T, error)) (T, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cargado && time.Since(c.cargadoEn) < c.ttl {
+		return c.valor, nil
+	}
+
+	valor, err := load(ctx)
+	if err != nil {
+		var vacio T
+		return vacio, err
+	}
+	c.valor, c.cargadoEn, c.cargado = valor, time.Now(), true
+	return valor, nil
+}
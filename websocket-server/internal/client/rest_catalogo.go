@@ -0,0 +1,157 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/tarifa"
+)
+
+// restVehiculo vehículo tal como lo devuelve GET /vehiculos (camelCase)
+type restVehiculo struct {
+	ID             string `json:"id"`
+	Placa          string `json:"placa"`
+	Marca          string `json:"marca"`
+	Modelo         string `json:"modelo"`
+	TipoVehiculoID string `json:"tipoVehiculoId"`
+}
+
+// restTipoVehiculo tipo de vehículo con la tarifa que le corresponde
+type restTipoVehiculo struct {
+	ID           string `json:"id"`
+	Categoria    string `json:"categoria"`
+	TipoTarifaID string `json:"tipoTarifaId"`
+}
+
+// vehiculoCatalogo datos de un vehículo resueltos con su tipo y tarifa
+type vehiculoCatalogo struct {
+	restVehiculo
+	Categoria string
+	Tarifa    *tarifa.Tarifa
+}
+
+// espacioCatalogo número de un espacio y la letra de su sección
+type espacioCatalogo struct {
+	Numero       string
+	SeccionLetra string
+}
+
+// catalogo vehículos y espacios del backend para completar tickets y espacios sin
+// consultar cada recurso por separado. Se comparte entre consultas: no se modifica.
+type catalogo struct {
+	vehiculos map[string]vehiculoCatalogo
+	porPlaca  map[string]vehiculoCatalogo
+	espacios  map[string]espacioCatalogo
+}
+
+// getCatalogo devuelve el catálogo vigente, descargado como máximo una vez cada cacheTTL
+func (c *RestClient) getCatalogo(ctx context.Context) (*catalogo, error) {
+	return c.catalogoCache.get(ctx, c.loadCatalogo)
+}
+
+// loadCatalogo combina /vehiculos, /tipo-vehiculo, /tipo-tarifa y /secciones/with-espacios,
+// consultados una sola vez y en paralelo
+func (c *RestClient) loadCatalogo(ctx context.Context) (*catalogo, error) {
+	var (
+		vehiculos []restVehiculo
+		tipos     []restTipoVehiculo
+		tarifas   []restTipoTarifa
+		secciones []restSeccion
+	)
+	err := c.getAllJSON(ctx,
+		jsonRequest{"/vehiculos", &vehiculos},
+		jsonRequest{"/tipo-vehiculo", &tipos},
+		jsonRequest{"/tipo-tarifa", &tarifas},
+		jsonRequest{"/secciones/with-espacios", &secciones},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	preciosPorTarifa := make(map[string]tarifa.Tarifa, len(tarifas))
+	for _, t := range tarifas {
+		preciosPorTarifa[t.ID] = tarifa.Tarifa{PrecioHora: t.PrecioHora, PrecioDia: t.PrecioDia}
+	}
+	tiposPorID := make(map[string]restTipoVehiculo, len(tipos))
+	for _, tipo := range tipos {
+		tiposPorID[tipo.ID] = tipo
+	}
+
+	cat := &catalogo{
+		vehiculos: make(map[string]vehiculoCatalogo, len(vehiculos)),
+		porPlaca:  make(map[string]vehiculoCatalogo, len(vehiculos)),
+		espacios:  make(map[string]espacioCatalogo),
+	}
+	for _, v := range vehiculos {
+		vehiculo := vehiculoCatalogo{restVehiculo: v}
+		if tipo, ok := tiposPorID[v.TipoVehiculoID]; ok {
+			vehiculo.Categoria = tipo.Categoria
+			if precios, ok := preciosPorTarifa[tipo.TipoTarifaID]; ok {
+				vehiculo.Tarifa = &precios
+			}
+		}
+		cat.vehiculos[v.ID] = vehiculo
+		cat.porPlaca[v.Placa] = vehiculo
+	}
+	for _, seccion := range secciones {
+		for _, espacio := range seccion.Espacios {
+			cat.espacios[espacio.ID] = espacioCatalogo{Numero: espacio.Numero, SeccionLetra: seccion.LetraSeccion}
+		}
+	}
+	return cat, nil
+}
+
+// completarTickets agrega a cada ticket placa, marca, modelo, categoría y tarifa de
+// su vehículo, y el número de espacio con la letra de su sección
+func (c *RestClient) completarTickets(ctx context.Context, tickets []models.Ticket) error {
+	if len(tickets) == 0 {
+		return nil
+	}
+
+	cat, err := c.getCatalogo(ctx)
+	if err != nil {
+		return fmt.Errorf("error al completar tickets activos: %w", err)
+	}
+	for i := range tickets {
+		cat.completar(&tickets[i])
+	}
+	return nil
+}
+
+// completar agrega al ticket los datos de su vehículo y de su espacio
+func (cat *catalogo) completar(ticket *models.Ticket) {
+	if vehiculo, ok := cat.vehiculos[ticket.VehiculoID]; ok {
+		ticket.VehiculoPlaca = vehiculo.Placa
+		ticket.VehiculoMarca = vehiculo.Marca
+		ticket.VehiculoModelo = vehiculo.Modelo
+		ticket.VehiculoCategoria = vehiculo.Categoria
+		ticket.Tarifa = vehiculo.Tarifa
+	}
+	if espacio, ok := cat.espacios[ticket.EspacioID]; ok {
+		ticket.EspacioNumero = espacio.Numero
+		ticket.SeccionLetra = espacio.SeccionLetra
+	}
+}
+
+// asignarTarifasEspacios completa la tarifa del vehículo de cada espacio ocupado,
+// identificado por su placa. Sin catálogo los espacios se envían sin cobro acumulado.
+func (c *RestClient) asignarTarifasEspacios(ctx context.Context, secciones []models.EspaciosPorSeccion) {
+	cat, err := c.getCatalogo(ctx)
+	if err != nil {
+		log.Printf("⚠️  Sin tarifas para el cobro acumulado de espacios: %v", err)
+		return
+	}
+	for i := range secciones {
+		for j := range secciones[i].Espacios {
+			espacio := &secciones[i].Espacios[j]
+			if espacio.VehiculoPlaca == nil {
+				continue
+			}
+			if vehiculo, ok := cat.porPlaca[*espacio.VehiculoPlaca]; ok {
+				espacio.Tarifa = vehiculo.Tarifa
+			}
+		}
+	}
+}
@@ -1,145 +1,299 @@
 package client
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"net/url"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/metrics"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/repository/interfaces"
 )
 
 // RestClient cliente HTTP para comunicarse con el REST API
 type RestClient struct {
 	baseURL    string
 	httpClient *http.Client
+
+	// Hasta cuándo no se vuelven a intentar los endpoints /dashboard (el backend no los expone)
+	mu                    sync.Mutex
+	dashboardMissingUntil time.Time
+
+	// Recursos completos que el backend no filtra, compartidos entre consultas
+	catalogoCache  *recursoCache[*catalogo]
+	multasCache    *recursoCache[[]restMulta]
+	historialCache *recursoCache[[]models.Ticket]
 }
 
-// NewRestClient crea una nueva instancia del cliente REST
-func NewRestClient(baseURL string) *RestClient {
+// RestClient implementa la fuente de datos del dashboard
+var _ interfaces.DataSource = (*RestClient)(nil)
+
+// NewRestClient crea una nueva instancia del cliente REST. cacheTTL es cuánto se
+// reutilizan el catálogo y las multas descargados del backend; conviene que sea al
+// menos un ciclo de actualización para descargarlos una sola vez por ciclo.
+func NewRestClient(baseURL string, cacheTTL time.Duration) *RestClient {
 	return &RestClient{
-		baseURL: baseURL,
+		baseURL:        baseURL,
+		catalogoCache:  &recursoCache[*catalogo]{ttl: cacheTTL},
+		multasCache:    &recursoCache[[]restMulta]{ttl: cacheTTL},
+		historialCache: &recursoCache[[]models.Ticket]{ttl: busquedaCacheTTL},
 		httpClient: &http.Client{
 			Timeout: 10 * time.Second,
+			// circuit breaker -> reintentos -> métricas por intento
+			Transport: &breakerTransport{
+				name: "REST API",
+				base: &retryTransport{
+					base: metrics.NewTransport(http.DefaultTransport, basePath(baseURL)),
+				},
+			},
 		},
 	}
 }
 
-// GetDashboardData obtiene los datos del dashboard construyéndolos desde endpoints existentes
-func (c *RestClient) GetDashboardData() (*models.DashboardData, error) {
-	// Obtener espacios disponibles y ocupados
-	espaciosDisp, err := c.getEspaciosDisponiblesCount()
+// basePath ruta de baseURL (por ejemplo /api) que se omite en las etiquetas de métricas
+func basePath(baseURL string) string {
+	u, err := url.Parse(baseURL)
 	if err != nil {
-		return nil, fmt.Errorf("error obteniendo espacios disponibles: %w", err)
+		return ""
 	}
+	return u.Path
+}
 
-	// Obtener total de espacios
-	totalEspacios, err := c.getTotalEspacios()
-	if err != nil {
-		return nil, fmt.Errorf("error obteniendo total espacios: %w", err)
-	}
+// dashboardProbeInterval cada cuánto se vuelve a probar el módulo /dashboard si el backend no lo tenía
+const dashboardProbeInterval = 5 * time.Minute
 
-	// Calcular ocupados
-	espaciosOcup := totalEspacios - espaciosDisp
+// statusError respuesta del REST API con un código distinto de 200
+type statusError struct {
+	StatusCode int
+	Body       string
+}
 
-	// Obtener tickets activos (vehículos en el estacionamiento)
-	vehiculosActivos, err := c.getVehiculosActivos()
+func (e *statusError) Error() string {
+	return fmt.Sprintf("REST API respondió con status %d: %s", e.StatusCode, e.Body)
+}
+
+// getJSON hace GET de path y decodifica la respuesta en out
+func (c *RestClient) getJSON(ctx context.Context, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
 	if err != nil {
-		return nil, fmt.Errorf("error obteniendo vehículos activos: %w", err)
+		return err
 	}
 
-	// Obtener dinero recaudado (si tienes endpoint de transacciones)
-	dineroHoy, dineroMes := c.getDineroRecaudado()
-
-	return &models.DashboardData{
-		EspaciosDisponibles: espaciosDisp,
-		EspaciosOcupados:    espaciosOcup,
-		TotalEspacios:       totalEspacios,
-		DineroRecaudadoHoy:  dineroHoy,
-		DineroRecaudadoMes:  dineroMes,
-		VehiculosActivos:    vehiculosActivos,
-		Timestamp:           time.Now(),
-	}, nil
-}
-
-// getEspaciosDisponiblesCount obtiene la cantidad de espacios disponibles
-func (c *RestClient) getEspaciosDisponiblesCount() (int, error) {
-	url := fmt.Sprintf("%s/espacios", c.baseURL)
-	
-	resp, err := c.httpClient.Get(url)
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return 0, err
+		return err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("status %d", resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		return &statusError{StatusCode: resp.StatusCode, Body: string(body)}
 	}
 
-	var espacios []map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&espacios); err != nil {
-		return 0, err
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// jsonRequest recurso a obtener con getAllJSON y dónde decodificarlo
+type jsonRequest struct {
+	path string
+	out  interface{}
+}
+
+// getAllJSON obtiene varios recursos en paralelo. Si uno falla se cancelan los demás
+// y se devuelve el primer error.
+func (c *RestClient) getAllJSON(ctx context.Context, requests ...jsonRequest) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(requests))
+	for i, req := range requests {
+		wg.Add(1)
+		go func(i int, req jsonRequest) {
+			defer wg.Done()
+			if err := c.getJSON(ctx, req.path, req.out); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", req.path, err)
+				cancel()
+			}
+		}(i, req)
 	}
+	wg.Wait()
 
-	// Contar solo espacios con estado = true (disponibles)
-	disponibles := 0
-	for _, espacio := range espacios {
-		if estado, ok := espacio["estado"].(bool); ok && estado {
-			disponibles++
+	// Preferir el error original a los context.Canceled que provocó
+	for _, err := range errs {
+		if err != nil && !errors.Is(err, context.Canceled) {
+			return err
 		}
 	}
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	return disponibles, nil
+// Name identifica la fuente en logs
+func (c *RestClient) Name() string {
+	return "rest"
 }
 
-// getTotalEspacios obtiene el total de espacios en el estacionamiento
-func (c *RestClient) getTotalEspacios() (int, error) {
-	url := fmt.Sprintf("%s/espacios", c.baseURL)
-	
-	resp, err := c.httpClient.Get(url)
-	if err != nil {
-		return 0, err
+// getFromDashboardModule consulta un endpoint del módulo dashboard del backend
+// (/dashboard/...). Devuelve ok = false si el backend no expone el módulo, para
+// que el llamador use los endpoints básicos.
+func (c *RestClient) getFromDashboardModule(ctx context.Context, path string, out interface{}) (bool, error) {
+	if !c.dashboardModuleAvailable() {
+		return false, nil
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return 0, fmt.Errorf("status %d", resp.StatusCode)
+	err := c.getJSON(ctx, path, out)
+	if err == nil {
+		return true, nil
 	}
 
-	var espacios []map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&espacios); err != nil {
-		return 0, err
+	var statusErr *statusError
+	if errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound {
+		log.Printf("⚠️  El REST API no expone %s, se usan los endpoints básicos", path)
+		c.markDashboardModuleMissing()
+		return false, nil
 	}
+	return false, err
+}
 
-	return len(espacios), nil
+// dashboardModuleAvailable indica si conviene intentar los endpoints /dashboard
+func (c *RestClient) dashboardModuleAvailable() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return time.Now().After(c.dashboardMissingUntil)
 }
 
-// getVehiculosActivos obtiene la cantidad de vehículos actualmente en el estacionamiento
-func (c *RestClient) getVehiculosActivos() (int, error) {
-	// Usar endpoint de tickets
-	url := fmt.Sprintf("%s/tickets", c.baseURL)
-	
-	resp, err := c.httpClient.Get(url)
+// markDashboardModuleMissing evita probar /dashboard hasta el próximo intervalo
+func (c *RestClient) markDashboardModuleMissing() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.dashboardMissingUntil = time.Now().Add(dashboardProbeInterval)
+}
+
+// GetDashboardData obtiene los datos del dashboard. Usa el endpoint agregado
+// GET /dashboard del backend y, si no existe, los construye desde los endpoints básicos.
+// Las multas pendientes se calculan desde GET /multas (reutilizado durante cacheTTL).
+func (c *RestClient) GetDashboardData(ctx context.Context) (*models.DashboardData, error) {
+	data := &models.DashboardData{}
+	ok, err := c.getFromDashboardModule(ctx, "/dashboard", data)
 	if err != nil {
-		// Si falla, retornar 0 en lugar de error
-		return 0, nil
+		return nil, fmt.Errorf("error obteniendo dashboard del REST API: %w", err)
+	}
+	if !ok {
+		if data, err = c.buildDashboardData(ctx); err != nil {
+			return nil, err
+		}
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return 0, nil
+	if err := c.completarMultas(ctx, data); err != nil {
+		return nil, fmt.Errorf("error obteniendo multas pendientes: %w", err)
 	}
 
-	var tickets []map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&tickets); err != nil {
-		return 0, nil
+	if data.Timestamp.IsZero() {
+		data.Timestamp = time.Now()
+	}
+	return data, nil
+}
+
+// restEspacio espacio tal como lo devuelve GET /espacios (camelCase)
+type restEspacio struct {
+	ID     string `json:"id"`
+	Numero string `json:"numero"`
+	Estado bool   `json:"estado"`
+}
+
+// buildDashboardData construye el dashboard desde los endpoints básicos, consultando
+// cada recurso una sola vez y en paralelo. Si falla /espacios se cancela el resto.
+func (c *RestClient) buildDashboardData(ctx context.Context) (*models.DashboardData, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		wg               sync.WaitGroup
+		espacios         []restEspacio
+		espaciosErr      error
+		vehiculosActivos int
+		vehiculosErr     error
+		dineroHoy        float64
+		dineroMes        float64
+		dineroErr        error
+	)
+
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		if espaciosErr = c.getJSON(ctx, "/espacios", &espacios); espaciosErr != nil {
+			cancel()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if vehiculosActivos, vehiculosErr = c.getVehiculosActivos(ctx); vehiculosErr != nil {
+			cancel()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		if dineroHoy, dineroMes, dineroErr = c.getDineroRecaudado(ctx); dineroErr != nil {
+			cancel()
+		}
+	}()
+	wg.Wait()
+
+	// Un dato faltante no se reemplaza por cero: falla el dashboard completo y el
+	// servicio decide si servir el último snapshot válido
+	if espaciosErr != nil {
+		return nil, fmt.Errorf("error obteniendo espacios: %w", espaciosErr)
+	}
+	if vehiculosErr != nil {
+		return nil, fmt.Errorf("error obteniendo vehículos activos: %w", vehiculosErr)
+	}
+	if dineroErr != nil {
+		return nil, fmt.Errorf("error obteniendo dinero recaudado: %w", dineroErr)
+	}
+
+	// Contar espacios con estado = true (disponibles) sobre la misma lista
+	espaciosDisp := 0
+	for _, espacio := range espacios {
+		if espacio.Estado {
+			espaciosDisp++
+		}
+	}
+
+	return &models.DashboardData{
+		EspaciosDisponibles: espaciosDisp,
+		EspaciosOcupados:    len(espacios) - espaciosDisp,
+		TotalEspacios:       len(espacios),
+		DineroRecaudadoHoy:  dineroHoy,
+		DineroRecaudadoMes:  dineroMes,
+		VehiculosActivos:    vehiculosActivos,
+		Timestamp:           time.Now(),
+	}, nil
+}
+
+// getVehiculosActivos obtiene la cantidad de vehículos actualmente en el estacionamiento
+func (c *RestClient) getVehiculosActivos(ctx context.Context) (int, error) {
+	var tickets []restTicket
+	if err := c.getJSON(ctx, "/tickets", &tickets); err != nil {
+		return 0, err
 	}
 
 	// Contar solo tickets sin fecha de salida
 	activos := 0
 	for _, ticket := range tickets {
-		if fechaSalida, ok := ticket["fechaSalida"]; !ok || fechaSalida == nil {
+		if ticket.FechaSalida == nil {
 			activos++
 		}
 	}
@@ -157,26 +311,12 @@ type DetallePago struct {
 	PagoID    string  `json:"pagoId"`
 }
 
-// getDineroRecaudado obtiene el dinero recaudado (hoy y mes) desde el endpoint detalle-pago
-func (c *RestClient) getDineroRecaudado() (float64, float64) {
-	url := fmt.Sprintf("%s/detalle-pago", c.baseURL)
-
-	resp, err := c.httpClient.Get(url)
-	if err != nil {
-		fmt.Printf("Error obteniendo detalles de pago: %v\n", err)
-		return 0.0, 0.0
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		fmt.Printf("Status code inesperado al obtener pagos: %d\n", resp.StatusCode)
-		return 0.0, 0.0
-	}
-
+// getDineroRecaudado obtiene el dinero recaudado (hoy y mes) desde el endpoint detalle-pago.
+// Descarga todos los pagos: solo se usa cuando el backend no expone GET /dashboard.
+func (c *RestClient) getDineroRecaudado(ctx context.Context) (float64, float64, error) {
 	var detallesPago []DetallePago
-	if err := json.NewDecoder(resp.Body).Decode(&detallesPago); err != nil {
-		fmt.Printf("Error decodificando detalles de pago: %v\n", err)
-		return 0.0, 0.0
+	if err := c.getJSON(ctx, "/detalle-pago", &detallesPago); err != nil {
+		return 0.0, 0.0, err
 	}
 
 	// Obtener fecha actual
@@ -205,7 +345,7 @@ func (c *RestClient) getDineroRecaudado() (float64, float64) {
 		}
 	}
 
-	return dineroHoy, dineroMes
+	return dineroHoy, dineroMes, nil
 }
 
 // parseFechaPago intenta parsear la fecha en varios formatos comunes
@@ -229,80 +369,223 @@ func parseFechaPago(fechaStr string) (time.Time, error) {
 	return time.Time{}, fmt.Errorf("formato de fecha no reconocido: %s", fechaStr)
 }
 
-// GetEspaciosPorSeccion obtiene espacios agrupados por sección desde el REST API
-func (c *RestClient) GetEspaciosPorSeccion() ([]models.EspaciosPorSeccion, error) {
-	url := fmt.Sprintf("%s/secciones/with-espacios", c.baseURL)
-	
-	resp, err := c.httpClient.Get(url)
+// restSeccion sección con sus espacios tal como la devuelve GET /secciones/with-espacios
+type restSeccion struct {
+	ID           string        `json:"id"`
+	LetraSeccion string        `json:"letraSeccion"`
+	Espacios     []restEspacio `json:"espacios"`
+}
+
+// GetEspaciosPorSeccion obtiene espacios agrupados por sección desde el REST API.
+// Sin el módulo dashboard se arma desde /secciones/with-espacios, sin placas ni horas.
+func (c *RestClient) GetEspaciosPorSeccion(ctx context.Context) ([]models.EspaciosPorSeccion, error) {
+	var secciones []models.EspaciosPorSeccion
+	ok, err := c.getFromDashboardModule(ctx, "/dashboard/espacios/por-seccion", &secciones)
 	if err != nil {
 		return nil, fmt.Errorf("error al obtener espacios por sección del REST API: %w", err)
 	}
-	defer resp.Body.Close()
+	if ok {
+		c.asignarTarifasEspacios(ctx, secciones)
+		return secciones, nil
+	}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("REST API respondió con status %d: %s", resp.StatusCode, string(body))
+	raw, err := c.getSecciones(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	var secciones []models.EspaciosPorSeccion
-	if err := json.NewDecoder(resp.Body).Decode(&secciones); err != nil {
-		return nil, fmt.Errorf("error al decodificar espacios por sección: %w", err)
+	secciones = make([]models.EspaciosPorSeccion, 0, len(raw))
+	for _, seccion := range raw {
+		agrupada := models.EspaciosPorSeccion{
+			SeccionLetra:  seccion.LetraSeccion,
+			TotalEspacios: len(seccion.Espacios),
+			Espacios:      make([]models.EspacioDetalle, 0, len(seccion.Espacios)),
+		}
+		for _, espacio := range seccion.Espacios {
+			if espacio.Estado {
+				agrupada.EspaciosDisponibles++
+			} else {
+				agrupada.EspaciosOcupados++
+			}
+			agrupada.Espacios = append(agrupada.Espacios, espacio.toDetalle(seccion.LetraSeccion))
+		}
+		secciones = append(secciones, agrupada)
 	}
 
 	return secciones, nil
 }
 
-// GetTicketsActivos obtiene tickets activos desde el REST API
-func (c *RestClient) GetTicketsActivos() ([]models.Ticket, error) {
-	url := fmt.Sprintf("%s/tickets", c.baseURL)
-	
-	resp, err := c.httpClient.Get(url)
+// getSecciones obtiene las secciones con sus espacios
+func (c *RestClient) getSecciones(ctx context.Context) ([]restSeccion, error) {
+	var secciones []restSeccion
+	if err := c.getJSON(ctx, "/secciones/with-espacios", &secciones); err != nil {
+		return nil, fmt.Errorf("error al obtener secciones del REST API: %w", err)
+	}
+	return secciones, nil
+}
+
+// toDetalle convierte el espacio del backend al detalle del dashboard
+func (e restEspacio) toDetalle(seccionLetra string) models.EspacioDetalle {
+	return models.EspacioDetalle{
+		ID:           e.ID,
+		Numero:       e.Numero,
+		Estado:       e.Estado,
+		SeccionLetra: seccionLetra,
+	}
+}
+
+// GetTicketsActivos obtiene tickets sin fecha de salida desde el REST API, completados
+// con vehículo, espacio y sección a partir del catálogo del backend
+func (c *RestClient) GetTicketsActivos(ctx context.Context) ([]models.Ticket, error) {
+	var tickets []models.Ticket
+	ok, err := c.getFromDashboardModule(ctx, "/dashboard/tickets/activos", &tickets)
 	if err != nil {
 		return nil, fmt.Errorf("error al obtener tickets activos del REST API: %w", err)
 	}
-	defer resp.Body.Close()
+	if !ok {
+		if tickets, err = c.getTicketsActivosFiltrados(ctx, nil); err != nil {
+			return nil, err
+		}
+	}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("REST API respondió con status %d: %s", resp.StatusCode, string(body))
+	if err := c.completarTickets(ctx, tickets); err != nil {
+		return nil, err
 	}
+	return c.marcarMultas(ctx, tickets)
+}
 
-	var tickets []models.Ticket
-	if err := json.NewDecoder(resp.Body).Decode(&tickets); err != nil {
-		return nil, fmt.Errorf("error al decodificar tickets activos: %w", err)
+// getTicketsActivosFiltrados descarga /tickets y conserva los que no tienen fecha de
+// salida. Si vehiculos no es nil, solo los de esos vehículos.
+func (c *RestClient) getTicketsActivosFiltrados(ctx context.Context, vehiculos map[string]bool) ([]models.Ticket, error) {
+	var raw []restTicket
+	if err := c.getJSON(ctx, "/tickets", &raw); err != nil {
+		return nil, fmt.Errorf("error al obtener tickets del REST API: %w", err)
+	}
+
+	tickets := []models.Ticket{}
+	for _, t := range raw {
+		if t.FechaSalida != nil {
+			continue
+		}
+		if vehiculos != nil && !vehiculos[t.VehiculoID] {
+			continue
+		}
+		tickets = append(tickets, t.toModel())
 	}
 
 	return tickets, nil
 }
 
-// GetEspaciosDisponibles obtiene espacios disponibles desde el REST API
-func (c *RestClient) GetEspaciosDisponibles() ([]models.EspacioDetalle, error) {
-	url := fmt.Sprintf("%s/espacios", c.baseURL)
-	
-	resp, err := c.httpClient.Get(url)
+// restTicket ticket tal como lo devuelve el backend NestJS (camelCase)
+type restTicket struct {
+	ID            string     `json:"id"`
+	FechaIngreso  time.Time  `json:"fechaIngreso"`
+	FechaSalida   *time.Time `json:"fechaSalida"`
+	VehiculoID    string     `json:"vehiculoId"`
+	EspacioID     string     `json:"espacioId"`
+	DetallePagoID *string    `json:"detallePagoId"`
+}
+
+// toModel convierte el ticket del backend al modelo del dashboard
+func (t restTicket) toModel() models.Ticket {
+	return models.Ticket{
+		ID:            t.ID,
+		FechaIngreso:  t.FechaIngreso,
+		FechaSalida:   t.FechaSalida,
+		VehiculoID:    t.VehiculoID,
+		EspacioID:     t.EspacioID,
+		DetallePagoID: t.DetallePagoID,
+	}
+}
+
+// GetTicketsActivosByAuthUser obtiene los tickets activos de los vehículos del cliente
+// vinculado al usuario del auth-service, usando el perfil del portal de usuario
+func (c *RestClient) GetTicketsActivosByAuthUser(ctx context.Context, authUserID string) ([]models.Ticket, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/user-portal/perfil", c.baseURL), nil)
 	if err != nil {
-		return nil, fmt.Errorf("error al obtener espacios disponibles del REST API: %w", err)
+		return nil, err
+	}
+	req.Header.Set("x-auth-user-id", authUserID)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener perfil del usuario del REST API: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		// Cuenta no vinculada a ningún cliente: no tiene tickets
+		return []models.Ticket{}, nil
+	}
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("REST API respondió con status %d: %s", resp.StatusCode, string(body))
+		return nil, &statusError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var perfil struct {
+		Vehiculos []struct {
+			ID string `json:"id"`
+		} `json:"vehiculos"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&perfil); err != nil {
+		return nil, fmt.Errorf("error al decodificar perfil del usuario: %w", err)
+	}
+
+	vehiculos := make(map[string]bool, len(perfil.Vehiculos))
+	for _, v := range perfil.Vehiculos {
+		vehiculos[v.ID] = true
+	}
+	if len(vehiculos) == 0 {
+		return []models.Ticket{}, nil
 	}
 
+	tickets, err := c.getTicketsActivosFiltrados(ctx, vehiculos)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.completarTickets(ctx, tickets); err != nil {
+		return nil, err
+	}
+	return c.marcarMultas(ctx, tickets)
+}
+
+// GetEspaciosDisponibles obtiene los espacios con estado = true y la letra de su sección
+func (c *RestClient) GetEspaciosDisponibles(ctx context.Context) ([]models.EspacioDetalle, error) {
 	var espacios []models.EspacioDetalle
-	if err := json.NewDecoder(resp.Body).Decode(&espacios); err != nil {
-		return nil, fmt.Errorf("error al decodificar espacios disponibles: %w", err)
+	ok, err := c.getFromDashboardModule(ctx, "/dashboard/espacios/disponibles", &espacios)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener espacios disponibles del REST API: %w", err)
+	}
+	if ok {
+		return espacios, nil
+	}
+
+	secciones, err := c.getSecciones(ctx)
+	if err != nil {
+		return nil, err
 	}
 
+	espacios = []models.EspacioDetalle{}
+	for _, seccion := range secciones {
+		for _, espacio := range seccion.Espacios {
+			if espacio.Estado {
+				espacios = append(espacios, espacio.toDetalle(seccion.LetraSeccion))
+			}
+		}
+	}
+	sort.Slice(espacios, func(i, j int) bool { return espacios[i].Numero < espacios[j].Numero })
+
 	return espacios, nil
 }
 
 // HealthCheck verifica si el REST API está disponible
-func (c *RestClient) HealthCheck() error {
-	url := fmt.Sprintf("%s/", c.baseURL)
-	
-	resp, err := c.httpClient.Get(url)
+func (c *RestClient) HealthCheck(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("REST API no disponible: %w", err)
 	}
@@ -0,0 +1,43 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+// GetFlujoHorario cuenta ingresos y salidas de [desde, hasta) por sección y hora. El
+// backend no filtra por fecha: se descargan todos los tickets y las secciones.
+func (c *RestClient) GetFlujoHorario(ctx context.Context, desde, hasta time.Time) ([]models.FlujoHora, error) {
+	var (
+		tickets   []restTicket
+		secciones []restSeccion
+	)
+	err := c.getAllJSON(ctx,
+		jsonRequest{"/tickets", &tickets},
+		jsonRequest{"/secciones/with-espacios", &secciones},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener tickets del REST API: %w", err)
+	}
+
+	letraEspacio := make(map[string]string)
+	for _, seccion := range secciones {
+		for _, espacio := range seccion.Espacios {
+			letraEspacio[espacio.ID] = seccion.LetraSeccion
+		}
+	}
+
+	movimientos := make([]models.MovimientoTicket, 0, len(tickets))
+	for _, ticket := range tickets {
+		movimientos = append(movimientos, models.MovimientoTicket{
+			SeccionLetra: letraEspacio[ticket.EspacioID],
+			FechaIngreso: ticket.FechaIngreso,
+			FechaSalida:  ticket.FechaSalida,
+		})
+	}
+
+	return models.AgruparFlujo(movimientos, desde, hasta), nil
+}
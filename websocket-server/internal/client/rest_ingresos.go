@@ -0,0 +1,85 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+// restPago pago del backend con el tipo de tarifa aplicado
+type restPago struct {
+	ID           string `json:"id"`
+	TipoTarifaID string `json:"tipoTarifaId"`
+}
+
+// restTipoTarifa tipo de tarifa del backend
+type restTipoTarifa struct {
+	ID         string  `json:"id"`
+	TipoTarifa string  `json:"tipoTarifa"`
+	PrecioHora float64 `json:"precioHora"`
+	PrecioDia  float64 `json:"precioDia"`
+}
+
+// GetDesgloseIngresos desglosa la recaudación de [desde, hasta) combinando los pagos,
+// sus tarifas y la sección del espacio de cada ticket. El backend no filtra por
+// fecha: se descargan los recursos completos una vez y en paralelo.
+func (c *RestClient) GetDesgloseIngresos(ctx context.Context, desde, hasta time.Time) (*models.DesgloseIngresos, error) {
+	var (
+		detalles  []DetallePago
+		pagos     []restPago
+		tarifas   []restTipoTarifa
+		tickets   []restTicket
+		secciones []restSeccion
+	)
+	err := c.getAllJSON(ctx,
+		jsonRequest{"/detalle-pago", &detalles},
+		jsonRequest{"/pagos", &pagos},
+		jsonRequest{"/tipo-tarifa", &tarifas},
+		jsonRequest{"/tickets", &tickets},
+		jsonRequest{"/secciones/with-espacios", &secciones},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener pagos del REST API: %w", err)
+	}
+
+	nombreTarifa := make(map[string]string, len(tarifas))
+	for _, tarifa := range tarifas {
+		nombreTarifa[tarifa.ID] = tarifa.TipoTarifa
+	}
+	tarifaPago := make(map[string]string, len(pagos))
+	for _, pago := range pagos {
+		tarifaPago[pago.ID] = nombreTarifa[pago.TipoTarifaID]
+	}
+	letraEspacio := make(map[string]string)
+	for _, seccion := range secciones {
+		for _, espacio := range seccion.Espacios {
+			letraEspacio[espacio.ID] = seccion.LetraSeccion
+		}
+	}
+	// Igual que en la base de datos, el ticket se busca por su detallePagoId
+	espacioDetalle := make(map[string]string, len(tickets))
+	for _, ticket := range tickets {
+		if ticket.DetallePagoID != nil {
+			espacioDetalle[*ticket.DetallePagoID] = ticket.EspacioID
+		}
+	}
+
+	detallados := make([]models.PagoDetallado, 0, len(detalles))
+	for _, detalle := range detalles {
+		fecha, err := parseFechaPago(detalle.FechaPago)
+		if err != nil {
+			continue
+		}
+		detallados = append(detallados, models.PagoDetallado{
+			Metodo:  detalle.Metodo,
+			Fecha:   fecha,
+			Total:   detalle.PagoTotal,
+			Tarifa:  tarifaPago[detalle.PagoID],
+			Seccion: letraEspacio[espacioDetalle[detalle.ID]],
+		})
+	}
+
+	return models.NewDesgloseIngresos(desde, hasta, detallados), nil
+}
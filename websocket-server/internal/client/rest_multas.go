@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+// restMulta multa tal como la devuelve GET /multas (camelCase)
+type restMulta struct {
+	ID         string  `json:"id"`
+	MontoTotal float64 `json:"montoTotal"`
+	VehiculoID string  `json:"vehiculoId"`
+	Estado     string  `json:"estado"`
+}
+
+// getMultasPendientes devuelve las multas pendientes, descargadas como máximo una vez
+// cada cacheTTL: el dashboard y los tickets de un mismo ciclo comparten la descarga.
+// La lista es compartida: no se modifica.
+func (c *RestClient) getMultasPendientes(ctx context.Context) ([]restMulta, error) {
+	return c.multasCache.get(ctx, c.loadMultasPendientes)
+}
+
+// loadMultasPendientes descarga /multas y conserva las que están pendientes
+func (c *RestClient) loadMultasPendientes(ctx context.Context) ([]restMulta, error) {
+	var multas []restMulta
+	if err := c.getJSON(ctx, "/multas", &multas); err != nil {
+		return nil, fmt.Errorf("error al obtener multas del REST API: %w", err)
+	}
+
+	pendientes := multas[:0]
+	for _, multa := range multas {
+		if multa.Estado == models.MultaPendiente {
+			pendientes = append(pendientes, multa)
+		}
+	}
+	return pendientes, nil
+}
+
+// completarMultas agrega al dashboard la cantidad y el monto de multas pendientes
+func (c *RestClient) completarMultas(ctx context.Context, data *models.DashboardData) error {
+	multas, err := c.getMultasPendientes(ctx)
+	if err != nil {
+		return err
+	}
+
+	data.MultasPendientes = len(multas)
+	data.MontoMultasPendientes = 0
+	for _, multa := range multas {
+		data.MontoMultasPendientes += multa.MontoTotal
+	}
+	return nil
+}
+
+// marcarMultas completa las multas pendientes del vehículo de cada ticket
+func (c *RestClient) marcarMultas(ctx context.Context, tickets []models.Ticket) ([]models.Ticket, error) {
+	if len(tickets) == 0 {
+		return tickets, nil
+	}
+
+	multas, err := c.getMultasPendientes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	models.MarcarMultasPendientes(tickets, resumenPorVehiculo(multas))
+	return tickets, nil
+}
+
+// resumenPorVehiculo agrupa cantidad y monto de las multas por vehículo
+func resumenPorVehiculo(multas []restMulta) map[string]models.ResumenMultas {
+	porVehiculo := make(map[string]models.ResumenMultas)
+	for _, multa := range multas {
+		if multa.VehiculoID == "" {
+			continue
+		}
+		resumen := porVehiculo[multa.VehiculoID]
+		resumen.Cantidad++
+		resumen.Monto += multa.MontoTotal
+		porVehiculo[multa.VehiculoID] = resumen
+	}
+	return porVehiculo
+}
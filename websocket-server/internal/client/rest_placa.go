@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+// BuscarPlaca busca los vehículos por placa en el catálogo y reparte sus tickets. El
+// backend no filtra: se descargan vehículos, tickets y multas completos.
+func (c *RestClient) BuscarPlaca(ctx context.Context, placa string, limite, visitas int) ([]models.ResultadoPlaca, error) {
+	cat, err := c.getCatalogo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar placa en el REST API: %w", err)
+	}
+
+	resultados := []models.ResultadoPlaca{}
+	for _, vehiculo := range cat.vehiculos {
+		if !models.CoincidePlaca(vehiculo.Placa, placa) {
+			continue
+		}
+		resultados = append(resultados, models.ResultadoPlaca{
+			VehiculoID: vehiculo.ID,
+			Placa:      vehiculo.Placa,
+			Marca:      vehiculo.Marca,
+			Modelo:     vehiculo.Modelo,
+			Categoria:  vehiculo.Categoria,
+		})
+	}
+	models.OrdenarResultadosPlaca(resultados, placa)
+	if len(resultados) > limite {
+		resultados = resultados[:limite]
+	}
+	if len(resultados) == 0 {
+		return resultados, nil
+	}
+
+	encontrados := make(map[string]bool, len(resultados))
+	for _, resultado := range resultados {
+		encontrados[resultado.VehiculoID] = true
+	}
+
+	var raw []restTicket
+	if err := c.getJSON(ctx, "/tickets", &raw); err != nil {
+		return nil, fmt.Errorf("error al obtener tickets del REST API: %w", err)
+	}
+	tickets := []models.Ticket{}
+	for _, t := range raw {
+		if !encontrados[t.VehiculoID] {
+			continue
+		}
+		ticket := t.toModel()
+		cat.completar(&ticket)
+		tickets = append(tickets, ticket)
+	}
+	models.AsignarTickets(resultados, tickets, visitas)
+
+	multas, err := c.getMultasPendientes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	models.MarcarMultasActivos(resultados, resumenPorVehiculo(multas))
+	return resultados, nil
+}
@@ -10,13 +10,47 @@ import (
 
 // Config contiene toda la configuración de la aplicación
 type Config struct {
-	Mode           string // "rest" o "database"
+	Mode           string // "rest", "database", "hybrid" o "graphql"
+	HybridPrimary  string // MODE=hybrid: fuente primaria, "database" (respaldo REST) o "rest"
 	RestAPIURL     string
+	GraphQLURL     string // MODE=graphql: endpoint del graphql-service
 	DatabaseURL    string
 	WSPort         string
 	WSPath         string
 	CORSOrigin     string
-	UpdateInterval int // segundos entre actualizaciones automáticas
+	UpdateInterval int    // segundos entre actualizaciones automáticas
+	NotifyChannel  string // canal LISTEN/NOTIFY para eventos de espacios (MODE=database / hybrid)
+	EventBuffer    int    // eventos recientes que el Hub conserva para reanudar conexiones
+	SnapshotTTL    int    // segundos que se reutiliza cada snapshot de consultas (0 = solo agrupar cargas)
+	HistoryDays    int    // días que se conserva el historial de ocupación
+	MetricsPort    string // puerto del listener interno de /metrics (vacío = deshabilitado)
+
+	// Backplane para distribuir eventos entre réplicas: "memory" (una réplica) o "postgres"
+	Backplane        string
+	BackplaneChannel string
+
+	// Alertas de permanencia máxima (vacías = deshabilitadas)
+	OverstayMaxStay    string // duración global, ej. "24h"
+	OverstayRules      string // reglas por sección o categoría, ej. "seccion:A=4h,categoria:Moto=12h"
+	OverstayEscalation string // tiempo excedido tras el cual se repite la alerta, ej. "1h,4h"
+
+	// Alertas de capacidad por sección (porcentajes de ocupación)
+	CapacityAlertsEnabled bool
+	CapacityWarning       float64
+	CapacityFull          float64
+	CapacityClearMargin   float64 // puntos bajo el umbral para despejar la alerta
+	CapacityRules         string  // umbrales por sección, ej. "A=70/90,C=85/98"
+
+	// Pronóstico de ocupación por sección
+	ForecastEnabled     bool // responder get_forecast
+	ForecastWeeks       int  // semanas de historial de tickets que se promedian
+	ForecastInDashboard bool // incluir el pronóstico en el payload del dashboard
+
+	// Autenticación del handshake WebSocket con tokens del auth-service
+	AuthEnabled        bool
+	JWTSecret          string // secreto HS256 de los access tokens (JWT_ACCESS_SECRET del auth-service)
+	AuthServiceURL     string // alternativa: obtener el secreto de /auth/validation-secret
+	InternalServiceKey string
 }
 
 // Load carga la configuración desde variables de entorno
@@ -31,14 +65,78 @@ func Load() *Config {
 		updateInterval = 5
 	}
 
+	eventBuffer, err := strconv.Atoi(getEnv("EVENT_BUFFER_SIZE", "1024"))
+	if err != nil || eventBuffer <= 0 {
+		eventBuffer = 1024
+	}
+
+	snapshotTTL, err := strconv.Atoi(getEnv("SNAPSHOT_TTL", "2"))
+	if err != nil || snapshotTTL < 0 {
+		snapshotTTL = 2
+	}
+
+	historyDays, err := strconv.Atoi(getEnv("HISTORY_RETENTION_DAYS", "8"))
+	if err != nil || historyDays <= 0 {
+		historyDays = 8
+	}
+
+	capacityWarning, err := strconv.ParseFloat(getEnv("CAPACITY_WARNING", "80"), 64)
+	if err != nil {
+		capacityWarning = 80
+	}
+
+	capacityFull, err := strconv.ParseFloat(getEnv("CAPACITY_FULL", "95"), 64)
+	if err != nil {
+		capacityFull = 95
+	}
+
+	capacityClearMargin, err := strconv.ParseFloat(getEnv("CAPACITY_CLEAR_MARGIN", "5"), 64)
+	if err != nil {
+		capacityClearMargin = 5
+	}
+
+	forecastWeeks, err := strconv.Atoi(getEnv("FORECAST_WEEKS", "4"))
+	if err != nil || forecastWeeks <= 0 {
+		forecastWeeks = 4
+	}
+
 	return &Config{
 		Mode:           getEnv("MODE", "rest"),
+		HybridPrimary:  getEnv("HYBRID_PRIMARY", "database"),
 		RestAPIURL:     getEnv("REST_API_URL", "http://localhost:3000"),
+		GraphQLURL:     getEnv("GRAPHQL_URL", "http://localhost:8000/graphql"),
 		DatabaseURL:    getEnv("DATABASE_URL", ""),
 		WSPort:         getEnv("WS_PORT", "8080"),
 		WSPath:         getEnv("WS_PATH", "/ws"),
 		CORSOrigin:     getEnv("CORS_ORIGIN", "*"),
 		UpdateInterval: updateInterval,
+		NotifyChannel:  getEnv("DB_NOTIFY_CHANNEL", "estacionamiento_eventos"),
+		EventBuffer:    eventBuffer,
+		SnapshotTTL:    snapshotTTL,
+		HistoryDays:    historyDays,
+		MetricsPort:    getEnv("METRICS_PORT", ""),
+
+		Backplane:        getEnv("BACKPLANE", "memory"),
+		BackplaneChannel: getEnv("BACKPLANE_CHANNEL", "ws_backplane"),
+
+		OverstayMaxStay:    getEnv("OVERSTAY_MAX_STAY", ""),
+		OverstayRules:      getEnv("OVERSTAY_RULES", ""),
+		OverstayEscalation: getEnv("OVERSTAY_ESCALATION", ""),
+
+		CapacityAlertsEnabled: getEnv("CAPACITY_ALERTS_ENABLED", "true") != "false",
+		CapacityWarning:       capacityWarning,
+		CapacityFull:          capacityFull,
+		CapacityClearMargin:   capacityClearMargin,
+		CapacityRules:         getEnv("CAPACITY_RULES", ""),
+
+		ForecastEnabled:     getEnv("FORECAST_ENABLED", "true") != "false",
+		ForecastWeeks:       forecastWeeks,
+		ForecastInDashboard: getEnv("FORECAST_IN_DASHBOARD", "false") == "true",
+
+		AuthEnabled:        getEnv("WS_AUTH_ENABLED", "true") != "false",
+		JWTSecret:          getEnv("JWT_SECRET", ""),
+		AuthServiceURL:     getEnv("AUTH_SERVICE_URL", ""),
+		InternalServiceKey: getEnv("INTERNAL_SERVICE_KEY", ""),
 	}
 }
 
@@ -60,5 +158,20 @@ func (c *Config) Validate() error {
 	if c.Mode == "rest" && c.RestAPIURL == "" {
 		log.Fatal("REST_API_URL es requerido cuando MODE=rest")
 	}
+	if c.Mode == "hybrid" && (c.DatabaseURL == "" || c.RestAPIURL == "") {
+		log.Fatal("DATABASE_URL y REST_API_URL son requeridos cuando MODE=hybrid")
+	}
+	if c.Mode == "hybrid" && c.HybridPrimary != "database" && c.HybridPrimary != "rest" {
+		log.Fatal("HYBRID_PRIMARY debe ser \"database\" o \"rest\"")
+	}
+	if c.Mode == "graphql" && c.GraphQLURL == "" {
+		log.Fatal("GRAPHQL_URL es requerido cuando MODE=graphql")
+	}
+	if c.Backplane == "postgres" && c.DatabaseURL == "" {
+		log.Fatal("DATABASE_URL es requerido cuando BACKPLANE=postgres")
+	}
+	if c.AuthEnabled && c.JWTSecret == "" && (c.AuthServiceURL == "" || c.InternalServiceKey == "") {
+		log.Fatal("JWT_SECRET (o AUTH_SERVICE_URL + INTERNAL_SERVICE_KEY) es requerido cuando WS_AUTH_ENABLED=true")
+	}
 	return nil
 }
@@ -0,0 +1,108 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// ResultadoPlaca vehículo encontrado por placa: si está estacionado, su ticket abierto
+// (espacio, sección, ingreso y cobro acumulado) y sus últimas visitas cerradas
+type ResultadoPlaca struct {
+	VehiculoID   string   `json:"vehiculo_id"`
+	Placa        string   `json:"placa"`
+	Marca        string   `json:"marca,omitempty"`
+	Modelo       string   `json:"modelo,omitempty"`
+	Categoria    string   `json:"categoria,omitempty"`
+	Estacionado  bool     `json:"estacionado"`
+	TicketActivo *Ticket  `json:"ticket_activo,omitempty"`
+	Visitas      []Ticket `json:"visitas"` // de la más reciente a la más antigua
+}
+
+// BusquedaPlaca respuesta de buscar_placa
+type BusquedaPlaca struct {
+	Placa      string           `json:"placa"` // búsqueda normalizada
+	Resultados []ResultadoPlaca `json:"resultados"`
+	Timestamp  time.Time        `json:"timestamp"`
+}
+
+// NormalizarPlaca deja solo letras ASCII y dígitos en mayúscula ("abc-123" -> "ABC123").
+// Debe coincidir con el regexp_replace(upper(placa), '[^A-Z0-9]', ...) de las consultas
+// SQL: letras como Ñ o É se descartan en ambos lados.
+func NormalizarPlaca(placa string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return -1
+	}, placa)
+}
+
+// CoincidePlaca indica si la placa contiene la búsqueda ya normalizada
+func CoincidePlaca(placa, busqueda string) bool {
+	return strings.Contains(NormalizarPlaca(placa), busqueda)
+}
+
+// OrdenarResultadosPlaca ordena primero la coincidencia exacta y luego por placa
+func OrdenarResultadosPlaca(resultados []ResultadoPlaca, busqueda string) {
+	sort.SliceStable(resultados, func(i, j int) bool {
+		exactaI := NormalizarPlaca(resultados[i].Placa) == busqueda
+		exactaJ := NormalizarPlaca(resultados[j].Placa) == busqueda
+		if exactaI != exactaJ {
+			return exactaI
+		}
+		return resultados[i].Placa < resultados[j].Placa
+	})
+}
+
+// AsignarTickets reparte los tickets entre los vehículos encontrados: el abierto queda
+// como ticket activo y los cerrados como visitas, como máximo visitas por vehículo y
+// de la más reciente a la más antigua
+func AsignarTickets(resultados []ResultadoPlaca, tickets []Ticket, visitas int) {
+	ordenados := make([]Ticket, len(tickets))
+	copy(ordenados, tickets)
+	sort.SliceStable(ordenados, func(i, j int) bool {
+		return ordenados[i].FechaIngreso.After(ordenados[j].FechaIngreso)
+	})
+
+	indice := make(map[string]int, len(resultados))
+	for i := range resultados {
+		indice[resultados[i].VehiculoID] = i
+		if resultados[i].Visitas == nil {
+			resultados[i].Visitas = []Ticket{}
+		}
+	}
+
+	for _, ticket := range ordenados {
+		i, ok := indice[ticket.VehiculoID]
+		if !ok {
+			continue
+		}
+		resultado := &resultados[i]
+		if ticket.FechaSalida == nil {
+			if resultado.TicketActivo == nil {
+				activo := ticket
+				resultado.TicketActivo = &activo
+				resultado.Estacionado = true
+			}
+			continue
+		}
+		if len(resultado.Visitas) < visitas {
+			resultado.Visitas = append(resultado.Visitas, ticket)
+		}
+	}
+}
+
+// MarcarMultasActivos completa las multas pendientes en el ticket activo de cada resultado
+func MarcarMultasActivos(resultados []ResultadoPlaca, porVehiculo map[string]ResumenMultas) {
+	for i := range resultados {
+		if activo := resultados[i].TicketActivo; activo != nil {
+			resumen := porVehiculo[activo.VehiculoID]
+			activo.MultasPendientes = resumen.Cantidad
+			activo.MontoMultasPendientes = resumen.Monto
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package models
+
+import "testing"
+
+func TestNormalizarPlaca(t *testing.T) {
+	for _, caso := range []struct {
+		placa, esperado string
+	}{
+		{"abc-123", "ABC123"},
+		{" Pbx 0942 ", "PBX0942"},
+		// Igual que '[^A-Z0-9]' en SQL: fuera de ASCII no queda nada
+		{"ñab-12", "AB12"},
+		{"ÉCU-٣45", "CU45"},
+		{"ÑÑ", ""},
+	} {
+		if got := NormalizarPlaca(caso.placa); got != caso.esperado {
+			t.Errorf("NormalizarPlaca(%q) = %q, se esperaba %q", caso.placa, got, caso.esperado)
+		}
+	}
+}
+
+func TestCoincidePlaca(t *testing.T) {
+	if !CoincidePlaca("pbñ-1234", "PB1234") {
+		t.Error("CoincidePlaca debe ignorar las letras fuera de ASCII como el SQL")
+	}
+	if CoincidePlaca("abc-123", "ABD") {
+		t.Error("CoincidePlaca(abc-123, ABD) = true, se esperaba false")
+	}
+}
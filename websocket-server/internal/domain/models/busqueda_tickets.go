@@ -0,0 +1,151 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Fechas por las que se filtra y ordena la búsqueda de tickets
+const (
+	CampoIngreso = "ingreso"
+	CampoSalida  = "salida" // solo tickets cerrados
+)
+
+// FiltroBusquedaTickets filtros de la búsqueda histórica de tickets. Todos son
+// opcionales; el rango [Desde, Hasta) se aplica a la fecha indicada por Campo.
+type FiltroBusquedaTickets struct {
+	Desde      *time.Time `json:"desde,omitempty"`
+	Hasta      *time.Time `json:"hasta,omitempty"`
+	Campo      string     `json:"campo,omitempty"`   // "ingreso" (por defecto) o "salida"
+	Seccion    string     `json:"seccion,omitempty"` // letra de sección
+	Placa      string     `json:"placa,omitempty"`   // placa completa o parcial
+	Pagado     *bool      `json:"pagado,omitempty"`  // con o sin detalle de pago
+	MinMinutos *int       `json:"min_minutos,omitempty"`
+	MaxMinutos *int       `json:"max_minutos,omitempty"` // duración; los abiertos cuentan hasta ahora
+}
+
+// Fecha devuelve la fecha del ticket por la que filtra y ordena el filtro, o nil si
+// el ticket no la tiene (salida de un ticket abierto)
+func (f FiltroBusquedaTickets) Fecha(ticket Ticket) *time.Time {
+	if f.Campo == CampoSalida {
+		return ticket.FechaSalida
+	}
+	return &ticket.FechaIngreso
+}
+
+// Coincide indica si el ticket cumple el filtro, ya normalizado (Placa con
+// NormalizarPlaca y Seccion en mayúsculas). ahora es el fin de los tickets abiertos.
+func (f FiltroBusquedaTickets) Coincide(ticket Ticket, ahora time.Time) bool {
+	fecha := f.Fecha(ticket)
+	if fecha == nil {
+		return false
+	}
+	if f.Desde != nil && fecha.Before(*f.Desde) {
+		return false
+	}
+	if f.Hasta != nil && !fecha.Before(*f.Hasta) {
+		return false
+	}
+	if f.Seccion != "" && strings.ToUpper(ticket.SeccionLetra) != f.Seccion {
+		return false
+	}
+	if f.Placa != "" && !CoincidePlaca(ticket.VehiculoPlaca, f.Placa) {
+		return false
+	}
+	if f.Pagado != nil && (ticket.DetallePagoID != nil) != *f.Pagado {
+		return false
+	}
+
+	fin := ahora
+	if ticket.FechaSalida != nil {
+		fin = *ticket.FechaSalida
+	}
+	minutos := int(fin.Sub(ticket.FechaIngreso) / time.Minute)
+	if f.MinMinutos != nil && minutos < *f.MinMinutos {
+		return false
+	}
+	if f.MaxMinutos != nil && minutos > *f.MaxMinutos {
+		return false
+	}
+	return true
+}
+
+// CursorTickets posición de la última fila de una página: fecha del campo filtrado e ID
+type CursorTickets struct {
+	Fecha time.Time
+	ID    string
+}
+
+// ErrCursorInvalido el cursor recibido no fue generado por la búsqueda
+var ErrCursorInvalido = errors.New("cursor inválido")
+
+// String codifica el cursor para enviarlo al cliente
+func (c CursorTickets) String() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.Fecha.Format(time.RFC3339Nano) + "|" + c.ID))
+}
+
+// ParseCursorTickets decodifica un cursor generado por CursorTickets.String
+func ParseCursorTickets(value string) (*CursorTickets, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrCursorInvalido
+	}
+	fecha, id, ok := strings.Cut(string(raw), "|")
+	if !ok || id == "" {
+		return nil, ErrCursorInvalido
+	}
+	t, err := time.Parse(time.RFC3339Nano, fecha)
+	if err != nil {
+		return nil, ErrCursorInvalido
+	}
+	return &CursorTickets{Fecha: t, ID: id}, nil
+}
+
+// Antes indica si la posición (fecha, id) va después del cursor en el orden
+// descendente de la búsqueda
+func (c CursorTickets) Antes(fecha time.Time, id string) bool {
+	if !fecha.Equal(c.Fecha) {
+		return fecha.Before(c.Fecha)
+	}
+	return id < c.ID
+}
+
+// PaginarTickets filtra, ordena por fecha del campo e ID descendentes y devuelve como
+// máximo limite tickets posteriores al cursor, para las fuentes que descargan todos
+// los tickets
+func PaginarTickets(tickets []Ticket, filtro FiltroBusquedaTickets, despues *CursorTickets, limite int, ahora time.Time) []Ticket {
+	pagina := []Ticket{}
+	for _, ticket := range tickets {
+		if !filtro.Coincide(ticket, ahora) {
+			continue
+		}
+		if despues != nil && !despues.Antes(*filtro.Fecha(ticket), ticket.ID) {
+			continue
+		}
+		pagina = append(pagina, ticket)
+	}
+
+	sort.Slice(pagina, func(i, j int) bool {
+		fi, fj := *filtro.Fecha(pagina[i]), *filtro.Fecha(pagina[j])
+		if !fi.Equal(fj) {
+			return fi.After(fj)
+		}
+		return pagina[i].ID > pagina[j].ID
+	})
+	if len(pagina) > limite {
+		pagina = pagina[:limite]
+	}
+	return pagina
+}
+
+// PaginaTickets resultado de una búsqueda de tickets. SiguienteCursor se envía en la
+// próxima consulta con los mismos filtros; vacío indica que no hay más resultados.
+type PaginaTickets struct {
+	Tickets         []Ticket              `json:"tickets"`
+	Limite          int                   `json:"limite"`
+	SiguienteCursor string                `json:"siguiente_cursor,omitempty"`
+	Filtro          FiltroBusquedaTickets `json:"filtro"`
+}
@@ -0,0 +1,160 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// Los cursores no van firmados: uno modificado que sigue decodificando es solo otra
+// posición, y la búsqueda vuelve a aplicar los filtros
+func TestParseCursorTickets(t *testing.T) {
+	fecha := time.Date(2026, 7, 15, 10, 30, 0, 123456789, time.UTC)
+	codificar := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	t.Run("ida y vuelta", func(t *testing.T) {
+		for _, id := range []string{"t-1", "a|b"} {
+			original := CursorTickets{Fecha: fecha, ID: id}
+			cursor, err := ParseCursorTickets(original.String())
+			if err != nil {
+				t.Fatalf("ParseCursorTickets(%q): %v", original.String(), err)
+			}
+			if !cursor.Fecha.Equal(fecha) || cursor.ID != id {
+				t.Errorf("cursor = %+v, se esperaba %+v", *cursor, original)
+			}
+		}
+	})
+
+	valido := CursorTickets{Fecha: fecha, ID: "t-1"}.String()
+	for _, caso := range []struct {
+		nombre string
+		cursor string
+	}{
+		{"vacío", ""},
+		{"no es base64", "!!!"},
+		{"base64 con relleno", base64.URLEncoding.EncodeToString([]byte(fecha.Format(time.RFC3339Nano) + "|t-1"))},
+		{"sin separador", codificar(fecha.Format(time.RFC3339Nano))},
+		{"sin ID", codificar(fecha.Format(time.RFC3339Nano) + "|")},
+		{"fecha inválida", codificar("ayer|t-1")},
+		{"fecha sin zona", codificar("2026-07-15T10:30:00|t-1")},
+		{"alterado en la fecha", valido[:2] + "B" + valido[3:]},
+		{"truncado", valido[:10]},
+	} {
+		t.Run(caso.nombre, func(t *testing.T) {
+			if cursor, err := ParseCursorTickets(caso.cursor); !errors.Is(err, ErrCursorInvalido) {
+				t.Errorf("ParseCursorTickets(%q) = %+v, %v; se esperaba ErrCursorInvalido", caso.cursor, cursor, err)
+			}
+		})
+	}
+}
+
+func TestCursorTicketsAntes(t *testing.T) {
+	fecha := time.Date(2026, 7, 15, 10, 0, 0, 0, time.UTC)
+	c := CursorTickets{Fecha: fecha, ID: "m"}
+
+	casos := []struct {
+		nombre string
+		fecha  time.Time
+		id     string
+		want   bool
+	}{
+		{"más antiguo", fecha.Add(-time.Nanosecond), "z", true},
+		{"más reciente", fecha.Add(time.Nanosecond), "a", false},
+		{"empate con ID menor", fecha, "l", true},
+		{"empate con ID mayor", fecha, "n", false},
+		{"la fila del cursor", fecha, "m", false},
+		{"empate en otra zona horaria", fecha.In(time.FixedZone("-05", -5*3600)), "l", true},
+		// Orden de bytes, como COLLATE "C": las mayúsculas van antes que las minúsculas
+		{"mayúscula antes que minúscula", fecha, "Z", true},
+		{"no ASCII después de ASCII", fecha, "é", false},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			if got := c.Antes(caso.fecha, caso.id); got != caso.want {
+				t.Errorf("Antes(%v, %q) = %v, se esperaba %v", caso.fecha, caso.id, got, caso.want)
+			}
+		})
+	}
+
+	// "10" < "9" como texto: el cursor compara IDs como strings, no como números
+	if !(CursorTickets{Fecha: fecha, ID: "9"}).Antes(fecha, "10") {
+		t.Error(`Antes(fecha, "10") con cursor "9" = false, se esperaba true`)
+	}
+}
+
+// ticketsPaginacion tickets con empates de fecha, IDs que difieren entre orden de
+// bytes y orden alfabético, y tickets abiertos
+func ticketsPaginacion() []Ticket {
+	base := time.Date(2026, 7, 15, 8, 0, 0, 0, time.UTC)
+	salida := func(d time.Duration) *time.Time { s := base.Add(d); return &s }
+	return []Ticket{
+		{ID: "a", FechaIngreso: base, FechaSalida: salida(2 * time.Hour)},
+		{ID: "B", FechaIngreso: base, FechaSalida: salida(2 * time.Hour)},
+		{ID: "é", FechaIngreso: base, FechaSalida: salida(3 * time.Hour)},
+		{ID: "10", FechaIngreso: base},
+		{ID: "9", FechaIngreso: base.Add(time.Hour)},
+		{ID: "Z", FechaIngreso: base.Add(time.Hour), FechaSalida: salida(2 * time.Hour)},
+		{ID: "c", FechaIngreso: base.Add(-time.Hour), FechaSalida: salida(time.Hour)},
+		{ID: "d", FechaIngreso: base.Add(-2 * time.Hour)},
+	}
+}
+
+// recorrer pide páginas de tamaño limite siguiendo el último ticket de cada una hasta
+// una página incompleta y devuelve los IDs en orden
+func recorrer(t *testing.T, tickets []Ticket, filtro FiltroBusquedaTickets, limite int, ahora time.Time) []string {
+	t.Helper()
+	var ids []string
+	var despues *CursorTickets
+	for paginas := 0; ; paginas++ {
+		if paginas > len(tickets) {
+			t.Fatalf("más de %d páginas: el cursor no avanza (%v)", len(tickets), ids)
+		}
+		pagina := PaginarTickets(tickets, filtro, despues, limite, ahora)
+		for _, ticket := range pagina {
+			ids = append(ids, ticket.ID)
+		}
+		if len(pagina) < limite {
+			return ids
+		}
+		ultimo := pagina[len(pagina)-1]
+		despues = &CursorTickets{Fecha: *filtro.Fecha(ultimo), ID: ultimo.ID}
+	}
+}
+
+func TestPaginarTickets(t *testing.T) {
+	ahora := time.Date(2026, 7, 15, 12, 0, 0, 0, time.UTC)
+	tickets := ticketsPaginacion()
+
+	casos := []struct {
+		nombre string
+		filtro FiltroBusquedaTickets
+		want   []string
+	}{
+		// Empates por fecha en orden de bytes descendente: é > a > Z > B > 9 > 10
+		{"por ingreso", FiltroBusquedaTickets{Campo: CampoIngreso}, []string{"Z", "9", "é", "a", "B", "10", "c", "d"}},
+		{"por salida sin abiertos", FiltroBusquedaTickets{Campo: CampoSalida}, []string{"é", "a", "Z", "B", "c"}},
+	}
+	for _, caso := range casos {
+		for limite := 1; limite <= len(tickets)+1; limite++ {
+			if got := recorrer(t, tickets, caso.filtro, limite, ahora); !reflect.DeepEqual(got, caso.want) {
+				t.Errorf("%s con limite %d: %v, se esperaba %v", caso.nombre, limite, got, caso.want)
+			}
+		}
+	}
+
+	// El orden de los empates es el de bytes (COLLATE "C"), no el alfabético
+	ids := []string{"a", "B", "é", "10", "9", "Z"}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	if want := []string{"é", "a", "Z", "B", "9", "10"}; !reflect.DeepEqual(ids, want) {
+		t.Fatalf("orden de bytes %v, se esperaba %v", ids, want)
+	}
+
+	// Un cursor posterior a todo devuelve una página vacía, no nil
+	despues := &CursorTickets{Fecha: time.Time{}, ID: ""}
+	if pagina := PaginarTickets(tickets, FiltroBusquedaTickets{}, despues, 10, ahora); pagina == nil || len(pagina) != 0 {
+		t.Errorf("página tras el último = %v, se esperaba vacía", pagina)
+	}
+}
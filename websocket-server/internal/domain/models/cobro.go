@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/tarifa"
+)
+
+// TicketsConCobro copia los tickets completando en los abiertos el tiempo transcurrido
+// y, si se conoce su tarifa, el monto acumulado a ahora. No modifica tickets, que
+// puede ser un snapshot compartido.
+func TicketsConCobro(tickets []Ticket, ahora time.Time) []Ticket {
+	if tickets == nil {
+		return nil
+	}
+
+	copia := make([]Ticket, len(tickets))
+	for i, ticket := range tickets {
+		if ticket.FechaSalida == nil {
+			ticket.MinutosTranscurridos, ticket.MontoAcumulado = cobro(ticket.Tarifa, ticket.FechaIngreso, ahora)
+		}
+		copia[i] = ticket
+	}
+	return copia
+}
+
+// SeccionesConCobro copia las secciones completando tiempo transcurrido y monto
+// acumulado de los espacios ocupados con hora de ingreso
+func SeccionesConCobro(secciones []EspaciosPorSeccion, ahora time.Time) []EspaciosPorSeccion {
+	if secciones == nil {
+		return nil
+	}
+
+	copia := make([]EspaciosPorSeccion, len(secciones))
+	for i, seccion := range secciones {
+		copia[i] = seccion
+		copia[i].Espacios = make([]EspacioDetalle, len(seccion.Espacios))
+		for j, espacio := range seccion.Espacios {
+			if !espacio.Estado && espacio.HoraIngreso != nil {
+				if ingreso, err := time.Parse(time.RFC3339, *espacio.HoraIngreso); err == nil {
+					espacio.MinutosTranscurridos, espacio.MontoAcumulado = cobro(espacio.Tarifa, ingreso, ahora)
+				}
+			}
+			copia[i].Espacios[j] = espacio
+		}
+	}
+	return copia
+}
+
+// cobro minutos desde el ingreso y monto acumulado (nil sin tarifa)
+func cobro(t *tarifa.Tarifa, ingreso, ahora time.Time) (*int, *float64) {
+	var precios tarifa.Tarifa
+	if t != nil {
+		precios = *t
+	}
+
+	acumulado := precios.Acumulado(ingreso, ahora)
+	if t == nil {
+		return &acumulado.Minutos, nil
+	}
+	return &acumulado.Minutos, &acumulado.Monto
+}
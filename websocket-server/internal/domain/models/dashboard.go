@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/tarifa"
+)
 
 // DashboardData contiene todos los datos del dashboard
 type DashboardData struct {
@@ -11,22 +15,53 @@ type DashboardData struct {
 	DineroRecaudadoMes  float64   `json:"dinero_recaudado_mes"`
 	VehiculosActivos    int       `json:"vehiculos_activos"`
 	Timestamp           time.Time `json:"timestamp"`
+	Version             uint64    `json:"version,omitempty"`
+	Stale               bool      `json:"stale"` // la fuente no respondió: datos del último snapshot válido
+
+	// Multas en estado pendiente de todo el estacionamiento
+	MultasPendientes      int     `json:"multas_pendientes"`
+	MontoMultasPendientes float64 `json:"monto_multas_pendientes"`
+
+	// Pronóstico de ocupación por sección (solo con FORECAST_IN_DASHBOARD=true)
+	Pronostico *Pronostico `json:"pronostico,omitempty"`
+}
+
+// DashboardDelta contiene solo los campos del dashboard que cambiaron respecto a BaseVersion
+type DashboardDelta struct {
+	Version     uint64                 `json:"version"`
+	BaseVersion uint64                 `json:"base_version"`
+	Changes     map[string]interface{} `json:"changes"`
+	Timestamp   time.Time              `json:"timestamp"`
 }
 
 // EspacioOcupadoEvent evento cuando se ocupa un espacio
 type EspacioOcupadoEvent struct {
 	EspacioID     string    `json:"espacio_id"`
 	Numero        string    `json:"numero"`
+	SeccionLetra  string    `json:"seccion_letra"`
 	VehiculoPlaca string    `json:"vehiculo_placa"`
 	HoraIngreso   time.Time `json:"hora_ingreso"`
 }
 
 // EspacioLiberadoEvent evento cuando se libera un espacio
 type EspacioLiberadoEvent struct {
-	EspacioID   string    `json:"espacio_id"`
-	Numero      string    `json:"numero"`
-	MontoPagado float64   `json:"monto_pagado"`
-	HoraSalida  time.Time `json:"hora_salida"`
+	EspacioID    string    `json:"espacio_id"`
+	Numero       string    `json:"numero"`
+	SeccionLetra string    `json:"seccion_letra"`
+	MontoPagado  float64   `json:"monto_pagado"`
+	HoraSalida   time.Time `json:"hora_salida"`
+}
+
+// NotificacionEspacio payload publicado por los triggers de PostgreSQL (LISTEN/NOTIFY).
+// Las notificaciones de la tabla multa traen multa_id y estado en lugar del espacio.
+type NotificacionEspacio struct {
+	Tabla      string `json:"tabla"`
+	Operacion  string `json:"operacion"`
+	EspacioID  string `json:"espacio_id"`
+	TicketID   string `json:"ticket_id,omitempty"`
+	Disponible bool   `json:"disponible"`
+	MultaID    string `json:"multa_id,omitempty"`
+	Estado     string `json:"estado,omitempty"`
 }
 
 // EspacioDetalle información detallada de un espacio para el dashboard
@@ -37,6 +72,11 @@ type EspacioDetalle struct {
 	SeccionLetra  string  `json:"seccion_letra"`
 	VehiculoPlaca *string `json:"vehiculo_placa,omitempty"`
 	HoraIngreso   *string `json:"hora_ingreso,omitempty"`
+
+	// Tarifa del vehículo estacionado; tiempo y cobro acumulado se calculan al enviar
+	Tarifa               *tarifa.Tarifa `json:"-"`
+	MinutosTranscurridos *int           `json:"minutos_transcurridos,omitempty"`
+	MontoAcumulado       *float64       `json:"monto_acumulado,omitempty"`
 }
 
 // EspaciosPorSeccion agrupa espacios por sección
@@ -47,3 +87,34 @@ type EspaciosPorSeccion struct {
 	EspaciosOcupados    int              `json:"espacios_ocupados"`
 	Espacios            []EspacioDetalle `json:"espacios"`
 }
+
+// OverstayAlert alerta de un vehículo que superó su permanencia máxima. Nivel 0 es el
+// cruce del límite; cada paso de escalamiento alcanzado suma uno.
+type OverstayAlert struct {
+	TicketID         string    `json:"ticket_id"`
+	VehiculoPlaca    string    `json:"vehiculo_placa"`
+	EspacioID        string    `json:"espacio_id"`
+	EspacioNumero    string    `json:"espacio_numero"`
+	SeccionLetra     string    `json:"seccion_letra"`
+	HoraIngreso      time.Time `json:"hora_ingreso"`
+	MaximoMinutos    int       `json:"maximo_minutos"`
+	MinutosExcedidos int       `json:"minutos_excedidos"`
+	Nivel            int       `json:"nivel"`
+	Regla            string    `json:"regla"` // "global", "seccion:<letra>" o "categoria:<nombre>"
+	Timestamp        time.Time `json:"timestamp"`
+}
+
+// CapacidadAlerta cambio de nivel de ocupación de una sección: "advertencia" o "lleno"
+// al alcanzar el umbral y "normal" cuando la alerta se despeja
+type CapacidadAlerta struct {
+	SeccionLetra        string    `json:"seccion_letra"`
+	Nivel               string    `json:"nivel"`
+	NivelAnterior       string    `json:"nivel_anterior"`
+	Porcentaje          float64   `json:"porcentaje"`
+	EspaciosOcupados    int       `json:"espacios_ocupados"`
+	EspaciosDisponibles int       `json:"espacios_disponibles"`
+	TotalEspacios       int       `json:"total_espacios"`
+	UmbralAdvertencia   float64   `json:"umbral_advertencia"`
+	UmbralLleno         float64   `json:"umbral_lleno"`
+	Timestamp           time.Time `json:"timestamp"`
+}
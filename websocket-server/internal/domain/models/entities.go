@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/tarifa"
+)
 
 // Espacio representa un espacio de estacionamiento
 type Espacio struct {
@@ -18,6 +22,23 @@ type Ticket struct {
 	VehiculoID    string     `json:"vehiculo_id"`
 	EspacioID     string     `json:"espacio_id"`
 	DetallePagoID *string    `json:"detalle_pago_id,omitempty"`
+
+	// Vista enriquecida: vehículo y espacio del ticket
+	VehiculoPlaca     string `json:"vehiculo_placa,omitempty"`
+	VehiculoMarca     string `json:"vehiculo_marca,omitempty"`
+	VehiculoModelo    string `json:"vehiculo_modelo,omitempty"`
+	VehiculoCategoria string `json:"vehiculo_categoria,omitempty"`
+	EspacioNumero     string `json:"espacio_numero,omitempty"`
+	SeccionLetra      string `json:"seccion_letra,omitempty"`
+
+	// Multas pendientes del vehículo: mayor que cero = no debe salir sin regularizar
+	MultasPendientes      int     `json:"multas_pendientes,omitempty"`
+	MontoMultasPendientes float64 `json:"monto_multas_pendientes,omitempty"`
+
+	// Tarifa del tipo de vehículo; tiempo y cobro acumulado se calculan al enviar
+	Tarifa               *tarifa.Tarifa `json:"-"`
+	MinutosTranscurridos *int           `json:"minutos_transcurridos,omitempty"`
+	MontoAcumulado       *float64       `json:"monto_acumulado,omitempty"`
 }
 
 // Pago representa un pago realizado
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+const (
+	// SinTarifa grupo de los pagos sin tipo de tarifa asociado
+	SinTarifa = "(sin tarifa)"
+
+	// SinSeccion grupo de los pagos cuyo ticket no tiene espacio con sección
+	SinSeccion = "(sin sección)"
+)
+
+// IngresosGrupo total recaudado por un grupo del desglose (método, tarifa o sección)
+type IngresosGrupo struct {
+	Clave string  `json:"clave"`
+	Total float64 `json:"total"`
+	Pagos int     `json:"pagos"`
+}
+
+// IngresosHora total recaudado en una hora del día (0 a 23)
+type IngresosHora struct {
+	Hora  int     `json:"hora"`
+	Total float64 `json:"total"`
+	Pagos int     `json:"pagos"`
+}
+
+// DesgloseIngresos recaudación de detalle_pago en [Desde, Hasta) desglosada por
+// método de pago, tipo de tarifa, sección del espacio y hora del día
+type DesgloseIngresos struct {
+	Desde      time.Time       `json:"desde"`
+	Hasta      time.Time       `json:"hasta"`
+	Total      float64         `json:"total"`
+	Pagos      int             `json:"pagos"`
+	PorMetodo  []IngresosGrupo `json:"por_metodo"`
+	PorTarifa  []IngresosGrupo `json:"por_tarifa"`
+	PorSeccion []IngresosGrupo `json:"por_seccion"`
+	PorHora    []IngresosHora  `json:"por_hora"` // siempre 24 elementos, también las horas sin pagos
+}
+
+// PagoDetallado pago con los datos por los que se desglosa la recaudación
+type PagoDetallado struct {
+	Metodo  string
+	Fecha   time.Time
+	Total   float64
+	Tarifa  string
+	Seccion string
+}
+
+// NewDesgloseIngresos agrupa los pagos de [desde, hasta). Los grupos se ordenan de
+// mayor a menor total y la hora del día se calcula con HoraDelDia.
+func NewDesgloseIngresos(desde, hasta time.Time, pagos []PagoDetallado) *DesgloseIngresos {
+	desglose := &DesgloseIngresos{Desde: desde, Hasta: hasta, PorHora: HorasVacias()}
+	metodos := map[string]*IngresosGrupo{}
+	tarifas := map[string]*IngresosGrupo{}
+	secciones := map[string]*IngresosGrupo{}
+
+	for _, pago := range pagos {
+		if pago.Fecha.Before(desde) || !pago.Fecha.Before(hasta) {
+			continue
+		}
+		desglose.Total += pago.Total
+		desglose.Pagos++
+
+		tarifa := pago.Tarifa
+		if tarifa == "" {
+			tarifa = SinTarifa
+		}
+		seccion := pago.Seccion
+		if seccion == "" {
+			seccion = SinSeccion
+		}
+		sumarGrupo(metodos, pago.Metodo, pago.Total)
+		sumarGrupo(tarifas, tarifa, pago.Total)
+		sumarGrupo(secciones, seccion, pago.Total)
+
+		hora := &desglose.PorHora[HoraDelDia(pago.Fecha.Unix()/3600)]
+		hora.Total += pago.Total
+		hora.Pagos++
+	}
+
+	desglose.PorMetodo = ordenarGrupos(metodos)
+	desglose.PorTarifa = ordenarGrupos(tarifas)
+	desglose.PorSeccion = ordenarGrupos(secciones)
+	return desglose
+}
+
+// HoraDelDia hora del día (0 a 23), en la zona horaria del servidor (time.Local), de
+// la hora que empieza en horaUnix*3600 segundos Unix. Todas las fuentes agrupan los
+// pagos por hora Unix y la convierten con esta función, para que el desglose por hora
+// no dependa de la zona horaria de la sesión de PostgreSQL ni de la de desde.
+func HoraDelDia(horaUnix int64) int {
+	return time.Unix(horaUnix*3600, 0).In(time.Local).Hour()
+}
+
+// HorasVacias las 24 horas del día sin pagos
+func HorasVacias() []IngresosHora {
+	horas := make([]IngresosHora, 24)
+	for i := range horas {
+		horas[i].Hora = i
+	}
+	return horas
+}
+
+// sumarGrupo suma un pago al grupo con la clave indicada
+func sumarGrupo(grupos map[string]*IngresosGrupo, clave string, total float64) {
+	grupo, ok := grupos[clave]
+	if !ok {
+		grupo = &IngresosGrupo{Clave: clave}
+		grupos[clave] = grupo
+	}
+	grupo.Total += total
+	grupo.Pagos++
+}
+
+// ordenarGrupos lista los grupos de mayor a menor total
+func ordenarGrupos(grupos map[string]*IngresosGrupo) []IngresosGrupo {
+	lista := make([]IngresosGrupo, 0, len(grupos))
+	for _, grupo := range grupos {
+		lista = append(lista, *grupo)
+	}
+	sort.Slice(lista, func(i, j int) bool {
+		if lista[i].Total != lista[j].Total {
+			return lista[i].Total > lista[j].Total
+		}
+		return lista[i].Clave < lista[j].Clave
+	})
+	return lista
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// zonaLocal cambia time.Local durante el test
+func zonaLocal(t *testing.T, zona *time.Location) {
+	t.Helper()
+	anterior := time.Local
+	time.Local = zona
+	t.Cleanup(func() { time.Local = anterior })
+}
+
+func TestHoraDelDia(t *testing.T) {
+	casos := []struct {
+		nombre string
+		zona   *time.Location
+		fecha  time.Time
+		want   int
+	}{
+		{"UTC", time.UTC, time.Date(2026, 7, 15, 10, 59, 0, 0, time.UTC), 10},
+		{"zona negativa", time.FixedZone("-05", -5*3600), time.Date(2026, 7, 15, 3, 0, 0, 0, time.UTC), 22},
+		{"fecha en otra zona", time.FixedZone("-05", -5*3600), time.Date(2026, 7, 15, 10, 0, 0, 0, time.FixedZone("+02", 2*3600)), 3},
+		// Con desfase de media hora cuenta el inicio de la hora: 10:45 UTC es 16:15 local
+		// pero su hora empieza a las 15:30
+		{"desfase de media hora", time.FixedZone("+0530", 5*3600+1800), time.Date(2026, 7, 15, 10, 45, 0, 0, time.UTC), 15},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			zonaLocal(t, caso.zona)
+			if got := HoraDelDia(caso.fecha.Unix() / 3600); got != caso.want {
+				t.Errorf("HoraDelDia(%v) = %d, se esperaba %d", caso.fecha, got, caso.want)
+			}
+		})
+	}
+}
+
+func TestNewDesgloseIngresos(t *testing.T) {
+	zonaLocal(t, time.FixedZone("-05", -5*3600))
+
+	// El rango llega en UTC: la hora del día no depende de la zona de desde
+	desde := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	hasta := time.Date(2026, 7, 2, 0, 0, 0, 0, time.UTC)
+	pago := func(hora, minuto int, metodo string, total float64, tarifa, seccion string) PagoDetallado {
+		return PagoDetallado{Metodo: metodo, Fecha: time.Date(2026, 7, 1, hora, minuto, 0, 0, time.UTC), Total: total, Tarifa: tarifa, Seccion: seccion}
+	}
+	pagos := []PagoDetallado{
+		pago(2, 10, "efectivo", 10, "Normal", "A"),                       // 21:10 local
+		pago(2, 50, "tarjeta", 5, "Normal", "B"),                         // 21:50 local
+		pago(15, 0, "tarjeta", 20, "", "A"),                              // 10:00 local
+		pago(23, 59, "efectivo", 7, "Moto", ""),                          // 18:59 local
+		{Metodo: "efectivo", Fecha: desde.Add(-time.Second), Total: 100}, // antes del rango
+		{Metodo: "efectivo", Fecha: hasta, Total: 100},                   // fin exclusivo
+	}
+
+	desglose := NewDesgloseIngresos(desde, hasta, pagos)
+
+	if desglose.Total != 42 || desglose.Pagos != 4 {
+		t.Errorf("total %v en %d pagos, se esperaba 42 en 4", desglose.Total, desglose.Pagos)
+	}
+	if want := []IngresosGrupo{{"tarjeta", 25, 2}, {"efectivo", 17, 2}}; !reflect.DeepEqual(desglose.PorMetodo, want) {
+		t.Errorf("por método %v, se esperaba %v", desglose.PorMetodo, want)
+	}
+	if want := []IngresosGrupo{{SinTarifa, 20, 1}, {"Normal", 15, 2}, {"Moto", 7, 1}}; !reflect.DeepEqual(desglose.PorTarifa, want) {
+		t.Errorf("por tarifa %v, se esperaba %v", desglose.PorTarifa, want)
+	}
+	if want := []IngresosGrupo{{"A", 30, 2}, {SinSeccion, 7, 1}, {"B", 5, 1}}; !reflect.DeepEqual(desglose.PorSeccion, want) {
+		t.Errorf("por sección %v, se esperaba %v", desglose.PorSeccion, want)
+	}
+
+	want := HorasVacias()
+	want[21] = IngresosHora{Hora: 21, Total: 15, Pagos: 2}
+	want[10] = IngresosHora{Hora: 10, Total: 20, Pagos: 1}
+	want[18] = IngresosHora{Hora: 18, Total: 7, Pagos: 1}
+	if !reflect.DeepEqual(desglose.PorHora, want) {
+		t.Errorf("por hora %v, se esperaba %v", desglose.PorHora, want)
+	}
+}
+
+func TestNewDesgloseIngresosVacio(t *testing.T) {
+	ahora := time.Now()
+	desglose := NewDesgloseIngresos(ahora.Add(-time.Hour), ahora, nil)
+
+	if len(desglose.PorHora) != 24 || desglose.PorMetodo == nil || desglose.PorTarifa == nil || desglose.PorSeccion == nil {
+		t.Errorf("desglose vacío = %+v, se esperaban 24 horas y grupos vacíos no nil", desglose)
+	}
+}
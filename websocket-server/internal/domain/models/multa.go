@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Estados de la columna multa.estado
+const (
+	MultaPendiente = "pendiente"
+	MultaPagada    = "pagada"
+	MultaAnulada   = "anulada"
+)
+
+// ResumenMultas cantidad y monto de multas pendientes
+type ResumenMultas struct {
+	Cantidad int     `json:"cantidad"`
+	Monto    float64 `json:"monto"`
+}
+
+// MultaEvent evento cuando se registra o se paga una multa. Los pendientes son los
+// del vehículo después del cambio; espacio y sección solo si el vehículo está estacionado.
+type MultaEvent struct {
+	MultaID               string    `json:"multa_id"`
+	Descripcion           string    `json:"descripcion"`
+	MontoMulta            float64   `json:"monto_multa"`
+	Estado                string    `json:"estado"`
+	FechaMulta            time.Time `json:"fecha_multa"`
+	VehiculoID            string    `json:"vehiculo_id,omitempty"`
+	VehiculoPlaca         string    `json:"vehiculo_placa,omitempty"`
+	TicketID              string    `json:"ticket_id,omitempty"`
+	EspacioID             string    `json:"espacio_id,omitempty"`
+	SeccionLetra          string    `json:"seccion_letra,omitempty"`
+	MultasPendientes      int       `json:"multas_pendientes"`
+	MontoMultasPendientes float64   `json:"monto_multas_pendientes"`
+}
+
+// MarcarMultasPendientes completa en cada ticket las multas pendientes de su vehículo
+func MarcarMultasPendientes(tickets []Ticket, porVehiculo map[string]ResumenMultas) {
+	for i := range tickets {
+		resumen := porVehiculo[tickets[i].VehiculoID]
+		tickets[i].MultasPendientes = resumen.Cantidad
+		tickets[i].MontoMultasPendientes = resumen.Monto
+	}
+}
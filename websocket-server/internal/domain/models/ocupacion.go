@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// SeccionTotal serie de ocupación de todo el estacionamiento (sin sección)
+const SeccionTotal = ""
+
+// OcupacionMinuto ocupación (ocupados / total, de 0 a 1) de una serie acumulada
+// durante un minuto a partir de los snapshots del dashboard
+type OcupacionMinuto struct {
+	Seccion  string
+	Minuto   time.Time
+	Min      float64
+	Max      float64
+	Suma     float64 // suma de las muestras, para promediar al agrupar
+	Muestras int
+}
+
+// OcupacionBucket ocupación mínima, máxima y promedio de un intervalo del historial
+type OcupacionBucket struct {
+	Inicio   time.Time `json:"inicio"`
+	Min      float64   `json:"min"`
+	Max      float64   `json:"max"`
+	Promedio float64   `json:"promedio"`
+	Muestras int       `json:"muestras"`
+}
+
+// HistorialOcupacion serie de ocupación agrupada en intervalos. Los intervalos sin
+// muestras se omiten.
+type HistorialOcupacion struct {
+	Seccion string            `json:"seccion,omitempty"`
+	Bucket  string            `json:"bucket"`
+	Desde   time.Time         `json:"desde"`
+	Hasta   time.Time         `json:"hasta"`
+	Buckets []OcupacionBucket `json:"buckets"`
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// FlujoHora ingresos y salidas de tickets de una sección en una hora (Hora es el
+// inicio de la hora)
+type FlujoHora struct {
+	SeccionLetra string
+	Hora         time.Time
+	Ingresos     int
+	Salidas      int
+}
+
+// MovimientoTicket fechas de un ticket con la sección de su espacio, para agrupar el
+// flujo en las fuentes que devuelven los tickets completos
+type MovimientoTicket struct {
+	SeccionLetra string
+	FechaIngreso time.Time
+	FechaSalida  *time.Time
+}
+
+// AgruparFlujo cuenta por sección y hora los ingresos y salidas ocurridos en
+// [desde, hasta). Los tickets sin sección se descartan.
+func AgruparFlujo(movimientos []MovimientoTicket, desde, hasta time.Time) []FlujoHora {
+	type clave struct {
+		seccion string
+		hora    time.Time
+	}
+	flujos := make(map[clave]*FlujoHora)
+	flujo := func(seccion string, fecha time.Time) *FlujoHora {
+		k := clave{seccion, fecha.Truncate(time.Hour)}
+		if f, ok := flujos[k]; ok {
+			return f
+		}
+		f := &FlujoHora{SeccionLetra: seccion, Hora: k.hora}
+		flujos[k] = f
+		return f
+	}
+	enRango := func(fecha time.Time) bool {
+		return !fecha.Before(desde) && fecha.Before(hasta)
+	}
+
+	for _, m := range movimientos {
+		if m.SeccionLetra == "" {
+			continue
+		}
+		if enRango(m.FechaIngreso) {
+			flujo(m.SeccionLetra, m.FechaIngreso).Ingresos++
+		}
+		if m.FechaSalida != nil && enRango(*m.FechaSalida) {
+			flujo(m.SeccionLetra, *m.FechaSalida).Salidas++
+		}
+	}
+
+	resultado := make([]FlujoHora, 0, len(flujos))
+	for _, f := range flujos {
+		resultado = append(resultado, *f)
+	}
+	sort.Slice(resultado, func(i, j int) bool {
+		if resultado[i].SeccionLetra != resultado[j].SeccionLetra {
+			return resultado[i].SeccionLetra < resultado[j].SeccionLetra
+		}
+		return resultado[i].Hora.Before(resultado[j].Hora)
+	})
+	return resultado
+}
+
+// PronosticoHora ocupación esperada de una sección al final de una hora futura
+type PronosticoHora struct {
+	Hora              time.Time `json:"hora"`
+	IngresosEsperados float64   `json:"ingresos_esperados"`
+	SalidasEsperadas  float64   `json:"salidas_esperadas"`
+	Ocupados          int       `json:"ocupados"`
+	Porcentaje        float64   `json:"porcentaje"`
+}
+
+// PronosticoSeccion ocupación actual de una sección y su pronóstico hora por hora
+type PronosticoSeccion struct {
+	SeccionLetra     string           `json:"seccion_letra"`
+	TotalEspacios    int              `json:"total_espacios"`
+	EspaciosOcupados int              `json:"espacios_ocupados"`
+	Horas            []PronosticoHora `json:"horas"`
+}
+
+// Pronostico ocupación esperada de cada sección para las próximas horas
+type Pronostico struct {
+	GeneradoEn       time.Time           `json:"generado_en"`
+	Modelo           string              `json:"modelo"`
+	SemanasHistorial int                 `json:"semanas_historial"`
+	Secciones        []PronosticoSeccion `json:"secciones"`
+}
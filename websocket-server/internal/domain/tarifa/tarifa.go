@@ -0,0 +1,51 @@
+// Package tarifa calcula el cobro de una estadía con la misma regla que aplica el
+// backend REST al registrar la salida (registro.service.ts, desocuparEspacio), para
+// que el monto mostrado en vivo coincida con el que se cobra en caja.
+package tarifa
+
+import (
+	"math"
+	"time"
+)
+
+// HorasCobroDiario desde esta cantidad de horas se cobran días completos
+const HorasCobroDiario = 8
+
+// Tarifa precios del tipo_tarifa asociado al tipo de vehículo
+type Tarifa struct {
+	PrecioHora float64 `json:"precio_hora"`
+	PrecioDia  float64 `json:"precio_dia"`
+}
+
+// Monto cobro de una estadía de la duración indicada, redondeado a centavos:
+//   - menos de 8 horas: horas iniciadas × precio por hora, con mínimo de una hora
+//   - 8 horas o más: días iniciados (de 24 horas) × precio por día
+func (t Tarifa) Monto(duracion time.Duration) float64 {
+	horas := math.Max(duracion.Hours(), 0)
+
+	var monto float64
+	if horas >= HorasCobroDiario {
+		monto = math.Ceil(horas/24) * t.PrecioDia
+	} else {
+		monto = math.Max(1, math.Ceil(horas)) * t.PrecioHora
+	}
+	return math.Round(monto*100) / 100
+}
+
+// Cobro tiempo transcurrido y monto acumulado de un ticket abierto
+type Cobro struct {
+	Minutos int
+	Monto   float64
+}
+
+// Acumulado calcula el cobro de un ticket que ingresó en ingreso si saliera en ahora
+func (t Tarifa) Acumulado(ingreso, ahora time.Time) Cobro {
+	duracion := ahora.Sub(ingreso)
+	if duracion < 0 {
+		duracion = 0
+	}
+	return Cobro{
+		Minutos: int(duracion / time.Minute),
+		Monto:   t.Monto(duracion),
+	}
+}
@@ -0,0 +1,72 @@
+package tarifa
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMonto(t *testing.T) {
+	tarifa := Tarifa{PrecioHora: 1.5, PrecioDia: 10}
+
+	casos := []struct {
+		nombre   string
+		duracion time.Duration
+		esperado float64
+	}{
+		{"sin tiempo cobra la hora mínima", 0, 1.5},
+		{"duración negativa cobra la hora mínima", -time.Hour, 1.5},
+		{"pocos minutos cobran la hora mínima", 10 * time.Minute, 1.5},
+		{"hora exacta", time.Hour, 1.5},
+		{"hora iniciada se redondea hacia arriba", time.Hour + time.Second, 3},
+		{"justo antes del cobro diario", 8*time.Hour - time.Second, 12},
+		{"desde 8 horas se cobra un día", 8 * time.Hour, 10},
+		{"un día exacto", 24 * time.Hour, 10},
+		{"día iniciado se redondea hacia arriba", 24*time.Hour + time.Minute, 20},
+		{"varios días", 72*time.Hour + 5*time.Hour, 40},
+	}
+
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			if got := tarifa.Monto(c.duracion); got != c.esperado {
+				t.Errorf("Monto(%v) = %v, se esperaba %v", c.duracion, got, c.esperado)
+			}
+		})
+	}
+}
+
+func TestMontoRedondeaCentavos(t *testing.T) {
+	casos := []struct {
+		nombre   string
+		tarifa   Tarifa
+		duracion time.Duration
+		esperado float64
+	}{
+		{"precio por hora con tres decimales", Tarifa{PrecioHora: 0.333}, 3 * time.Hour, 1},
+		{"error de coma flotante", Tarifa{PrecioHora: 0.1}, 3 * time.Hour, 0.3},
+		{"precio por día con tres decimales", Tarifa{PrecioDia: 2.005}, 9 * time.Hour, 2.01},
+	}
+
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			if got := c.tarifa.Monto(c.duracion); got != c.esperado {
+				t.Errorf("Monto(%v) = %v, se esperaba %v", c.duracion, got, c.esperado)
+			}
+		})
+	}
+}
+
+func TestAcumulado(t *testing.T) {
+	tarifa := Tarifa{PrecioHora: 2, PrecioDia: 15}
+	ingreso := time.Date(2026, 1, 10, 8, 0, 0, 0, time.UTC)
+
+	cobro := tarifa.Acumulado(ingreso, ingreso.Add(90*time.Minute+30*time.Second))
+	if cobro.Minutos != 90 || cobro.Monto != 4 {
+		t.Errorf("Acumulado = %+v, se esperaba {Minutos:90 Monto:4}", cobro)
+	}
+
+	// Reloj desfasado: ingreso posterior a ahora
+	cobro = tarifa.Acumulado(ingreso, ingreso.Add(-time.Minute))
+	if cobro.Minutos != 0 || cobro.Monto != 2 {
+		t.Errorf("Acumulado con ingreso futuro = %+v, se esperaba {Minutos:0 Monto:2}", cobro)
+	}
+}
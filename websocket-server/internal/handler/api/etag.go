@@ -0,0 +1,81 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// writeSnapshot responde datos de un snapshot del servicio con un ETag y su frescura en
+// cabeceras (X-Data-As-Of, Age y X-Data-Stale). huella es el valor sobre el que se
+// calcula el ETag, sin los campos que cambian aunque los datos no (nil = el mismo
+// value). Si el ETag coincide con If-None-Match se responde 304 sin cuerpo.
+func writeSnapshot(w http.ResponseWriter, r *http.Request, value, huella interface{}, asOf time.Time, stale bool) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("Error serializando respuesta JSON: %v", err)
+		writeError(w, http.StatusInternalServerError, "internal_error", "Error al serializar la respuesta")
+		return
+	}
+
+	contenido := body
+	if huella != nil {
+		if contenido, err = json.Marshal(huella); err != nil {
+			contenido = body
+		}
+	}
+	etag := calcularETag(contenido, stale)
+
+	header := w.Header()
+	header.Set("ETag", etag)
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Vary", "Authorization") // el contenido depende del rol
+	header.Set("X-Data-As-Of", asOf.UTC().Format(time.RFC3339))
+	header.Set("Age", strconv.Itoa(int(time.Since(asOf).Seconds())))
+	if stale {
+		header.Set("X-Data-Stale", "true")
+	}
+
+	if etagCoincide(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	header.Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(append(body, '\n')); err != nil {
+		log.Printf("Error escribiendo respuesta JSON: %v", err)
+	}
+}
+
+// calcularETag ETag débil: dos respuestas con el mismo ETag tienen los mismos datos,
+// aunque el cuerpo difiera en campos como el timestamp del snapshot
+func calcularETag(contenido []byte, stale bool) string {
+	hash := sha256.New()
+	hash.Write(contenido)
+	if stale {
+		hash.Write([]byte("|stale"))
+	}
+	return `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// etagCoincide compara If-None-Match (lista separada por comas o "*") con el ETag
+// usando la comparación débil de RFC 9110
+func etagCoincide(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidato := range strings.Split(ifNoneMatch, ",") {
+		candidato = strings.TrimSpace(candidato)
+		if candidato == "*" || strings.TrimPrefix(candidato, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
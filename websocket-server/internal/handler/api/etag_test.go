@@ -0,0 +1,109 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestCalcularETag(t *testing.T) {
+	formato := regexp.MustCompile(`^W/"[0-9a-f]{32}"$`)
+
+	etag := calcularETag([]byte(`{"a":1}`), false)
+	if !formato.MatchString(etag) {
+		t.Fatalf("ETag = %q, se esperaba W/\"<32 hex>\"", etag)
+	}
+	if otro := calcularETag([]byte(`{"a":1}`), false); otro != etag {
+		t.Errorf("ETag del mismo contenido = %q, se esperaba %q", otro, etag)
+	}
+	if otro := calcularETag([]byte(`{"a":2}`), false); otro == etag {
+		t.Error("contenido distinto con el mismo ETag")
+	}
+	if otro := calcularETag([]byte(`{"a":1}`), true); otro == etag {
+		t.Error("datos stale con el mismo ETag que los frescos")
+	}
+}
+
+func TestEtagCoincide(t *testing.T) {
+	const etag = `W/"abc"`
+
+	casos := []struct {
+		nombre      string
+		ifNoneMatch string
+		want        bool
+	}{
+		{"sin cabecera", "", false},
+		{"débil igual", `W/"abc"`, true},
+		{"fuerte igual", `"abc"`, true},
+		{"distinto", `W/"abd"`, false},
+		{"sin comillas", `abc`, false},
+		{"lista con el ETag", `"x", W/"abc" , "y"`, true},
+		{"lista sin el ETag", `"x", "y"`, false},
+		{"comodín", `*`, true},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			if got := etagCoincide(caso.ifNoneMatch, etag); got != caso.want {
+				t.Errorf("etagCoincide(%q) = %v, se esperaba %v", caso.ifNoneMatch, got, caso.want)
+			}
+		})
+	}
+}
+
+func TestWriteSnapshot(t *testing.T) {
+	asOf := time.Now().Add(-90 * time.Second)
+	responder := func(ifNoneMatch string, value, huella interface{}, stale bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, Prefix+"/dashboard", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		writeSnapshot(rec, req, value, huella, asOf, stale)
+		return rec
+	}
+
+	rec := responder("", map[string]int{"a": 1}, nil, false)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, se esperaba 200", rec.Code)
+	}
+	etag := rec.Header().Get("ETag")
+	if want := calcularETag([]byte(`{"a":1}`), false); etag != want {
+		t.Errorf("ETag = %q, se esperaba %q", etag, want)
+	}
+	if got := rec.Body.String(); got != "{\"a\":1}\n" {
+		t.Errorf("cuerpo = %q", got)
+	}
+	if got := rec.Header().Get("X-Data-As-Of"); got != asOf.UTC().Format(time.RFC3339) {
+		t.Errorf("X-Data-As-Of = %q", got)
+	}
+	if got := rec.Header().Get("Age"); got != "90" {
+		t.Errorf("Age = %q, se esperaba 90", got)
+	}
+	if got := rec.Header().Get("X-Data-Stale"); got != "" {
+		t.Errorf("X-Data-Stale = %q en datos frescos", got)
+	}
+
+	rec = responder(etag, map[string]int{"a": 1}, nil, false)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("con If-None-Match igual: status = %d y %d bytes, se esperaba 304 sin cuerpo", rec.Code, rec.Body.Len())
+	}
+	if got := rec.Header().Get("ETag"); got != etag {
+		t.Errorf("ETag del 304 = %q, se esperaba %q", got, etag)
+	}
+
+	// La huella excluye campos que cambian sin que cambien los datos
+	rec = responder(etag, map[string]int{"a": 1, "timestamp": 2}, map[string]int{"a": 1}, false)
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("misma huella: status = %d, se esperaba 304", rec.Code)
+	}
+
+	rec = responder(etag, map[string]int{"a": 1}, nil, true)
+	if rec.Code != http.StatusOK {
+		t.Errorf("mismos datos pero stale: status = %d, se esperaba 200", rec.Code)
+	}
+	if got := rec.Header().Get("X-Data-Stale"); got != "true" {
+		t.Errorf("X-Data-Stale = %q, se esperaba true", got)
+	}
+}
@@ -0,0 +1,104 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/auth"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/policy"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/repository/interfaces"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/service/dashboard"
+)
+
+// Prefix prefijo de las rutas de la API JSON
+const Prefix = "/api/v1"
+
+// claimsKey clave del contexto con los claims del token validado
+type claimsKey struct{}
+
+// Handler API JSON de solo lectura para consumidores que no mantienen un WebSocket
+type Handler struct {
+	Service *dashboard.Service
+
+	// Validator valida el access token (Authorization: Bearer); nil deshabilita la autenticación
+	Validator *auth.Validator
+}
+
+// NewHandler crea el handler de la API
+func NewHandler(service *dashboard.Service, validator *auth.Validator) *Handler {
+	return &Handler{Service: service, Validator: validator}
+}
+
+// Register registra las rutas de la API en el mux
+func (h *Handler) Register(mux *http.ServeMux) {
+	mux.HandleFunc(Prefix+"/dashboard", h.requireRoles(policy.RolesTodos, h.Dashboard))
+	mux.HandleFunc(Prefix+"/secciones", h.requireRoles(policy.RolesTodos, h.Secciones))
+	mux.HandleFunc(Prefix+"/espacios/disponibles", h.requireRoles(policy.RolesTodos, h.EspaciosDisponibles))
+	mux.HandleFunc(Prefix+"/tickets/activos", h.requireRoles(policy.RolesTodos, h.TicketsActivos))
+	mux.HandleFunc(Prefix+"/ingresos", h.requireRoles(policy.RolesPersonal, h.Ingresos))
+	mux.HandleFunc(Prefix+"/tickets", h.requireRoles(policy.RolesPersonal, h.BuscarTickets))
+}
+
+// requireRoles exige un access token válido con alguno de los roles indicados y deja
+// los claims en el contexto de la petición
+func (h *Handler) requireRoles(roles []string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "Solo se permite GET")
+			return
+		}
+		if h.Validator == nil {
+			next(w, r)
+			return
+		}
+
+		token, _ := auth.TokenFromRequest(r)
+		claims, err := h.Validator.ValidateToken(token)
+		if err != nil {
+			log.Printf("🔒 Petición a %s rechazada desde %s: %v", r.URL.Path, r.RemoteAddr, err)
+			writeError(w, http.StatusUnauthorized, "unauthorized", "Token de acceso inválido o ausente")
+			return
+		}
+		if !claims.HasRole(roles...) {
+			writeError(w, http.StatusForbidden, "forbidden", "No autorizado para "+r.URL.Path)
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), claimsKey{}, claims)))
+	}
+}
+
+// claimsFrom claims del token de la petición (nil sin autenticación)
+func claimsFrom(r *http.Request) *auth.Claims {
+	claims, _ := r.Context().Value(claimsKey{}).(*auth.Claims)
+	return claims
+}
+
+// writeJSON responde con el valor serializado
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		log.Printf("Error escribiendo respuesta JSON: %v", err)
+	}
+}
+
+// writeError responde con el mismo formato de error que el handshake WebSocket
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]string{"error": code, "message": message})
+}
+
+// writeQueryError responde el error de una consulta al servicio
+func writeQueryError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, dashboard.ErrRangoInvalido), errors.Is(err, dashboard.ErrFiltroInvalido):
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+	case errors.Is(err, interfaces.ErrNotSupported):
+		writeError(w, http.StatusNotImplemented, "unsupported", err.Error())
+	default:
+		log.Printf("%s: %v", message, err)
+		writeError(w, http.StatusBadGateway, "upstream_unavailable", message)
+	}
+}
@@ -0,0 +1,278 @@
+package api
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/auth"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/service/dashboard"
+)
+
+const secretoPrueba = "secreto-de-prueba"
+
+// fuentePrueba fuente con un dashboard y una sección ocupada fijos; registra el
+// usuario de la última consulta de tickets propios
+type fuentePrueba struct {
+	authUser string
+}
+
+func (f *fuentePrueba) Name() string { return "prueba" }
+
+func (f *fuentePrueba) GetDashboardData(context.Context) (*models.DashboardData, error) {
+	return &models.DashboardData{
+		EspaciosDisponibles:   3,
+		EspaciosOcupados:      1,
+		TotalEspacios:         4,
+		DineroRecaudadoHoy:    120,
+		DineroRecaudadoMes:    3400,
+		MultasPendientes:      2,
+		MontoMultasPendientes: 50,
+		Timestamp:             time.Now(),
+	}, nil
+}
+
+func (f *fuentePrueba) GetEspaciosPorSeccion(context.Context) ([]models.EspaciosPorSeccion, error) {
+	placa, ingreso := "ABC123", time.Now().Add(-time.Hour).Format(time.RFC3339)
+	return []models.EspaciosPorSeccion{{
+		SeccionLetra:     "A",
+		TotalEspacios:    1,
+		EspaciosOcupados: 1,
+		Espacios: []models.EspacioDetalle{
+			{ID: "e1", Numero: "A1", SeccionLetra: "A", VehiculoPlaca: &placa, HoraIngreso: &ingreso},
+		},
+	}}, nil
+}
+
+func (f *fuentePrueba) GetEspaciosDisponibles(context.Context) ([]models.EspacioDetalle, error) {
+	return []models.EspacioDetalle{}, nil
+}
+
+func (f *fuentePrueba) GetTicketsActivos(context.Context) ([]models.Ticket, error) {
+	return []models.Ticket{}, nil
+}
+
+func (f *fuentePrueba) GetTicketsActivosByAuthUser(_ context.Context, authUserID string) ([]models.Ticket, error) {
+	f.authUser = authUserID
+	return []models.Ticket{}, nil
+}
+
+func (f *fuentePrueba) GetDesgloseIngresos(context.Context, time.Time, time.Time) (*models.DesgloseIngresos, error) {
+	return &models.DesgloseIngresos{}, nil
+}
+
+func (f *fuentePrueba) GetFlujoHorario(context.Context, time.Time, time.Time) ([]models.FlujoHora, error) {
+	return nil, nil
+}
+
+func (f *fuentePrueba) BuscarPlaca(context.Context, string, int, int) ([]models.ResultadoPlaca, error) {
+	return nil, nil
+}
+
+func (f *fuentePrueba) BuscarTickets(context.Context, models.FiltroBusquedaTickets, *models.CursorTickets, int, time.Time) ([]models.Ticket, error) {
+	return nil, nil
+}
+
+// nuevoServidorPrueba API con autenticación HS256 sobre la fuente de prueba
+func nuevoServidorPrueba(t *testing.T) (*httptest.Server, *fuentePrueba) {
+	t.Helper()
+	fuente := &fuentePrueba{}
+	mux := http.NewServeMux()
+	NewHandler(dashboard.NewService(fuente, time.Minute), auth.NewValidator(secretoPrueba)).Register(mux)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, fuente
+}
+
+// tokenPrueba access token vigente del usuario sub con el rol indicado
+func tokenPrueba(t *testing.T, sub, role string) string {
+	t.Helper()
+	segmento := func(v interface{}) string {
+		b, err := json.Marshal(v)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b)
+	}
+	ahora := time.Now()
+	unsigned := segmento(map[string]interface{}{"alg": "HS256", "typ": "JWT"}) + "." + segmento(map[string]interface{}{
+		"sub":  sub,
+		"role": role,
+		"jti":  "jti-" + sub,
+		"type": "access",
+		"iat":  ahora.Add(-time.Minute).Unix(),
+		"exp":  ahora.Add(15 * time.Minute).Unix(),
+	})
+	mac := hmac.New(sha256.New, []byte(secretoPrueba))
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// pedir hace la petición con el token indicado ("" = sin Authorization)
+func pedir(t *testing.T, method, url, token string, header http.Header) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, url, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// decodificar cuerpo JSON de la respuesta
+func decodificar(t *testing.T, resp *http.Response, v interface{}) {
+	t.Helper()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+}
+
+func TestRequireRoles(t *testing.T) {
+	server, _ := nuevoServidorPrueba(t)
+
+	casos := []struct {
+		nombre string
+		method string
+		ruta   string
+		token  string
+		status int
+		error  string
+	}{
+		{"sin token", http.MethodGet, "/dashboard", "", http.StatusUnauthorized, "unauthorized"},
+		{"token inválido", http.MethodGet, "/dashboard", "a.b.c", http.StatusUnauthorized, "unauthorized"},
+		{"usuario en lectura abierta", http.MethodGet, "/dashboard", tokenPrueba(t, "u1", auth.RoleUser), http.StatusOK, ""},
+		{"usuario en ingresos", http.MethodGet, "/ingresos", tokenPrueba(t, "u1", auth.RoleUser), http.StatusForbidden, "forbidden"},
+		{"usuario en búsqueda de tickets", http.MethodGet, "/tickets", tokenPrueba(t, "u1", auth.RoleUser), http.StatusForbidden, "forbidden"},
+		{"rol desconocido", http.MethodGet, "/dashboard", tokenPrueba(t, "u1", "guest"), http.StatusUnauthorized, "unauthorized"},
+		{"operador en ingresos", http.MethodGet, "/ingresos", tokenPrueba(t, "op", auth.RoleOperator), http.StatusOK, ""},
+		{"método no permitido", http.MethodPost, "/dashboard", tokenPrueba(t, "op", auth.RoleOperator), http.StatusMethodNotAllowed, "method_not_allowed"},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			resp := pedir(t, caso.method, server.URL+Prefix+caso.ruta, caso.token, nil)
+			if resp.StatusCode != caso.status {
+				t.Fatalf("status = %d, se esperaba %d", resp.StatusCode, caso.status)
+			}
+			if caso.error == "" {
+				return
+			}
+			var body map[string]string
+			decodificar(t, resp, &body)
+			if body["error"] != caso.error {
+				t.Errorf("error = %q, se esperaba %q", body["error"], caso.error)
+			}
+		})
+	}
+}
+
+func TestDashboardPorRol(t *testing.T) {
+	server, _ := nuevoServidorPrueba(t)
+	financieros := []string{"dinero_recaudado_hoy", "dinero_recaudado_mes", "monto_multas_pendientes"}
+
+	var personal map[string]interface{}
+	decodificar(t, pedir(t, http.MethodGet, server.URL+Prefix+"/dashboard", tokenPrueba(t, "op", auth.RoleOperator), nil), &personal)
+	for _, campo := range financieros {
+		if _, ok := personal[campo]; !ok {
+			t.Errorf("operador sin %s", campo)
+		}
+	}
+
+	resp := pedir(t, http.MethodGet, server.URL+Prefix+"/dashboard", tokenPrueba(t, "u1", auth.RoleUser), nil)
+	var usuario map[string]interface{}
+	decodificar(t, resp, &usuario)
+	for _, campo := range financieros {
+		if _, ok := usuario[campo]; ok {
+			t.Errorf("usuario recibió %s", campo)
+		}
+	}
+	if usuario["espacios_disponibles"] != float64(3) {
+		t.Errorf("espacios_disponibles = %v, se esperaba 3", usuario["espacios_disponibles"])
+	}
+
+	// Cada rol tiene su propio ETag: el de un usuario no revela los ingresos
+	etagUsuario := resp.Header.Get("ETag")
+	etagPersonal := pedir(t, http.MethodGet, server.URL+Prefix+"/dashboard", tokenPrueba(t, "op", auth.RoleOperator), nil).Header.Get("ETag")
+	if etagUsuario == "" || etagUsuario == etagPersonal {
+		t.Errorf("ETag usuario = %q, personal = %q: se esperaban distintos", etagUsuario, etagPersonal)
+	}
+}
+
+func TestDashboardNoModificado(t *testing.T) {
+	server, _ := nuevoServidorPrueba(t)
+	token := tokenPrueba(t, "u1", auth.RoleUser)
+
+	primera := pedir(t, http.MethodGet, server.URL+Prefix+"/dashboard", token, nil)
+	etag := primera.Header.Get("ETag")
+	if primera.StatusCode != http.StatusOK || etag == "" {
+		t.Fatalf("primera respuesta: status = %d, ETag = %q", primera.StatusCode, etag)
+	}
+
+	// El snapshot se recarga con otro timestamp pero los mismos datos: mismo ETag
+	segunda := pedir(t, http.MethodGet, server.URL+Prefix+"/dashboard", token, http.Header{"If-None-Match": {etag}})
+	if segunda.StatusCode != http.StatusNotModified {
+		t.Errorf("status = %d, se esperaba 304", segunda.StatusCode)
+	}
+
+	otro := pedir(t, http.MethodGet, server.URL+Prefix+"/dashboard", token, http.Header{"If-None-Match": {`W/"otro"`}})
+	if otro.StatusCode != http.StatusOK {
+		t.Errorf("con ETag distinto: status = %d, se esperaba 200", otro.StatusCode)
+	}
+}
+
+func TestSeccionesPorRol(t *testing.T) {
+	server, _ := nuevoServidorPrueba(t)
+	primerEspacio := func(token string) map[string]interface{} {
+		var secciones []struct {
+			Espacios []map[string]interface{} `json:"espacios"`
+		}
+		decodificar(t, pedir(t, http.MethodGet, server.URL+Prefix+"/secciones", token, nil), &secciones)
+		if len(secciones) != 1 || len(secciones[0].Espacios) != 1 {
+			t.Fatalf("secciones = %+v, se esperaba una sección con un espacio", secciones)
+		}
+		return secciones[0].Espacios[0]
+	}
+
+	personal := primerEspacio(tokenPrueba(t, "op", auth.RoleOperator))
+	if personal["vehiculo_placa"] != "ABC123" {
+		t.Errorf("operador: vehiculo_placa = %v, se esperaba ABC123", personal["vehiculo_placa"])
+	}
+	if _, ok := personal["hora_ingreso"]; !ok {
+		t.Error("operador sin hora_ingreso")
+	}
+
+	usuario := primerEspacio(tokenPrueba(t, "u1", auth.RoleUser))
+	for _, campo := range []string{"vehiculo_placa", "hora_ingreso", "minutos_transcurridos", "monto_acumulado"} {
+		if _, ok := usuario[campo]; ok {
+			t.Errorf("usuario recibió %s", campo)
+		}
+	}
+}
+
+func TestTicketsActivosDelUsuario(t *testing.T) {
+	server, fuente := nuevoServidorPrueba(t)
+
+	resp := pedir(t, http.MethodGet, server.URL+Prefix+"/tickets/activos", tokenPrueba(t, "u1", auth.RoleUser), nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, se esperaba 200", resp.StatusCode)
+	}
+	if fuente.authUser != "u1" {
+		t.Errorf("tickets consultados para %q, se esperaba u1", fuente.authUser)
+	}
+}
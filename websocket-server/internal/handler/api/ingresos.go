@@ -0,0 +1,58 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Ingresos GET /api/v1/ingresos?desde=...&hasta=...
+// Desglose de la recaudación por método, tarifa, sección y hora del día. Las fechas
+// aceptan RFC 3339 o YYYY-MM-DD; una fecha sin hora en hasta incluye ese día completo.
+// Sin parámetros se desglosa el mes en curso.
+func (h *Handler) Ingresos(w http.ResponseWriter, r *http.Request) {
+	ahora := time.Now()
+	desde := time.Date(ahora.Year(), ahora.Month(), 1, 0, 0, 0, 0, ahora.Location())
+	hasta := ahora
+
+	query := r.URL.Query()
+	if value := query.Get("desde"); value != "" {
+		fecha, _, err := parseFecha(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+			return
+		}
+		desde = fecha
+	}
+	if value := query.Get("hasta"); value != "" {
+		fecha, soloDia, err := parseFecha(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+			return
+		}
+		if soloDia {
+			fecha = fecha.AddDate(0, 0, 1)
+		}
+		hasta = fecha
+	}
+
+	desglose, err := h.Service.GetDesgloseIngresos(r.Context(), desde, hasta)
+	if err != nil {
+		writeQueryError(w, err, "Error al obtener desglose de ingresos")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, desglose)
+}
+
+// parseFecha interpreta una fecha RFC 3339 o YYYY-MM-DD (medianoche local). soloDia
+// indica que se recibió solo la fecha.
+func parseFecha(value string) (fecha time.Time, soloDia bool, err error) {
+	if fecha, err = time.Parse(time.RFC3339, value); err == nil {
+		return fecha, false, nil
+	}
+	if fecha, err = time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return fecha, true, nil
+	}
+	return time.Time{}, false, fmt.Errorf("fecha inválida %q: se espera RFC 3339 o YYYY-MM-DD", value)
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/policy"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/service/dashboard"
+)
+
+// Lecturas equivalentes a los mensajes get_* del WebSocket, con el mismo filtrado por
+// rol (paquete policy): un usuario final no recibe ingresos ni datos de vehículos de
+// terceros.
+
+// Dashboard GET /api/v1/dashboard
+// Resumen de ocupación, recaudación y multas pendientes (equivale a get_dashboard).
+func (h *Handler) Dashboard(w http.ResponseWriter, r *http.Request) {
+	snapshot, err := h.Service.GetDashboardData(r.Context())
+	if err != nil {
+		writeQueryError(w, err, "Error al obtener datos del dashboard")
+		return
+	}
+
+	// Copia: el snapshot es compartido
+	data := *snapshot.Data
+	data.Stale = snapshot.Stale
+
+	// El timestamp se renueva en cada recarga del snapshot aunque los datos no cambien
+	huella := data
+	huella.Timestamp = time.Time{}
+
+	claims := claimsFrom(r)
+	writeSnapshot(w, r, policy.RedactDashboard(claims, &data), policy.RedactDashboard(claims, &huella), snapshot.FetchedAt, snapshot.Stale)
+}
+
+// Secciones GET /api/v1/secciones
+// Espacios agrupados por sección con el tiempo y el cobro acumulado de los ocupados
+// (equivale a get_espacios_por_seccion).
+func (h *Handler) Secciones(w http.ResponseWriter, r *http.Request) {
+	snapshot, err := h.Service.GetEspaciosPorSeccion(r.Context())
+	if err != nil {
+		writeQueryError(w, err, "Error al obtener espacios por sección")
+		return
+	}
+
+	secciones := policy.RedactSecciones(claimsFrom(r), snapshot.Data)
+	writeSnapshot(w, r, secciones, nil, snapshot.FetchedAt, snapshot.Stale)
+}
+
+// EspaciosDisponibles GET /api/v1/espacios/disponibles
+// Espacios libres (equivale a get_espacios_disponibles).
+func (h *Handler) EspaciosDisponibles(w http.ResponseWriter, r *http.Request) {
+	snapshot, err := h.Service.GetEspaciosDisponibles(r.Context())
+	if err != nil {
+		writeQueryError(w, err, "Error al obtener espacios disponibles")
+		return
+	}
+
+	writeSnapshot(w, r, snapshot.Data, nil, snapshot.FetchedAt, snapshot.Stale)
+}
+
+// TicketsActivos GET /api/v1/tickets/activos?seccion=A&placa=ABC&orden=placa&desc=true
+// Tickets activos con su vehículo, espacio, tiempo y cobro acumulado (equivale a
+// get_tickets_activos). Un usuario final solo recibe los de sus vehículos.
+func (h *Handler) TicketsActivos(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filtro := dashboard.FiltroTickets{
+		Seccion: query.Get("seccion"),
+		Placa:   query.Get("placa"),
+		Orden:   query.Get("orden"),
+	}
+	if value := query.Get("desc"); value != "" {
+		desc, err := strconv.ParseBool(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", "desc debe ser true o false")
+			return
+		}
+		filtro.Desc = desc
+	}
+
+	if !policy.IsStaff(claimsFrom(r)) {
+		tickets, err := h.Service.GetTicketsActivosByAuthUser(r.Context(), claimsFrom(r).Sub, filtro)
+		if err != nil {
+			writeQueryError(w, err, "Error al obtener tickets activos")
+			return
+		}
+		writeSnapshot(w, r, tickets, nil, time.Now(), false)
+		return
+	}
+
+	snapshot, err := h.Service.GetTicketsActivos(r.Context(), filtro)
+	if err != nil {
+		writeQueryError(w, err, "Error al obtener tickets activos")
+		return
+	}
+	writeSnapshot(w, r, snapshot.Data, nil, snapshot.FetchedAt, snapshot.Stale)
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+// BuscarTickets GET /api/v1/tickets?desde=...&hasta=...&campo=salida&seccion=A&placa=ABC
+// &pagado=false&min_minutos=...&max_minutos=...&limite=50&cursor=...
+// Búsqueda histórica de tickets abiertos y cerrados, del más reciente al más antiguo.
+// Las fechas aceptan RFC 3339 o YYYY-MM-DD (hasta sin hora incluye ese día); campo
+// indica si el rango se aplica al ingreso (por defecto) o a la salida. Para la página
+// siguiente se repiten los filtros con cursor=siguiente_cursor.
+func (h *Handler) BuscarTickets(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filtro := models.FiltroBusquedaTickets{
+		Campo:   query.Get("campo"),
+		Seccion: query.Get("seccion"),
+		Placa:   query.Get("placa"),
+	}
+
+	if value := query.Get("desde"); value != "" {
+		fecha, _, err := parseFecha(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+			return
+		}
+		filtro.Desde = &fecha
+	}
+	if value := query.Get("hasta"); value != "" {
+		fecha, soloDia, err := parseFecha(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+			return
+		}
+		if soloDia {
+			fecha = fecha.AddDate(0, 0, 1)
+		}
+		filtro.Hasta = &fecha
+	}
+	if value := query.Get("pagado"); value != "" {
+		pagado, err := strconv.ParseBool(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_request", "pagado debe ser true o false")
+			return
+		}
+		filtro.Pagado = &pagado
+	}
+
+	var err error
+	if filtro.MinMinutos, err = queryInt(query, "min_minutos"); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if filtro.MaxMinutos, err = queryInt(query, "max_minutos"); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	limite, err := queryInt(query, "limite")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+	if limite == nil {
+		limite = new(int)
+	}
+
+	pagina, err := h.Service.BuscarTickets(r.Context(), filtro, query.Get("cursor"), *limite)
+	if err != nil {
+		writeQueryError(w, err, "Error al buscar tickets")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, pagina)
+}
+
+// queryInt lee un parámetro entero opcional (nil si no se envió)
+func queryInt(query url.Values, key string) (*int, error) {
+	value := query.Get(key)
+	if value == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s debe ser un número entero", key)
+	}
+	return &n, nil
+}
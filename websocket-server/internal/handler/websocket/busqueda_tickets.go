@@ -0,0 +1,35 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+// BuscarTicketsRequest datos del mensaje "buscar_tickets": filtros opcionales y, para
+// las páginas siguientes, el siguiente_cursor de la respuesta anterior
+type BuscarTicketsRequest struct {
+	models.FiltroBusquedaTickets
+	Cursor string `json:"cursor,omitempty"`
+	Limite int    `json:"limite,omitempty"`
+}
+
+// sendBuscarTickets envía una página de la búsqueda histórica de tickets
+func (c *Client) sendBuscarTickets(req Message) {
+	var payload BuscarTicketsRequest
+	if len(req.Data) > 0 {
+		if err := json.Unmarshal(req.Data, &payload); err != nil {
+			c.sendError(req, ErrCodeInvalidPayload, "Filtros inválidos: desde / hasta deben ser fechas RFC 3339 y las duraciones números")
+			return
+		}
+	}
+
+	pagina, err := c.Service.BuscarTickets(context.Background(), payload.FiltroBusquedaTickets, payload.Cursor, payload.Limite)
+	if err != nil {
+		c.sendQueryError(req, err, "Error al buscar tickets")
+		return
+	}
+
+	c.reply(req, "tickets_resultado", pagina)
+}
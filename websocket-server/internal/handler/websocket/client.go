@@ -4,11 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"log"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/auth"
 	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/metrics"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/policy"
 	"github.com/josedavid1945/estacionamiento-websocket/internal/service/dashboard"
 )
 
@@ -19,24 +23,46 @@ type Client struct {
 	Send       chan []byte
 	Hub        *Hub
 	Service    *dashboard.Service
+	Claims     *auth.Claims // nil si la autenticación está deshabilitada
 	closeMutex sync.Mutex
 	closed     bool
+
+	// Tópicos a los que el cliente está suscrito
+	subs subscriptions
 }
 
-// Message estructura de mensaje WebSocket
+// Message estructura de mensaje WebSocket. ID es opcional: si la solicitud lo
+// incluye, la respuesta (o el error) lo repite para correlacionarlas.
 type Message struct {
-	Type string          `json:"type"`
-	Data json.RawMessage `json:"data,omitempty"`
+	Type      string          `json:"type"`
+	ID        string          `json:"id,omitempty"`
+	Seq       uint64          `json:"seq,omitempty"`       // número de secuencia de los eventos difundidos
+	Freshness *Freshness      `json:"freshness,omitempty"` // antigüedad de las respuestas servidas desde un snapshot
+	Data      json.RawMessage `json:"data,omitempty"`
+}
+
+// Freshness indica cuándo se obtuvieron de la fuente los datos de una respuesta.
+// Stale es true si la fuente no respondió y se envía el último resultado válido.
+type Freshness struct {
+	AsOf  time.Time `json:"as_of"`
+	AgeMs int64     `json:"age_ms"`
+	Stale bool      `json:"stale"`
+}
+
+// newFreshness calcula la antigüedad de datos obtenidos en asOf
+func newFreshness(asOf time.Time, stale bool) *Freshness {
+	return &Freshness{AsOf: asOf, AgeMs: time.Since(asOf).Milliseconds(), Stale: stale}
 }
 
 // NewClient crea un nuevo cliente WebSocket
-func NewClient(conn *websocket.Conn, hub *Hub, service *dashboard.Service) *Client {
+func NewClient(conn *websocket.Conn, hub *Hub, service *dashboard.Service, claims *auth.Claims) *Client {
 	return &Client{
 		ID:      generateClientID(),
 		Conn:    conn,
 		Send:    make(chan []byte, 256),
 		Hub:     hub,
 		Service: service,
+		Claims:  claims,
 		closed:  false,
 	}
 }
@@ -67,6 +93,7 @@ func (c *Client) ReadPump() {
 		var msg Message
 		if err := json.Unmarshal(messageBytes, &msg); err != nil {
 			log.Printf("Error al parsear mensaje: %v", err)
+			c.sendError(msg, ErrCodeInvalidPayload, "Mensaje JSON inválido")
 			continue
 		}
 
@@ -119,77 +146,213 @@ func (c *Client) WritePump() {
 
 // handleMessage procesa los mensajes recibidos del cliente
 func (c *Client) handleMessage(msg Message) {
+	metrics.MessagesReceived.Inc(messageTypeLabel(msg.Type))
+
+	// messagePolicy lista todos los tipos del switch: es el único lugar que rechaza los desconocidos
+	if _, ok := messagePolicy[msg.Type]; !ok {
+		log.Printf("Tipo de mensaje desconocido: %s", msg.Type)
+		c.sendError(msg, ErrCodeUnknownType, "Tipo de mensaje desconocido: "+msg.Type)
+		return
+	}
+	if !c.authorize(msg.Type) {
+		log.Printf("🔒 Cliente %s (rol %s) no autorizado para %s", c.ID, c.Claims.Role, msg.Type)
+		c.sendError(msg, ErrCodeForbidden, "No autorizado para "+msg.Type)
+		return
+	}
+
 	switch msg.Type {
 	case "get_dashboard":
-		c.sendDashboardUpdate()
+		c.sendDashboardUpdate(msg)
+	case "dashboard_resync":
+		c.sendDashboardSnapshot(msg)
 	case "get_espacios_por_seccion":
-		c.sendEspaciosPorSeccion()
+		c.sendEspaciosPorSeccion(msg)
 	case "get_espacios_disponibles":
-		c.sendEspaciosDisponibles()
+		c.sendEspaciosDisponibles(msg)
 	case "get_tickets_activos":
-		c.sendTicketsActivos()
-	default:
-		log.Printf("Tipo de mensaje desconocido: %s", msg.Type)
+		c.sendTicketsActivos(msg)
+	case "get_occupancy_history":
+		c.sendOccupancyHistory(msg)
+	case "get_revenue_breakdown":
+		c.sendRevenueBreakdown(msg)
+	case "get_forecast":
+		c.sendForecast(msg)
+	case "buscar_placa":
+		c.sendBuscarPlaca(msg)
+	case "buscar_tickets":
+		c.sendBuscarTickets(msg)
+	case "subscribe":
+		c.handleSubscription(msg, true)
+	case "unsubscribe":
+		c.handleSubscription(msg, false)
+	case "resume":
+		c.handleResume(msg)
 	}
 }
 
-// sendDashboardUpdate envía actualización completa del dashboard
-func (c *Client) sendDashboardUpdate() {
+// sendDashboardUpdate consulta el dashboard y envía el snapshot completo con su versión.
+// Si los datos cambiaron, el Hub además notifica el delta al resto de clientes.
+func (c *Client) sendDashboardUpdate(req Message) {
 	ctx := context.Background()
-	data, err := c.Service.GetDashboardData(ctx)
+	data, broadcast, err := c.Hub.RefreshDashboard(ctx)
 	if err != nil {
 		log.Printf("Error obteniendo datos del dashboard: %v", err)
-		c.sendError("Error al obtener datos del dashboard")
+		c.sendError(req, ErrCodeUpstreamUnavailable, "Error al obtener datos del dashboard")
+		return
+	}
+
+	c.replyDashboard(req, data, broadcast)
+}
+
+// sendDashboardSnapshot envía el último snapshot completo conocido por el Hub,
+// usado al conectar y cuando el cliente pide resincronizar tras perder una versión
+func (c *Client) sendDashboardSnapshot(req Message) {
+	ctx := context.Background()
+	data, broadcast, err := c.Hub.DashboardSnapshot(ctx)
+	if err != nil {
+		log.Printf("Error obteniendo snapshot del dashboard: %v", err)
+		c.sendError(req, ErrCodeUpstreamUnavailable, "Error al obtener datos del dashboard")
 		return
 	}
 
-	c.sendMessage("dashboard_update", data)
+	c.replyDashboard(req, data, broadcast)
+}
+
+// replyDashboard responde el snapshot del dashboard, salvo que el cliente ya lo haya
+// recibido en el dashboard_update difundido por el primer snapshot
+func (c *Client) replyDashboard(req Message, data *models.DashboardData, broadcast bool) {
+	if broadcast && c.IsSubscribed(TopicDashboard) {
+		return
+	}
+	c.replySnapshot(req, "dashboard_update", policy.RedactDashboard(c.Claims, data), data.Timestamp, data.Stale)
 }
 
 // sendEspaciosPorSeccion envía espacios agrupados por sección
-func (c *Client) sendEspaciosPorSeccion() {
+func (c *Client) sendEspaciosPorSeccion(req Message) {
 	ctx := context.Background()
 	secciones, err := c.Service.GetEspaciosPorSeccion(ctx)
 	if err != nil {
 		log.Printf("Error obteniendo espacios por sección: %v", err)
-		c.sendError("Error al obtener espacios por sección")
+		c.sendError(req, ErrCodeUpstreamUnavailable, "Error al obtener espacios por sección")
 		return
 	}
 
-	c.sendMessage("espacios_por_seccion", secciones)
+	c.replySnapshot(req, "espacios_por_seccion", policy.RedactSecciones(c.Claims, secciones.Data), secciones.FetchedAt, secciones.Stale)
 }
 
 // sendEspaciosDisponibles envía lista de espacios disponibles
-func (c *Client) sendEspaciosDisponibles() {
+func (c *Client) sendEspaciosDisponibles(req Message) {
 	ctx := context.Background()
 	espacios, err := c.Service.GetEspaciosDisponibles(ctx)
 	if err != nil {
 		log.Printf("Error obteniendo espacios disponibles: %v", err)
-		c.sendError("Error al obtener espacios disponibles")
+		c.sendError(req, ErrCodeUpstreamUnavailable, "Error al obtener espacios disponibles")
 		return
 	}
 
-	c.sendMessage("espacios_disponibles", espacios)
+	c.replySnapshot(req, "espacios_disponibles", espacios.Data, espacios.FetchedAt, espacios.Stale)
 }
 
-// sendTicketsActivos envía tickets activos
-func (c *Client) sendTicketsActivos() {
-	ctx := context.Background()
-	tickets, err := c.Service.GetTicketsActivos(ctx)
-	if err != nil {
-		log.Printf("Error obteniendo tickets activos: %v", err)
-		c.sendError("Error al obtener tickets activos")
+// handleSubscription procesa subscribe / unsubscribe y responde con los tópicos vigentes
+func (c *Client) handleSubscription(req Message, subscribe bool) {
+	var payload SubscriptionRequest
+	if err := json.Unmarshal(req.Data, &payload); err != nil || len(payload.Topics) == 0 {
+		c.sendError(req, ErrCodeInvalidPayload, "Se requiere una lista de tópicos")
 		return
 	}
 
-	c.sendMessage("tickets_activos", tickets)
+	topics := make([]string, 0, len(payload.Topics))
+	for _, raw := range payload.Topics {
+		topic, ok := normalizeTopic(raw)
+		if !ok {
+			c.sendError(req, ErrCodeInvalidPayload, "Tópico desconocido: "+raw)
+			return
+		}
+		if subscribe && !c.authorizeTopic(topic) {
+			c.sendError(req, ErrCodeForbidden, "No autorizado para el tópico "+topic)
+			return
+		}
+		topics = append(topics, topic)
+	}
+
+	if subscribe {
+		c.subs.add(topics)
+	} else {
+		c.subs.remove(topics)
+	}
+
+	current := c.subs.list()
+	sort.Strings(current)
+	c.reply(req, "subscriptions", SubscriptionRequest{Topics: current})
 }
 
-// sendMessage envía un mensaje al cliente
+// handleResume reenvía los eventos perdidos desde la última secuencia vista
+func (c *Client) handleResume(req Message) {
+	var payload ResumeRequest
+	if err := json.Unmarshal(req.Data, &payload); err != nil {
+		c.sendError(req, ErrCodeInvalidPayload, "Se requiere stream_id y last_seq")
+		return
+	}
+
+	result := c.Hub.Resume(c, payload)
+	if result.ResyncRequired {
+		log.Printf("Cliente %s no puede reanudar desde seq %d, requiere resincronizar", c.ID, payload.LastSeq)
+	}
+	c.reply(req, "resume_result", result)
+}
+
+// IsSubscribed indica si el cliente debe recibir eventos de alguno de los tópicos.
+// Solo cuentan los tópicos que su rol puede recibir, también para los clientes sin
+// suscripciones explícitas: un evento solo de tópicos del personal no les llega.
+func (c *Client) IsSubscribed(topics ...string) bool {
+	authorized := c.authorizedTopics(topics)
+	if len(authorized) == 0 {
+		return false
+	}
+	return c.subs.matches(authorized)
+}
+
+// sendMessage envía un mensaje al cliente sin ID de correlación
 func (c *Client) sendMessage(messageType string, data interface{}) {
-	msg := Message{
-		Type: messageType,
+	c.sendEnvelope(Message{Type: messageType}, data)
+}
+
+// reply responde a una solicitud repitiendo su ID
+func (c *Client) reply(req Message, messageType string, data interface{}) {
+	c.sendEnvelope(Message{Type: messageType, ID: req.ID}, data)
+}
+
+// replySnapshot responde con datos servidos desde un snapshot indicando su antigüedad
+func (c *Client) replySnapshot(req Message, messageType string, data interface{}, asOf time.Time, stale bool) {
+	c.sendEnvelope(Message{Type: messageType, ID: req.ID, Freshness: newFreshness(asOf, stale)}, data)
+}
+
+// deliver envía un evento difundido por el Hub con su número de secuencia,
+// aplicando el filtrado por rol según el tipo de payload. Las alertas y los payloads
+// sin un caso explícito solo se envían al personal.
+func (c *Client) deliver(ev hubEvent) {
+	var data interface{}
+	switch payload := ev.Payload.(type) {
+	case *models.DashboardData:
+		data = policy.RedactDashboard(c.Claims, payload)
+	case *models.DashboardDelta:
+		data = policy.RedactDashboardDelta(c.Claims, payload)
+	case *models.EspacioOcupadoEvent, *models.EspacioLiberadoEvent:
+		data = policy.RedactEvento(c.Claims, payload)
+	case *models.MultaEvent:
+		data = policy.RedactMulta(c.Claims, payload)
+	default:
+		// Alertas de permanencia y capacidad y cualquier tipo nuevo: solo personal
+		if !c.isStaff() {
+			return
+		}
+		data = payload
 	}
+	c.sendEnvelope(Message{Type: ev.Type, Seq: ev.Seq}, data)
+}
+
+// sendEnvelope serializa y encola un mensaje para el cliente
+func (c *Client) sendEnvelope(msg Message, data interface{}) {
 
 	if data != nil {
 		dataBytes, err := json.Marshal(data)
@@ -206,16 +369,51 @@ func (c *Client) sendMessage(messageType string, data interface{}) {
 		return
 	}
 
+	// Close cierra Send bajo closeMutex: el envío no bloquea, se hace con el lock tomado
+	c.closeMutex.Lock()
+	defer c.closeMutex.Unlock()
+	if c.closed {
+		return
+	}
+
 	select {
 	case c.Send <- messageBytes:
+		metrics.MessagesSent.Inc(msg.Type)
 	default:
+		metrics.SendDropped.Inc(msg.Type)
 		log.Printf("Canal de envío lleno para cliente %s", c.ID)
 	}
 }
 
-// sendError envía un mensaje de error al cliente
-func (c *Client) sendError(errorMsg string) {
-	c.sendMessage("error", map[string]string{"message": errorMsg})
+// sendError envía un error tipado como respuesta a la solicitud
+func (c *Client) sendError(req Message, code ErrorCode, errorMsg string) {
+	c.reply(req, "error", ErrorPayload{
+		Code:        code,
+		Message:     errorMsg,
+		RequestType: req.Type,
+		Retryable:   retryableCodes[code],
+	})
+}
+
+// sendQueryError responde el error de una consulta al servicio. Los errores de
+// validación o de consulta no soportada llevan su detalle; el resto, message.
+func (c *Client) sendQueryError(req Message, err error, message string) {
+	code := errorCode(err)
+	if code != ErrCodeUpstreamUnavailable {
+		c.sendError(req, code, err.Error())
+		return
+	}
+	log.Printf("%s: %v", message, err)
+	c.sendError(req, code, message)
+}
+
+// messageTypeLabel etiqueta de métricas del tipo recibido. Los tipos fuera de la
+// política se agrupan en "unknown" para no crear una serie por cada valor arbitrario.
+func messageTypeLabel(messageType string) string {
+	if _, ok := messagePolicy[messageType]; ok {
+		return messageType
+	}
+	return "unknown"
 }
 
 // Close cierra la conexión del cliente de forma segura
@@ -236,18 +434,3 @@ func (c *Client) Close() {
 func generateClientID() string {
 	return time.Now().Format("20060102150405") + "-" + string(rune(time.Now().UnixNano()%26+65))
 }
-
-// BroadcastDashboardUpdate envía actualización del dashboard a todos los clientes
-func (c *Client) BroadcastDashboardUpdate(data *models.DashboardData) {
-	c.sendMessage("dashboard_update", data)
-}
-
-// BroadcastEspacioOcupado notifica que un espacio fue ocupado
-func (c *Client) BroadcastEspacioOcupado(event *models.EspacioOcupadoEvent) {
-	c.sendMessage("espacio_ocupado", event)
-}
-
-// BroadcastEspacioLiberado notifica que un espacio fue liberado
-func (c *Client) BroadcastEspacioLiberado(event *models.EspacioLiberadoEvent) {
-	c.sendMessage("espacio_liberado", event)
-}
@@ -0,0 +1,41 @@
+package websocket
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/auth"
+)
+
+// cerrarSend reproduce Close sin conexión: marca el cliente cerrado y cierra Send
+func cerrarSend(c *Client) {
+	c.closeMutex.Lock()
+	defer c.closeMutex.Unlock()
+	c.closed = true
+	close(c.Send)
+}
+
+func TestSendEnvelopeTrasCerrar(t *testing.T) {
+	c := nuevoClientePrueba(auth.RoleOperator)
+	cerrarSend(c)
+
+	// No debe entrar en pánico por enviar en un canal cerrado
+	c.sendMessage("session", SessionInfo{ClientID: c.ID})
+}
+
+func TestSendEnvelopeConcurrenteConCierre(t *testing.T) {
+	c := nuevoClientePrueba(auth.RoleOperator)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.sendMessage("session", SessionInfo{ClientID: c.ID})
+			}
+		}()
+	}
+	cerrarSend(c)
+	wg.Wait()
+}
@@ -0,0 +1,105 @@
+package websocket
+
+import (
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+// camposSinDelta no se consideran al comparar snapshots del dashboard
+var camposSinDelta = map[string]bool{
+	"timestamp": true,
+	"version":   true,
+}
+
+// dashboardState guarda el último snapshot enviado y su número de versión
+type dashboardState struct {
+	mu      sync.RWMutex
+	last    *models.DashboardData
+	fields  map[string]interface{}
+	version uint64
+}
+
+// current devuelve una copia del último snapshot con su versión, o nil si aún no hay
+func (s *dashboardState) current() *models.DashboardData {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.last == nil {
+		return nil
+	}
+	snapshot := *s.last
+	return &snapshot
+}
+
+// update registra un nuevo snapshot. Si cambió algún campo incrementa la versión y
+// devuelve el delta; si es idéntico al anterior devuelve nil. El primer snapshot
+// genera versión 1 sin delta (first = true) porque no hay base contra la cual comparar.
+func (s *dashboardState) update(data *models.DashboardData) (delta *models.DashboardDelta, first bool, err error) {
+	fields, err := dashboardFields(data)
+	if err != nil {
+		return nil, false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.last == nil {
+		s.version = 1
+		s.store(data, fields)
+		return nil, true, nil
+	}
+
+	changes := make(map[string]interface{})
+	for key, value := range fields {
+		if camposSinDelta[key] {
+			continue
+		}
+		if previous, ok := s.fields[key]; !ok || !reflect.DeepEqual(previous, value) {
+			changes[key] = value
+		}
+	}
+
+	if len(changes) == 0 {
+		// Mismos datos, pero confirmados en esta consulta: actualizar su antigüedad
+		refreshed := *s.last
+		refreshed.Timestamp = data.Timestamp
+		s.last = &refreshed
+		return nil, false, nil
+	}
+
+	base := s.version
+	s.version++
+	s.store(data, fields)
+
+	return &models.DashboardDelta{
+		Version:     s.version,
+		BaseVersion: base,
+		Changes:     changes,
+		Timestamp:   data.Timestamp,
+	}, false, nil
+}
+
+// store guarda el snapshot con la versión actual (requiere el lock tomado)
+func (s *dashboardState) store(data *models.DashboardData, fields map[string]interface{}) {
+	snapshot := *data
+	snapshot.Version = s.version
+	s.last = &snapshot
+	s.fields = fields
+}
+
+// dashboardFields convierte el dashboard a un mapa campo -> valor usando sus tags JSON
+func dashboardFields(data *models.DashboardData) (map[string]interface{}, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
@@ -0,0 +1,168 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+// dashboardPrueba snapshot base con el timestamp indicado
+func dashboardPrueba(ts time.Time) *models.DashboardData {
+	return &models.DashboardData{
+		EspaciosDisponibles: 3,
+		EspaciosOcupados:    1,
+		TotalEspacios:       4,
+		DineroRecaudadoHoy:  12.5,
+		VehiculosActivos:    1,
+		Timestamp:           ts,
+	}
+}
+
+func TestDashboardStatePrimerSnapshot(t *testing.T) {
+	var s dashboardState
+	if s.current() != nil {
+		t.Fatal("current() sin snapshots, se esperaba nil")
+	}
+
+	delta, first, err := s.update(dashboardPrueba(time.Unix(100, 0)))
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if !first || delta != nil {
+		t.Errorf("primer snapshot: first = %v, delta = %v, se esperaba first sin delta", first, delta)
+	}
+	if got := s.current(); got == nil || got.Version != 1 {
+		t.Errorf("current() = %+v, se esperaba la versión 1", got)
+	}
+}
+
+func TestDashboardStateSinCambiosNoGeneraDelta(t *testing.T) {
+	var s dashboardState
+	if _, _, err := s.update(dashboardPrueba(time.Unix(100, 0))); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	// Solo cambian timestamp y version, que no cuentan como cambio
+	igual := dashboardPrueba(time.Unix(200, 0))
+	igual.Version = 7
+	delta, first, err := s.update(igual)
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if delta != nil || first {
+		t.Errorf("snapshot idéntico: delta = %+v, first = %v, se esperaba nada que difundir", delta, first)
+	}
+
+	got := s.current()
+	if got.Version != 1 {
+		t.Errorf("versión = %d, se esperaba 1 sin cambios", got.Version)
+	}
+	if !got.Timestamp.Equal(time.Unix(200, 0)) {
+		t.Errorf("timestamp = %v, se esperaba el de la última consulta", got.Timestamp)
+	}
+}
+
+func TestDashboardStateDeltaSoloCamposCambiados(t *testing.T) {
+	var s dashboardState
+	if _, _, err := s.update(dashboardPrueba(time.Unix(100, 0))); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	for i, caso := range []struct {
+		modificar func(d *models.DashboardData)
+		campos    []string
+	}{
+		{func(d *models.DashboardData) { d.EspaciosDisponibles, d.EspaciosOcupados = 2, 2 },
+			[]string{"espacios_disponibles", "espacios_ocupados"}},
+		{func(d *models.DashboardData) { d.EspaciosDisponibles, d.EspaciosOcupados, d.DineroRecaudadoHoy = 2, 2, 20 },
+			[]string{"dinero_recaudado_hoy"}},
+	} {
+		data := dashboardPrueba(time.Unix(int64(200+i), 0))
+		caso.modificar(data)
+
+		delta, first, err := s.update(data)
+		if err != nil {
+			t.Fatalf("update %d: %v", i, err)
+		}
+		if first || delta == nil {
+			t.Fatalf("cambio %d: delta = %v, first = %v, se esperaba un delta", i, delta, first)
+		}
+
+		version := uint64(i + 2)
+		if delta.Version != version || delta.BaseVersion != version-1 {
+			t.Errorf("cambio %d: versión %d sobre %d, se esperaba %d sobre %d", i, delta.Version, delta.BaseVersion, version, version-1)
+		}
+		if len(delta.Changes) != len(caso.campos) {
+			t.Errorf("cambio %d: campos %v, se esperaba solo %v", i, delta.Changes, caso.campos)
+		}
+		for _, campo := range caso.campos {
+			if _, ok := delta.Changes[campo]; !ok {
+				t.Errorf("cambio %d: falta %q en el delta %v", i, campo, delta.Changes)
+			}
+		}
+		if !delta.Timestamp.Equal(data.Timestamp) {
+			t.Errorf("cambio %d: timestamp = %v, se esperaba %v", i, delta.Timestamp, data.Timestamp)
+		}
+	}
+}
+
+func TestDashboardStateResyncDevuelveSnapshotCompleto(t *testing.T) {
+	var s dashboardState
+	s.update(dashboardPrueba(time.Unix(100, 0)))
+	cambiado := dashboardPrueba(time.Unix(200, 0))
+	cambiado.VehiculosActivos = 3
+	s.update(cambiado)
+
+	// Un cliente que perdió un delta se resincroniza con el snapshot vigente
+	got := s.current()
+	if got.Version != 2 || got.VehiculosActivos != 3 || got.TotalEspacios != 4 {
+		t.Errorf("current() = %+v, se esperaba el snapshot completo en la versión 2", got)
+	}
+
+	// La copia devuelta no altera el estado guardado
+	got.VehiculosActivos = 99
+	if s.current().VehiculosActivos != 3 {
+		t.Error("modificar la copia de current() alteró el estado")
+	}
+}
+
+func TestRefreshDashboardSinCambiosNoDifunde(t *testing.T) {
+	h, c := nuevoHubConDatos(t)
+	c.subs.add([]string{TopicDashboard})
+
+	if _, broadcast, err := h.RefreshDashboard(context.Background()); err != nil || !broadcast {
+		t.Fatalf("primer refresco: broadcast = %v, err = %v", broadcast, err)
+	}
+	if got := mensajes(t, c); len(got) != 1 || got[0] != "dashboard_update" {
+		t.Fatalf("primer refresco: mensajes %v, se esperaba dashboard_update", got)
+	}
+
+	// fuenteDatos devuelve siempre los mismos datos
+	if _, broadcast, err := h.RefreshDashboard(context.Background()); err != nil || broadcast {
+		t.Fatalf("segundo refresco: broadcast = %v, err = %v", broadcast, err)
+	}
+	if got := mensajes(t, c); len(got) != 0 {
+		t.Errorf("segundo refresco: mensajes %v, no se esperaba ninguna difusión", got)
+	}
+
+	c.handleMessage(Message{Type: "dashboard_resync", ID: "r1"})
+	var msg Message
+	select {
+	case raw := <-c.Send:
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			t.Fatalf("mensaje inválido: %v", err)
+		}
+	default:
+		t.Fatal("dashboard_resync sin respuesta")
+	}
+	var data models.DashboardData
+	if err := json.Unmarshal(msg.Data, &data); err != nil {
+		t.Fatalf("datos inválidos: %v", err)
+	}
+	if msg.Type != "dashboard_update" || msg.ID != "r1" || data.Version != 1 {
+		t.Errorf("resync: tipo %q, id %q, versión %d, se esperaba dashboard_update r1 en la versión 1", msg.Type, msg.ID, data.Version)
+	}
+}
@@ -0,0 +1,57 @@
+package websocket
+
+import (
+	"errors"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/repository/interfaces"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/service/dashboard"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/service/ocupacion"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/service/pronostico"
+)
+
+// ErrorCode código estable de error enviado en los mensajes "error"
+type ErrorCode string
+
+const (
+	// ErrCodeUnknownType el tipo de mensaje no existe
+	ErrCodeUnknownType ErrorCode = "UNKNOWN_TYPE"
+
+	// ErrCodeInvalidPayload el mensaje o su campo data no se pudo interpretar
+	ErrCodeInvalidPayload ErrorCode = "INVALID_PAYLOAD"
+
+	// ErrCodeForbidden el rol del cliente no permite la operación
+	ErrCodeForbidden ErrorCode = "FORBIDDEN"
+
+	// ErrCodeUpstreamUnavailable la base de datos o el REST API no respondieron
+	ErrCodeUpstreamUnavailable ErrorCode = "UPSTREAM_UNAVAILABLE"
+
+	// ErrCodeUnsupported la fuente de datos configurada (MODE) no ofrece la consulta
+	ErrCodeUnsupported ErrorCode = "UNSUPPORTED"
+)
+
+// retryableCodes errores transitorios que el cliente puede reintentar
+var retryableCodes = map[ErrorCode]bool{
+	ErrCodeUpstreamUnavailable: true,
+}
+
+// ErrorPayload contenido del mensaje "error"
+type ErrorPayload struct {
+	Code        ErrorCode `json:"code"`
+	Message     string    `json:"message"`
+	RequestType string    `json:"request_type,omitempty"`
+	Retryable   bool      `json:"retryable"`
+}
+
+// errorCode clasifica el error de una consulta: parámetros inválidos, consulta que la
+// fuente no ofrece o fuente no disponible
+func errorCode(err error) ErrorCode {
+	switch {
+	case errors.Is(err, dashboard.ErrRangoInvalido), errors.Is(err, dashboard.ErrFiltroInvalido),
+		errors.Is(err, ocupacion.ErrConsultaInvalida), errors.Is(err, pronostico.ErrHorizonteInvalido):
+		return ErrCodeInvalidPayload
+	case errors.Is(err, interfaces.ErrNotSupported):
+		return ErrCodeUnsupported
+	default:
+		return ErrCodeUpstreamUnavailable
+	}
+}
@@ -0,0 +1,132 @@
+package websocket
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// hubEvent evento difundido por el Hub, conservado para reenviarlo en un resume
+type hubEvent struct {
+	Seq     uint64
+	Type    string
+	Topics  []string
+	Payload interface{}
+}
+
+// ResumeRequest payload del mensaje "resume"
+type ResumeRequest struct {
+	StreamID string `json:"stream_id"`
+	LastSeq  uint64 `json:"last_seq"`
+}
+
+// ResumeResult respuesta al mensaje "resume"
+type ResumeResult struct {
+	StreamID       string `json:"stream_id"`
+	FromSeq        uint64 `json:"from_seq"`
+	ToSeq          uint64 `json:"to_seq"`
+	Replayed       int    `json:"replayed"`
+	ResyncRequired bool   `json:"resync_required"`
+}
+
+// SessionInfo se envía al conectar para que el cliente sepa desde dónde reanudar
+type SessionInfo struct {
+	ClientID string `json:"client_id"`
+	StreamID string `json:"stream_id"`
+	Seq      uint64 `json:"seq"`
+}
+
+// eventLog buffer circular acotado con los últimos eventos difundidos.
+// Los números de secuencia son crecientes dentro de un mismo streamID; al
+// reiniciar el servidor cambia el streamID y los clientes deben resincronizar.
+type eventLog struct {
+	mu       sync.RWMutex
+	streamID string
+	events   []hubEvent
+	start    int
+	count    int
+	lastSeq  uint64
+}
+
+// newEventLog crea un buffer con capacidad para size eventos
+func newEventLog(size int) *eventLog {
+	if size <= 0 {
+		size = 1
+	}
+	return &eventLog{
+		streamID: newStreamID(),
+		events:   make([]hubEvent, size),
+	}
+}
+
+// append asigna el siguiente número de secuencia y guarda el evento
+func (l *eventLog) append(eventType string, topics []string, payload interface{}) hubEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.lastSeq++
+	ev := hubEvent{Seq: l.lastSeq, Type: eventType, Topics: topics, Payload: payload}
+
+	idx := (l.start + l.count) % len(l.events)
+	l.events[idx] = ev
+	if l.count < len(l.events) {
+		l.count++
+	} else {
+		l.start = (l.start + 1) % len(l.events)
+	}
+	return ev
+}
+
+// since devuelve los eventos posteriores a lastSeq. ok = false si el hueco ya no
+// está completo en el buffer o la secuencia no pertenece a este stream.
+func (l *eventLog) since(streamID string, lastSeq uint64) (events []hubEvent, ok bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if streamID != l.streamID || lastSeq > l.lastSeq {
+		return nil, false
+	}
+	if lastSeq == l.lastSeq {
+		return nil, true
+	}
+
+	oldest := l.lastSeq - uint64(l.count) + 1
+	if l.count == 0 || lastSeq+1 < oldest {
+		return nil, false
+	}
+
+	skip := int(lastSeq + 1 - oldest)
+	events = make([]hubEvent, 0, l.count-skip)
+	for i := skip; i < l.count; i++ {
+		events = append(events, l.events[(l.start+i)%len(l.events)])
+	}
+	return events, true
+}
+
+// reset empieza un stream nuevo y descarta el buffer: las secuencias del stream
+// anterior ya no se pueden reanudar
+func (l *eventLog) reset() (string, uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.streamID = newStreamID()
+	l.start, l.count, l.lastSeq = 0, 0, 0
+	return l.streamID, l.lastSeq
+}
+
+// current devuelve el stream y la última secuencia asignada
+func (l *eventLog) current() (string, uint64) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.streamID, l.lastSeq
+}
+
+// newStreamID genera un identificador aleatorio para esta ejecución del Hub
+func newStreamID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return time.Now().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(b)
+}
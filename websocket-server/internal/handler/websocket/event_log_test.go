@@ -0,0 +1,106 @@
+package websocket
+
+import (
+	"reflect"
+	"testing"
+)
+
+// secuencias números de secuencia de los eventos
+func secuencias(events []hubEvent) []uint64 {
+	seqs := []uint64{}
+	for _, ev := range events {
+		seqs = append(seqs, ev.Seq)
+	}
+	return seqs
+}
+
+// logConEventos buffer de capacidad size con n eventos difundidos
+func logConEventos(size, n int) *eventLog {
+	l := newEventLog(size)
+	for i := 0; i < n; i++ {
+		l.append("dashboard_update", nil, i)
+	}
+	return l
+}
+
+func TestEventLogSince(t *testing.T) {
+	casos := []struct {
+		nombre  string
+		size    int
+		eventos int
+		lastSeq uint64
+		want    []uint64
+		ok      bool
+	}{
+		{"sin eventos", 4, 0, 0, nil, true},
+		{"al día", 4, 3, 3, nil, true},
+		{"desde el inicio", 4, 3, 0, []uint64{1, 2, 3}, true},
+		{"hueco parcial", 4, 3, 1, []uint64{2, 3}, true},
+		{"buffer lleno", 4, 4, 0, []uint64{1, 2, 3, 4}, true},
+		{"tras dar la vuelta", 4, 6, 2, []uint64{3, 4, 5, 6}, true},
+		{"tras varias vueltas", 4, 11, 9, []uint64{10, 11}, true},
+		{"hueco más antiguo que el buffer", 4, 6, 1, nil, false},
+		{"desde cero tras dar la vuelta", 4, 6, 0, nil, false},
+		{"secuencia por delante", 4, 3, 4, nil, false},
+		{"por delante sin eventos", 4, 0, 1, nil, false},
+		{"capacidad mínima", 0, 3, 2, []uint64{3}, true},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			l := logConEventos(caso.size, caso.eventos)
+			streamID, _ := l.current()
+
+			events, ok := l.since(streamID, caso.lastSeq)
+			if ok != caso.ok {
+				t.Fatalf("ok = %v, se esperaba %v", ok, caso.ok)
+			}
+			if caso.want == nil {
+				if events != nil {
+					t.Errorf("eventos %v, se esperaba ninguno", secuencias(events))
+				}
+				return
+			}
+			if got := secuencias(events); !reflect.DeepEqual(got, caso.want) {
+				t.Errorf("eventos %v, se esperaba %v", got, caso.want)
+			}
+		})
+	}
+}
+
+func TestEventLogSincePayload(t *testing.T) {
+	l := logConEventos(3, 5)
+	streamID, _ := l.current()
+
+	events, ok := l.since(streamID, 3)
+	if !ok || len(events) != 2 {
+		t.Fatalf("since = %d eventos, %v; se esperaban 2", len(events), ok)
+	}
+	// El payload i se difundió con la secuencia i+1
+	for _, ev := range events {
+		if ev.Payload != int(ev.Seq)-1 {
+			t.Errorf("evento %d con payload %v, se esperaba %d", ev.Seq, ev.Payload, ev.Seq-1)
+		}
+	}
+}
+
+func TestEventLogOtroStream(t *testing.T) {
+	l := logConEventos(4, 3)
+	anterior, _ := l.current()
+
+	if _, ok := l.since("otro-stream", 2); ok {
+		t.Error("since con un streamID ajeno = ok, se esperaba resync")
+	}
+
+	// Tras reset el stream anterior ya no se puede reanudar, aunque la secuencia exista
+	streamID, seq := l.reset()
+	if streamID == anterior || seq != 0 {
+		t.Fatalf("reset = %q, %d; se esperaba un stream nuevo desde 0", streamID, seq)
+	}
+	l.append("dashboard_update", nil, nil)
+	if _, ok := l.since(anterior, 0); ok {
+		t.Error("since con el stream anterior al reset = ok, se esperaba resync")
+	}
+	if events, ok := l.since(streamID, 0); !ok || !reflect.DeepEqual(secuencias(events), []uint64{1}) {
+		t.Errorf("since tras reset = %v, %v; se esperaba [1]", secuencias(events), ok)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/gorilla/websocket"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/auth"
 	"github.com/josedavid1945/estacionamiento-websocket/internal/service/dashboard"
 )
 
@@ -67,25 +68,48 @@ var upgrader = websocket.Upgrader{
 type Handler struct {
 	Hub     *Hub
 	Service *dashboard.Service
+
+	// Validator valida el access token del handshake; nil deshabilita la autenticación
+	Validator *auth.Validator
 }
 
 // NewHandler crea una nueva instancia del handler
-func NewHandler(hub *Hub, service *dashboard.Service) *Handler {
+func NewHandler(hub *Hub, service *dashboard.Service, validator *auth.Validator) *Handler {
 	return &Handler{
-		Hub:     hub,
-		Service: service,
+		Hub:       hub,
+		Service:   service,
+		Validator: validator,
 	}
 }
 
 // ServeWS maneja las solicitudes de upgrade a WebSocket
 func (h *Handler) ServeWS(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	var claims *auth.Claims
+	var responseHeader http.Header
+
+	if h.Validator != nil {
+		token, subprotocol := auth.TokenFromRequest(r)
+		var err error
+		claims, err = h.Validator.ValidateToken(token)
+		if err != nil {
+			log.Printf("🔒 Handshake rechazado desde %s: %v", r.RemoteAddr, err)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"unauthorized","message":"Token de acceso inválido o ausente"}`))
+			return
+		}
+		if subprotocol != "" {
+			responseHeader = http.Header{"Sec-WebSocket-Protocol": []string{subprotocol}}
+		}
+	}
+
+	conn, err := upgrader.Upgrade(w, r, responseHeader)
 	if err != nil {
 		log.Printf("Error al actualizar a WebSocket: %v", err)
 		return
 	}
 
-	client := NewClient(conn, h.Hub, h.Service)
+	client := NewClient(conn, h.Hub, h.Service, claims)
 	h.Hub.Register <- client
 
 	// Iniciar goroutines para lectura y escritura
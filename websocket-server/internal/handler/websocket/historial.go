@@ -0,0 +1,52 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// historialRangoDefecto rango consultado si la solicitud no indica desde
+const historialRangoDefecto = 24 * time.Hour
+
+// OccupancyHistoryRequest datos del mensaje "get_occupancy_history". Sin desde / hasta
+// se devuelven las últimas 24 horas; sin seccion, la ocupación de todo el estacionamiento.
+type OccupancyHistoryRequest struct {
+	Desde   *time.Time `json:"desde,omitempty"`
+	Hasta   *time.Time `json:"hasta,omitempty"`
+	Bucket  string     `json:"bucket"` // "5m", "1h" o "1d"
+	Seccion string     `json:"seccion,omitempty"`
+}
+
+// sendOccupancyHistory envía la ocupación mínima, máxima y promedio por intervalo
+func (c *Client) sendOccupancyHistory(req Message) {
+	if c.Hub.history == nil {
+		c.sendError(req, ErrCodeUpstreamUnavailable, "Historial de ocupación no disponible")
+		return
+	}
+
+	var payload OccupancyHistoryRequest
+	if err := json.Unmarshal(req.Data, &payload); err != nil {
+		c.sendError(req, ErrCodeInvalidPayload, "Se requiere bucket (5m, 1h o 1d) y opcionalmente desde, hasta y seccion")
+		return
+	}
+
+	hasta := time.Now()
+	if payload.Hasta != nil {
+		hasta = *payload.Hasta
+	}
+	desde := hasta.Add(-historialRangoDefecto)
+	if payload.Desde != nil {
+		desde = *payload.Desde
+	}
+
+	ctx := context.Background()
+	historial, err := c.Hub.history.GetHistorial(ctx, strings.ToUpper(payload.Seccion), payload.Bucket, desde, hasta)
+	if err != nil {
+		c.sendQueryError(req, err, "Error al obtener historial de ocupación")
+		return
+	}
+
+	c.reply(req, "occupancy_history", historial)
+}
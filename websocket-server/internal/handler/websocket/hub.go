@@ -6,7 +6,14 @@ import (
 	"sync"
 	"time"
 
+	"github.com/josedavid1945/estacionamiento-websocket/internal/backplane"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/metrics"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/service/capacidad"
 	"github.com/josedavid1945/estacionamiento-websocket/internal/service/dashboard"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/service/ocupacion"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/service/permanencia"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/service/pronostico"
 )
 
 // Hub mantiene el conjunto de clientes activos y transmite mensajes
@@ -26,30 +33,76 @@ type Hub struct {
 	// Servicio de dashboard
 	Service *dashboard.Service
 
+	// Historial de ocupación alimentado con cada snapshot (nil = deshabilitado)
+	history *ocupacion.Service
+
+	// Overstay evalúa la permanencia de los tickets activos en cada ciclo (nil = deshabilitado)
+	Overstay *permanencia.Monitor
+
+	// Capacidad evalúa la ocupación de cada sección en cada ciclo (nil = deshabilitado)
+	Capacidad *capacidad.Monitor
+
+	// Pronostico responde get_forecast (nil = deshabilitado); con PronosticoEnDashboard
+	// también se incluye en cada snapshot del dashboard
+	Pronostico            *pronostico.Service
+	PronosticoEnDashboard bool
+
 	// Intervalo de actualización automática
 	UpdateInterval time.Duration
 
 	// Mutex para acceso concurrente
 	mu sync.RWMutex
 
+	// Último snapshot del dashboard enviado y su versión
+	dashboard dashboardState
+
+	// Serializa las actualizaciones para que los deltas salgan en orden de versión
+	refreshMu sync.Mutex
+
+	// Últimos eventos difundidos, numerados, para reenviarlos en un resume
+	events *eventLog
+
+	// Serializa asignación de secuencia y envío para que lleguen en orden
+	publishMu sync.Mutex
+
+	// Distribución de eventos entre réplicas
+	backplane  backplane.Backplane
+	instanceID string
+	seenKeys   *recentKeys
+
 	// Contexto para cancelar actualizaciones
 	ctx    context.Context
 	cancel context.CancelFunc
 }
 
-// NewHub crea una nueva instancia del Hub
-func NewHub(service *dashboard.Service, updateInterval time.Duration) *Hub {
+// NewHub crea una nueva instancia del Hub. eventBufferSize es la cantidad de
+// eventos recientes que se conservan para reanudar conexiones; bp distribuye los
+// eventos entre réplicas (nil usa un backplane en memoria, para una sola réplica);
+// history registra la ocupación en cada ciclo periódico del dashboard (puede ser nil).
+func NewHub(service *dashboard.Service, updateInterval time.Duration, eventBufferSize int, bp backplane.Backplane, history *ocupacion.Service) *Hub {
 	ctx, cancel := context.WithCancel(context.Background())
-	return &Hub{
+	if bp == nil {
+		bp = backplane.NewMemory()
+	}
+
+	events := newEventLog(eventBufferSize)
+	h := &Hub{
 		Clients:        make(map[*Client]bool),
 		Broadcast:      make(chan []byte, 256),
 		Register:       make(chan *Client),
 		Unregister:     make(chan *Client),
 		Service:        service,
+		history:        history,
 		UpdateInterval: updateInterval,
+		events:         events,
+		backplane:      bp,
+		instanceID:     events.streamID,
+		seenKeys:       newRecentKeys(eventBufferSize),
 		ctx:            ctx,
 		cancel:         cancel,
 	}
+	bp.Subscribe(h.receiveRemote)
+	return h
 }
 
 // Run inicia el Hub
@@ -62,11 +115,14 @@ func (h *Hub) Run() {
 		case client := <-h.Register:
 			h.mu.Lock()
 			h.Clients[client] = true
+			metrics.ConnectedClients.Set(float64(len(h.Clients)))
 			h.mu.Unlock()
 			log.Printf("Cliente conectado: %s. Total clientes: %d", client.ID, len(h.Clients))
 
-			// Enviar datos iniciales al nuevo cliente
-			go client.sendDashboardUpdate()
+			// Enviar sesión y datos iniciales al nuevo cliente
+			streamID, seq := h.events.current()
+			client.sendMessage("session", SessionInfo{ClientID: client.ID, StreamID: streamID, Seq: seq})
+			go client.sendDashboardSnapshot(Message{})
 
 		case client := <-h.Unregister:
 			h.mu.Lock()
@@ -74,6 +130,7 @@ func (h *Hub) Run() {
 				delete(h.Clients, client)
 				log.Printf("Cliente desconectado: %s. Total clientes: %d", client.ID, len(h.Clients))
 			}
+			metrics.ConnectedClients.Set(float64(len(h.Clients)))
 			h.mu.Unlock()
 
 		case message := <-h.Broadcast:
@@ -105,36 +162,203 @@ func (h *Hub) startAutoUpdates() {
 		select {
 		case <-ticker.C:
 			h.broadcastDashboardUpdate()
+			h.checkOverstay()
+			h.checkCapacidad()
 		case <-h.ctx.Done():
 			return
 		}
 	}
 }
 
-// broadcastDashboardUpdate envía actualización del dashboard a todos los clientes
+// broadcastDashboardUpdate envía actualización del dashboard a todos los clientes y
+// registra la ocupación en el historial. Solo el ciclo periódico muestrea el historial:
+// las consultas de los clientes no deben alterar su frecuencia.
 func (h *Hub) broadcastDashboardUpdate() {
-	data, err := h.Service.GetDashboardData(h.ctx)
+	data, _, err := h.RefreshDashboard(h.ctx)
 	if err != nil {
 		log.Printf("Error obteniendo datos del dashboard para broadcast: %v", err)
 		return
 	}
+	if h.history != nil && !data.Stale {
+		h.history.Record(h.ctx, data.Timestamp, data.EspaciosOcupados, data.TotalEspacios)
+	}
+}
 
-	h.mu.RLock()
-	clientCount := len(h.Clients)
-	clients := make([]*Client, 0, clientCount)
-	for client := range h.Clients {
-		clients = append(clients, client)
+// RefreshDashboard consulta el dashboard y, si cambió, envía a todos los clientes un
+// dashboard_delta con los campos modificados. Devuelve el snapshot completo vigente y
+// broadcast = true si fue el primero y ya se envió completo a los suscriptores.
+func (h *Hub) RefreshDashboard(ctx context.Context) (data *models.DashboardData, broadcast bool, err error) {
+	h.refreshMu.Lock()
+	defer h.refreshMu.Unlock()
+	return h.refreshDashboard(ctx)
+}
+
+// refreshDashboard implementa RefreshDashboard; requiere refreshMu tomado
+func (h *Hub) refreshDashboard(ctx context.Context) (*models.DashboardData, bool, error) {
+	snapshot, err := h.Service.GetDashboardData(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	// Copia: el snapshot es compartido por el servicio
+	data := *snapshot.Data
+	data.Stale = snapshot.Stale
+	if h.Pronostico != nil && h.PronosticoEnDashboard {
+		if pronostico, err := h.Pronostico.Actual(ctx); err == nil {
+			data.Pronostico = pronostico
+		}
+	}
+	if !data.Stale {
+		metrics.RecordDashboard(&data)
+	}
+
+	delta, first, err := h.dashboard.update(&data)
+	if err != nil {
+		return nil, false, err
+	}
+
+	current := h.dashboard.current()
+	if delta == nil && !first {
+		// Sin cambios: no reenviar el mismo payload
+		return current, false, nil
 	}
-	h.mu.RUnlock()
 
-	// Enviar a todos los clientes
+	// El dashboard no pasa por el backplane: cada réplica consulta la misma fuente
+	// y mantiene su propia cadena de versiones
+	var sent int
+	if first {
+		sent = h.publishLocal("dashboard_update", []string{TopicDashboard}, current)
+	} else {
+		sent = h.publishLocal("dashboard_delta", []string{TopicDashboard}, delta)
+	}
+
+	if sent > 0 && delta != nil {
+		log.Printf("Dashboard v%d: %d campo(s) cambiaron, delta enviado a %d clientes", delta.Version, len(delta.Changes), sent)
+	}
+
+	return current, first, nil
+}
+
+// DashboardSnapshot devuelve el último snapshot conocido sin consultar de nuevo,
+// o lo obtiene si todavía no existe ninguno. Espera a una actualización en curso para
+// no devolver una versión a la que le falte el delta que se está difundiendo.
+func (h *Hub) DashboardSnapshot(ctx context.Context) (data *models.DashboardData, broadcast bool, err error) {
+	h.refreshMu.Lock()
+	defer h.refreshMu.Unlock()
+
+	if current := h.dashboard.current(); current != nil {
+		return current, false, nil
+	}
+	return h.refreshDashboard(ctx)
+}
+
+// PublishEspacioOcupado envía el evento espacio_ocupado a los clientes suscritos
+// al espacio, a su sección o a la lista de tickets activos
+func (h *Hub) PublishEspacioOcupado(event *models.EspacioOcupadoEvent) {
+	h.Service.InvalidateSnapshots()
+	key := "espacio_ocupado:" + event.EspacioID + ":" + event.HoraIngreso.Format(time.RFC3339Nano)
+	h.publish("espacio_ocupado", key, espacioTopics(event.EspacioID, event.SeccionLetra), event)
+	log.Printf("🚗 Espacio %s ocupado por %s", event.Numero, event.VehiculoPlaca)
+}
+
+// PublishEspacioLiberado envía el evento espacio_liberado a los clientes suscritos
+// al espacio, a su sección o a la lista de tickets activos
+func (h *Hub) PublishEspacioLiberado(event *models.EspacioLiberadoEvent) {
+	h.Service.InvalidateSnapshots()
+	key := "espacio_liberado:" + event.EspacioID + ":" + event.HoraSalida.Format(time.RFC3339Nano)
+	h.publish("espacio_liberado", key, espacioTopics(event.EspacioID, event.SeccionLetra), event)
+	log.Printf("🅿️  Espacio %s liberado", event.Numero)
+}
+
+// PublishMultaRegistrada envía el evento multa_registrada a los clientes suscritos a
+// multas o a la lista de tickets activos y, si el vehículo está estacionado, a su espacio
+func (h *Hub) PublishMultaRegistrada(event *models.MultaEvent) {
+	h.Service.InvalidateSnapshots()
+	h.publish("multa_registrada", "multa_registrada:"+event.MultaID, multaTopics(event), event)
+	log.Printf("🧾 Multa registrada a %s (%d pendiente(s))", event.VehiculoPlaca, event.MultasPendientes)
+}
+
+// PublishMultaPagada envía el evento multa_pagada a los mismos tópicos que multa_registrada
+func (h *Hub) PublishMultaPagada(event *models.MultaEvent) {
+	h.Service.InvalidateSnapshots()
+	h.publish("multa_pagada", "multa_pagada:"+event.MultaID, multaTopics(event), event)
+	log.Printf("💵 Multa pagada por %s (%d pendiente(s))", event.VehiculoPlaca, event.MultasPendientes)
+}
+
+// publishLocal numera el evento, lo guarda en el buffer y lo envía a los clientes
+// de esta instancia suscritos a alguno de sus tópicos. Devuelve a cuántos se envió.
+func (h *Hub) publishLocal(eventType string, topics []string, payload interface{}) int {
+	h.publishMu.Lock()
+	defer h.publishMu.Unlock()
+
+	start := time.Now()
+	defer func() { metrics.BroadcastDuration.Observe(time.Since(start).Seconds(), eventType) }()
+
+	ev := h.events.append(eventType, topics, payload)
+	clients := h.snapshotClients(topics...)
 	for _, client := range clients {
-		client.BroadcastDashboardUpdate(data)
+		client.deliver(ev)
+	}
+	return len(clients)
+}
+
+// Resume reenvía al cliente los eventos posteriores a lastSeq que coincidan con sus
+// suscripciones. Si el hueco ya no está en el buffer indica que debe resincronizar.
+func (h *Hub) Resume(client *Client, req ResumeRequest) ResumeResult {
+	h.publishMu.Lock()
+	defer h.publishMu.Unlock()
+
+	streamID, lastSeq := h.events.current()
+	result := ResumeResult{StreamID: streamID, FromSeq: req.LastSeq, ToSeq: lastSeq}
+
+	events, ok := h.events.since(req.StreamID, req.LastSeq)
+	if !ok {
+		result.ResyncRequired = true
+		return result
 	}
 
-	if clientCount > 0 {
-		log.Printf("Dashboard actualizado y enviado a %d clientes", clientCount)
+	for _, ev := range events {
+		if client.IsSubscribed(ev.Topics...) {
+			client.deliver(ev)
+			result.Replayed++
+		}
+	}
+	return result
+}
+
+// espacioTopics tópicos afectados por un movimiento en un espacio
+func espacioTopics(espacioID, seccionLetra string) []string {
+	topics := []string{TopicEspacio(espacioID), TopicTicketsActivos}
+	if seccionLetra != "" {
+		topics = append(topics, TopicSeccion(seccionLetra))
+	}
+	return topics
+}
+
+// multaTopics tópicos afectados por una multa: cambia la marca del ticket activo del vehículo
+func multaTopics(event *models.MultaEvent) []string {
+	topics := []string{TopicMultas, TopicTicketsActivos}
+	if event.EspacioID != "" {
+		topics = append(topics, TopicEspacio(event.EspacioID))
+	}
+	if event.SeccionLetra != "" {
+		topics = append(topics, TopicSeccion(event.SeccionLetra))
+	}
+	return topics
+}
+
+// snapshotClients copia la lista de clientes suscritos a alguno de los tópicos
+// para enviar sin mantener el lock
+func (h *Hub) snapshotClients(topics ...string) []*Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	clients := make([]*Client, 0, len(h.Clients))
+	for client := range h.Clients {
+		if client.IsSubscribed(topics...) {
+			clients = append(clients, client)
+		}
 	}
+	return clients
 }
 
 // GetClientCount devuelve el número de clientes conectados
@@ -157,6 +381,7 @@ func (h *Hub) Shutdown() {
 		client.Close()
 		delete(h.Clients, client)
 	}
+	metrics.ConnectedClients.Set(0)
 
 	log.Println("Hub cerrado")
 }
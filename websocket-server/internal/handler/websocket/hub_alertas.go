@@ -0,0 +1,70 @@
+package websocket
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/service/dashboard"
+)
+
+// checkOverstay evalúa la permanencia de los tickets activos y publica un
+// overstay_alert por cada cruce de límite o paso de escalamiento. Con datos viejos
+// (stale) no se evalúa para no alertar por vehículos que quizá ya salieron.
+func (h *Hub) checkOverstay() {
+	if h.Overstay == nil {
+		return
+	}
+
+	tickets, err := h.Service.GetTicketsActivos(h.ctx, dashboard.FiltroTickets{})
+	if err != nil {
+		log.Printf("Error obteniendo tickets activos para alertas de permanencia: %v", err)
+		return
+	}
+	if tickets.Stale {
+		return
+	}
+
+	for _, alerta := range h.Overstay.Evaluar(tickets.Data, time.Now()) {
+		alerta := alerta
+		topics := append(espacioTopics(alerta.EspacioID, alerta.SeccionLetra), TopicAlertas)
+		key := fmt.Sprintf("overstay_alert:%s:%d", alerta.TicketID, alerta.Nivel)
+		h.publish("overstay_alert", key, topics, &alerta)
+		log.Printf("⏰ %s excede su permanencia en %d min (espacio %s, nivel %d, regla %s)",
+			alerta.VehiculoPlaca, alerta.MinutosExcedidos, alerta.EspacioNumero, alerta.Nivel, alerta.Regla)
+	}
+}
+
+// checkCapacidad evalúa la ocupación de cada sección y publica un capacidad_alerta
+// cuando una sección cambia de nivel. Los datos viejos (stale) no se evalúan.
+func (h *Hub) checkCapacidad() {
+	if h.Capacidad == nil {
+		return
+	}
+
+	secciones, err := h.Service.GetEspaciosPorSeccion(h.ctx)
+	if err != nil {
+		log.Printf("Error obteniendo secciones para alertas de capacidad: %v", err)
+		return
+	}
+	if secciones.Stale {
+		return
+	}
+
+	for _, alerta := range h.Capacidad.Evaluar(secciones.Data, time.Now()) {
+		alerta := alerta
+		topics := []string{TopicAlertas, TopicSeccion(alerta.SeccionLetra)}
+		// Las réplicas evalúan los mismos datos: la transición de la sección descarta duplicados
+		key := fmt.Sprintf("%s:%s:%s", capacidadGroup(&alerta), alerta.NivelAnterior, alerta.Nivel)
+		h.publish("capacidad_alerta", key, topics, &alerta)
+		log.Printf("🚦 Sección %s: %s → %s (%.1f%% ocupado, %d/%d)",
+			alerta.SeccionLetra, alerta.NivelAnterior, alerta.Nivel, alerta.Porcentaje, alerta.EspaciosOcupados, alerta.TotalEspacios)
+	}
+}
+
+// capacidadGroup agrupa las alertas de capacidad de una sección
+func capacidadGroup(alerta *models.CapacidadAlerta) string {
+	return "capacidad_alerta:" + strings.ToUpper(alerta.SeccionLetra)
+}
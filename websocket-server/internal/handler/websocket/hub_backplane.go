@@ -0,0 +1,165 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/backplane"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+// remotePayloads reconstruye el tipo de cada evento recibido por el backplane para
+// que deliver aplique el mismo filtrado por rol que a los eventos locales
+var remotePayloads = map[string]func() interface{}{
+	"espacio_ocupado":  func() interface{} { return &models.EspacioOcupadoEvent{} },
+	"espacio_liberado": func() interface{} { return &models.EspacioLiberadoEvent{} },
+	"multa_registrada": func() interface{} { return &models.MultaEvent{} },
+	"multa_pagada":     func() interface{} { return &models.MultaEvent{} },
+	"overstay_alert":   func() interface{} { return &models.OverstayAlert{} },
+	"capacidad_alerta": func() interface{} { return &models.CapacidadAlerta{} },
+}
+
+// recentKeys recuerda las últimas claves de eventos para descartar duplicados
+// (por ejemplo, varias réplicas que reciben el mismo NOTIFY de la base de datos).
+// Los eventos de estado (como el nivel de capacidad de una sección) solo recuerdan
+// la última clave de su grupo, para que una transición repetida más tarde se publique.
+type recentKeys struct {
+	mu     sync.Mutex
+	seen   map[string]bool
+	order  []string
+	next   int
+	latest map[string]string // grupo -> última clave
+}
+
+// newRecentKeys crea un conjunto acotado a size claves
+func newRecentKeys(size int) *recentKeys {
+	return &recentKeys{
+		seen:   make(map[string]bool, size),
+		order:  make([]string, size),
+		latest: make(map[string]string),
+	}
+}
+
+// add registra la clave y devuelve false si ya se había visto
+func (r *recentKeys) add(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.seen[key] {
+		return false
+	}
+	if old := r.order[r.next]; old != "" {
+		delete(r.seen, old)
+	}
+	r.order[r.next] = key
+	r.next = (r.next + 1) % len(r.order)
+	r.seen[key] = true
+	return true
+}
+
+// replace registra la clave como la última de su grupo y devuelve false si ya lo era
+func (r *recentKeys) replace(group, key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.latest[group] == key {
+		return false
+	}
+	r.latest[group] = key
+	return true
+}
+
+// duplicate indica si el evento ya se publicó, según su clave de idempotencia
+func (h *Hub) duplicate(key string, payload interface{}) bool {
+	if key == "" {
+		return false
+	}
+	if alerta, ok := payload.(*models.CapacidadAlerta); ok {
+		return !h.seenKeys.replace(capacidadGroup(alerta), key)
+	}
+	return !h.seenKeys.add(key)
+}
+
+// publish entrega el evento a los clientes locales y lo envía por el backplane
+// al resto de instancias. key identifica el evento para descartar duplicados.
+func (h *Hub) publish(eventType, key string, topics []string, payload interface{}) int {
+	if h.duplicate(key, payload) {
+		return 0
+	}
+
+	sent := h.publishLocal(eventType, topics, payload)
+
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		log.Printf("Error serializando evento %s para el backplane: %v", eventType, err)
+		return sent
+	}
+
+	env := backplane.Envelope{
+		Origin:  h.instanceID,
+		Type:    eventType,
+		Topics:  topics,
+		Key:     key,
+		Payload: raw,
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(h.ctx, 5*time.Second)
+		defer cancel()
+		if err := h.backplane.Publish(ctx, env); err != nil {
+			log.Printf("Error publicando en backplane: %v", err)
+		}
+	}()
+
+	return sent
+}
+
+// receiveRemote entrega a los clientes locales un evento publicado por otra instancia
+func (h *Hub) receiveRemote(env backplane.Envelope) {
+	if env.Type == backplane.TypeReconnect {
+		h.resetSession()
+		return
+	}
+	if env.Origin == h.instanceID {
+		return
+	}
+
+	var payload interface{} = env.Payload
+	if factory, ok := remotePayloads[env.Type]; ok {
+		typed := factory()
+		if err := json.Unmarshal(env.Payload, typed); err != nil {
+			log.Printf("Error decodificando evento %s del backplane: %v", env.Type, err)
+			return
+		}
+		payload = typed
+	}
+	if h.duplicate(env.Key, payload) {
+		return
+	}
+
+	// Otra instancia vio un cambio en los datos: los snapshots locales quedaron viejos
+	h.Service.InvalidateSnapshots()
+	h.publishLocal(env.Type, env.Topics, payload)
+}
+
+// resetSession se llama cuando el backplane perdió eventos de otras instancias:
+// empieza un stream nuevo y reenvía la sesión a los clientes, que al no poder
+// reanudar el stream anterior piden los datos completos
+func (h *Hub) resetSession() {
+	h.Service.InvalidateSnapshots()
+
+	h.publishMu.Lock()
+	defer h.publishMu.Unlock()
+
+	streamID, seq := h.events.reset()
+	h.mu.RLock()
+	for client := range h.Clients {
+		client.sendMessage("session", SessionInfo{ClientID: client.ID, StreamID: streamID, Seq: seq})
+	}
+	total := len(h.Clients)
+	h.mu.RUnlock()
+
+	log.Printf("🔄 Backplane reconectado: nuevo stream %s enviado a %d cliente(s)", streamID, total)
+}
@@ -0,0 +1,96 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/auth"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/backplane"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/service/dashboard"
+)
+
+// nuevoHubPrueba Hub sin fuente de datos con un operador conectado
+func nuevoHubPrueba(t *testing.T) (*Hub, *Client) {
+	t.Helper()
+	h := NewHub(dashboard.NewService(nil, time.Minute), time.Minute, 16, nil, nil)
+	t.Cleanup(h.cancel)
+
+	c := nuevoClientePrueba(auth.RoleOperator)
+	c.Hub = h
+	h.Clients[c] = true
+	return h, c
+}
+
+// remotoCapacidad envelope de otra réplica con la transición de la sección A
+func remotoCapacidad(t *testing.T, anterior, nivel string) backplane.Envelope {
+	t.Helper()
+	alerta := &models.CapacidadAlerta{SeccionLetra: "A", NivelAnterior: anterior, Nivel: nivel}
+	payload, err := json.Marshal(alerta)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	return backplane.Envelope{
+		Origin:  "otra-replica",
+		Type:    "capacidad_alerta",
+		Topics:  []string{TopicAlertas, TopicSeccion("A")},
+		Key:     fmt.Sprintf("%s:%s:%s", capacidadGroup(alerta), anterior, nivel),
+		Payload: payload,
+	}
+}
+
+func TestCapacidadDeduplicaPorTransicion(t *testing.T) {
+	h, c := nuevoHubPrueba(t)
+
+	pasos := []struct {
+		anterior, nivel string
+		entregada       bool
+	}{
+		{"normal", "advertencia", true},
+		{"normal", "advertencia", false}, // la misma transición desde otra réplica
+		{"advertencia", "normal", true},
+		{"normal", "advertencia", true}, // la sección vuelve a subir más tarde
+	}
+	for i, paso := range pasos {
+		h.receiveRemote(remotoCapacidad(t, paso.anterior, paso.nivel))
+		got := recibido(t, c) == "capacidad_alerta"
+		if got != paso.entregada {
+			t.Errorf("paso %d (%s → %s): entregada = %v, se esperaba %v", i, paso.anterior, paso.nivel, got, paso.entregada)
+		}
+	}
+}
+
+func TestReconexionBackplaneReiniciaSesion(t *testing.T) {
+	h, c := nuevoHubPrueba(t)
+
+	h.publishLocal("capacidad_alerta", []string{TopicAlertas}, &models.CapacidadAlerta{SeccionLetra: "A"})
+	recibido(t, c)
+	streamAnterior, seqAnterior := h.events.current()
+
+	h.receiveRemote(backplane.Envelope{Type: backplane.TypeReconnect})
+
+	var msg Message
+	select {
+	case raw := <-c.Send:
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			t.Fatalf("mensaje inválido: %v", err)
+		}
+	default:
+		t.Fatal("el cliente no recibió la sesión nueva")
+	}
+	var session SessionInfo
+	if err := json.Unmarshal(msg.Data, &session); err != nil || msg.Type != "session" {
+		t.Fatalf("mensaje %s %s, se esperaba session (%v)", msg.Type, msg.Data, err)
+	}
+	if session.StreamID == streamAnterior || session.Seq != 0 {
+		t.Errorf("sesión %+v, se esperaba un stream nuevo desde la secuencia 0", session)
+	}
+
+	// El stream anterior ya no se puede reanudar: el cliente debe pedir los datos completos
+	result := h.Resume(c, ResumeRequest{StreamID: streamAnterior, LastSeq: seqAnterior})
+	if !result.ResyncRequired {
+		t.Errorf("resume del stream anterior = %+v, se esperaba resync_required", result)
+	}
+}
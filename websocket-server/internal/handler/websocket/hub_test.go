@@ -0,0 +1,133 @@
+package websocket
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/auth"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/service/dashboard"
+)
+
+// fuenteDatos fuente con un dashboard fijo y el resto de las consultas vacías
+type fuenteDatos struct{}
+
+func (fuenteDatos) Name() string { return "prueba" }
+
+func (fuenteDatos) GetDashboardData(context.Context) (*models.DashboardData, error) {
+	return &models.DashboardData{EspaciosDisponibles: 2, EspaciosOcupados: 2, TotalEspacios: 4, Timestamp: time.Now()}, nil
+}
+
+func (fuenteDatos) GetEspaciosPorSeccion(context.Context) ([]models.EspaciosPorSeccion, error) {
+	return []models.EspaciosPorSeccion{}, nil
+}
+
+func (fuenteDatos) GetEspaciosDisponibles(context.Context) ([]models.EspacioDetalle, error) {
+	return []models.EspacioDetalle{}, nil
+}
+
+func (fuenteDatos) GetTicketsActivos(context.Context) ([]models.Ticket, error) {
+	return []models.Ticket{}, nil
+}
+
+func (fuenteDatos) GetTicketsActivosByAuthUser(context.Context, string) ([]models.Ticket, error) {
+	return []models.Ticket{}, nil
+}
+
+func (fuenteDatos) GetDesgloseIngresos(context.Context, time.Time, time.Time) (*models.DesgloseIngresos, error) {
+	return &models.DesgloseIngresos{}, nil
+}
+
+func (fuenteDatos) GetFlujoHorario(context.Context, time.Time, time.Time) ([]models.FlujoHora, error) {
+	return []models.FlujoHora{}, nil
+}
+
+func (fuenteDatos) BuscarPlaca(context.Context, string, int, int) ([]models.ResultadoPlaca, error) {
+	return []models.ResultadoPlaca{}, nil
+}
+
+func (fuenteDatos) BuscarTickets(context.Context, models.FiltroBusquedaTickets, *models.CursorTickets, int, time.Time) ([]models.Ticket, error) {
+	return []models.Ticket{}, nil
+}
+
+// nuevoHubConDatos Hub sobre una fuente con datos con un operador conectado
+func nuevoHubConDatos(t *testing.T) (*Hub, *Client) {
+	t.Helper()
+	h := NewHub(dashboard.NewService(fuenteDatos{}, time.Minute), time.Minute, 16, nil, nil)
+	t.Cleanup(h.cancel)
+
+	c := nuevoClientePrueba(auth.RoleOperator)
+	c.Hub = h
+	c.Service = h.Service
+	h.Clients[c] = true
+	return h, c
+}
+
+// mensajes tipos de todos los mensajes encolados para el cliente
+func mensajes(t *testing.T, c *Client) []string {
+	t.Helper()
+	var tipos []string
+	for tipo := recibido(t, c); tipo != ""; tipo = recibido(t, c) {
+		tipos = append(tipos, tipo)
+	}
+	return tipos
+}
+
+func TestPrimerDashboardSinRespuestaDuplicada(t *testing.T) {
+	for _, caso := range []struct {
+		nombre string
+		enviar func(c *Client)
+	}{
+		{"get_dashboard", func(c *Client) { c.sendDashboardUpdate(Message{Type: "get_dashboard", ID: "r1"}) }},
+		{"al conectar", func(c *Client) { c.sendDashboardSnapshot(Message{}) }},
+	} {
+		t.Run(caso.nombre, func(t *testing.T) {
+			_, c := nuevoHubConDatos(t)
+
+			caso.enviar(c)
+			if got := mensajes(t, c); len(got) != 1 || got[0] != "dashboard_update" {
+				t.Fatalf("primer snapshot: mensajes %v, se esperaba un único dashboard_update", got)
+			}
+
+			// Con un snapshot vigente la respuesta es directa
+			caso.enviar(c)
+			if got := mensajes(t, c); len(got) != 1 || got[0] != "dashboard_update" {
+				t.Errorf("segundo pedido: mensajes %v, se esperaba la respuesta dashboard_update", got)
+			}
+		})
+	}
+}
+
+func TestPrimerDashboardRespondeANoSuscritos(t *testing.T) {
+	h, c := nuevoHubConDatos(t)
+	c.subs.add([]string{TopicSeccion("A")})
+
+	c.sendDashboardUpdate(Message{Type: "get_dashboard", ID: "r1"})
+	if got := mensajes(t, c); len(got) != 1 || got[0] != "dashboard_update" {
+		t.Fatalf("mensajes %v, se esperaba la respuesta dashboard_update", got)
+	}
+	if _, seq := h.events.current(); seq != 1 {
+		t.Errorf("secuencia %d, se esperaba el primer snapshot difundido", seq)
+	}
+}
+
+func TestHandleMessageRespondeCadaTipo(t *testing.T) {
+	// Todo tipo de messagePolicy debe tener su caso en handleMessage: sin él el mensaje
+	// se descartaría en silencio
+	for tipo := range messagePolicy {
+		t.Run(tipo, func(t *testing.T) {
+			_, c := nuevoHubConDatos(t)
+			c.handleMessage(Message{Type: tipo, ID: "r1"})
+			if got := mensajes(t, c); len(got) == 0 {
+				t.Errorf("%s sin respuesta", tipo)
+			}
+		})
+	}
+
+	_, c := nuevoHubConDatos(t)
+	c.handleMessage(Message{Type: "borrar_todo", ID: "r1"})
+	if got := mensajes(t, c); len(got) != 1 || got[0] != "error" {
+		t.Errorf("tipo desconocido: mensajes %v, se esperaba un error", got)
+	}
+}
@@ -0,0 +1,43 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// RevenueBreakdownRequest datos del mensaje "get_revenue_breakdown". Sin desde / hasta
+// se desglosa el mes en curso hasta ahora.
+type RevenueBreakdownRequest struct {
+	Desde *time.Time `json:"desde,omitempty"`
+	Hasta *time.Time `json:"hasta,omitempty"`
+}
+
+// sendRevenueBreakdown envía la recaudación del rango por método, tarifa, sección y hora
+func (c *Client) sendRevenueBreakdown(req Message) {
+	var payload RevenueBreakdownRequest
+	if len(req.Data) > 0 {
+		if err := json.Unmarshal(req.Data, &payload); err != nil {
+			c.sendError(req, ErrCodeInvalidPayload, "desde y hasta deben ser fechas RFC 3339")
+			return
+		}
+	}
+
+	ahora := time.Now()
+	desde := time.Date(ahora.Year(), ahora.Month(), 1, 0, 0, 0, 0, ahora.Location())
+	hasta := ahora
+	if payload.Desde != nil {
+		desde = *payload.Desde
+	}
+	if payload.Hasta != nil {
+		hasta = *payload.Hasta
+	}
+
+	desglose, err := c.Service.GetDesgloseIngresos(context.Background(), desde, hasta)
+	if err != nil {
+		c.sendQueryError(req, err, "Error al obtener desglose de ingresos")
+		return
+	}
+
+	c.reply(req, "revenue_breakdown", desglose)
+}
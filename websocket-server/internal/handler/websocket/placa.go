@@ -0,0 +1,30 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// BuscarPlacaRequest datos del mensaje "buscar_placa": placa completa o parcial y
+// cuántas visitas anteriores devolver por vehículo (5 por defecto)
+type BuscarPlacaRequest struct {
+	Placa   string `json:"placa"`
+	Visitas int    `json:"visitas,omitempty"`
+}
+
+// sendBuscarPlaca responde dónde está estacionado cada vehículo que coincide con la placa
+func (c *Client) sendBuscarPlaca(req Message) {
+	var payload BuscarPlacaRequest
+	if err := json.Unmarshal(req.Data, &payload); err != nil {
+		c.sendError(req, ErrCodeInvalidPayload, "Se requiere placa y opcionalmente visitas")
+		return
+	}
+
+	busqueda, err := c.Service.BuscarPlaca(context.Background(), payload.Placa, payload.Visitas)
+	if err != nil {
+		c.sendQueryError(req, err, "Error al buscar placa")
+		return
+	}
+
+	c.reply(req, "placa_resultado", busqueda)
+}
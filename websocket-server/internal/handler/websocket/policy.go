@@ -0,0 +1,74 @@
+package websocket
+
+import "github.com/josedavid1945/estacionamiento-websocket/internal/policy"
+
+var (
+	rolesTodos    = policy.RolesTodos
+	rolesPersonal = policy.RolesPersonal
+)
+
+// messagePolicy roles autorizados para cada tipo de mensaje del cliente.
+// get_tickets_activos está abierto a "user" porque el handler filtra por sus vehículos.
+var messagePolicy = map[string][]string{
+	"get_dashboard":            rolesTodos,
+	"dashboard_resync":         rolesTodos,
+	"get_espacios_por_seccion": rolesTodos,
+	"get_espacios_disponibles": rolesTodos,
+	"get_tickets_activos":      rolesTodos,
+	"get_occupancy_history":    rolesTodos,
+	"get_revenue_breakdown":    rolesPersonal,
+	"get_forecast":             rolesTodos,
+	"buscar_placa":             rolesPersonal,
+	"buscar_tickets":           rolesPersonal,
+	"subscribe":                rolesTodos,
+	"unsubscribe":              rolesTodos,
+	"resume":                   rolesTodos,
+}
+
+// topicPolicy roles autorizados para tópicos que exponen datos del personal
+var topicPolicy = map[string][]string{
+	TopicTicketsActivos: rolesPersonal,
+	TopicMultas:         rolesPersonal,
+	TopicAlertas:        rolesPersonal,
+}
+
+// isStaff indica si el cliente es admin u operador (ver policy.IsStaff)
+func (c *Client) isStaff() bool {
+	return policy.IsStaff(c.Claims)
+}
+
+// authorize verifica si el rol del cliente puede enviar el tipo de mensaje. Los tipos
+// sin política se rechazan siempre, también sin autenticación.
+func (c *Client) authorize(messageType string) bool {
+	roles, ok := messagePolicy[messageType]
+	if !ok {
+		return false
+	}
+	if c.Claims == nil {
+		return true
+	}
+	return c.Claims.HasRole(roles...)
+}
+
+// authorizeTopic verifica si el rol del cliente puede suscribirse al tópico
+func (c *Client) authorizeTopic(topic string) bool {
+	if c.Claims == nil {
+		return true
+	}
+	roles, ok := topicPolicy[topic]
+	if !ok {
+		return true
+	}
+	return c.Claims.HasRole(roles...)
+}
+
+// authorizedTopics filtra los tópicos de un evento que el rol del cliente puede recibir
+func (c *Client) authorizedTopics(topics []string) []string {
+	authorized := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		if c.authorizeTopic(topic) {
+			authorized = append(authorized, topic)
+		}
+	}
+	return authorized
+}
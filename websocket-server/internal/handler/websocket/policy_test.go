@@ -0,0 +1,95 @@
+package websocket
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/auth"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+func nuevoClientePrueba(role string) *Client {
+	c := &Client{ID: "c1", Send: make(chan []byte, 8)}
+	if role != "" {
+		c.Claims = &auth.Claims{Sub: "u1", Role: role}
+	}
+	return c
+}
+
+// recibido tipo del mensaje encolado para el cliente ("" si no se envió nada)
+func recibido(t *testing.T, c *Client) string {
+	t.Helper()
+	select {
+	case raw := <-c.Send:
+		var msg Message
+		if err := json.Unmarshal(raw, &msg); err != nil {
+			t.Fatalf("mensaje inválido: %v", err)
+		}
+		return msg.Type
+	default:
+		return ""
+	}
+}
+
+func TestAuthorizeDeniegaTiposDesconocidos(t *testing.T) {
+	for _, role := range []string{"", auth.RoleAdmin, auth.RoleUser} {
+		c := nuevoClientePrueba(role)
+		if c.authorize("borrar_todo") {
+			t.Errorf("rol %q autorizado para un tipo sin política", role)
+		}
+		if !c.authorize("get_dashboard") {
+			t.Errorf("rol %q no autorizado para get_dashboard", role)
+		}
+	}
+	if nuevoClientePrueba(auth.RoleUser).authorize("buscar_placa") {
+		t.Error("un usuario puede buscar placas")
+	}
+}
+
+func TestIsSubscribedAplicaTopicPolicy(t *testing.T) {
+	casos := []struct {
+		nombre   string
+		role     string
+		suscrito []string
+		topics   []string
+		recibe   bool
+	}{
+		{"usuario sin suscripciones, tópico del personal", auth.RoleUser, nil, []string{TopicAlertas}, false},
+		{"usuario sin suscripciones, tópico público", auth.RoleUser, nil, []string{TopicAlertas, TopicSeccion("A")}, true},
+		{"usuario suscrito a un tópico del personal", auth.RoleUser, []string{TopicMultas}, []string{TopicMultas}, false},
+		{"operador sin suscripciones", auth.RoleOperator, nil, []string{TopicAlertas}, true},
+		{"operador suscrito a otro tópico", auth.RoleOperator, []string{TopicMultas}, []string{TopicAlertas}, false},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			c := nuevoClientePrueba(caso.role)
+			if caso.suscrito != nil {
+				c.subs.add(caso.suscrito)
+			}
+			if got := c.IsSubscribed(caso.topics...); got != caso.recibe {
+				t.Errorf("IsSubscribed(%v) = %v, se esperaba %v", caso.topics, got, caso.recibe)
+			}
+		})
+	}
+}
+
+func TestDeliverAlertasSoloPersonal(t *testing.T) {
+	eventos := []hubEvent{
+		{Type: "capacidad_alerta", Payload: &models.CapacidadAlerta{SeccionLetra: "A", Nivel: "lleno"}},
+		{Type: "overstay_alert", Payload: &models.OverstayAlert{TicketID: "t1", VehiculoPlaca: "ABC-123"}},
+		{Type: "evento_remoto", Payload: json.RawMessage(`{"vehiculo_placa":"ABC-123"}`)},
+	}
+	for _, ev := range eventos {
+		usuario := nuevoClientePrueba(auth.RoleUser)
+		usuario.deliver(ev)
+		if got := recibido(t, usuario); got != "" {
+			t.Errorf("%s entregado a un usuario", ev.Type)
+		}
+
+		operador := nuevoClientePrueba(auth.RoleOperator)
+		operador.deliver(ev)
+		if got := recibido(t, operador); got != ev.Type {
+			t.Errorf("%s no entregado al operador (recibió %q)", ev.Type, got)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/service/pronostico"
+)
+
+// ForecastRequest datos del mensaje "get_forecast". Sin horas se pronostica el
+// horizonte máximo (3 horas).
+type ForecastRequest struct {
+	Horas int `json:"horas,omitempty"`
+}
+
+// sendForecast envía la ocupación esperada de cada sección para las próximas horas
+func (c *Client) sendForecast(req Message) {
+	if c.Hub.Pronostico == nil {
+		c.sendError(req, ErrCodeUpstreamUnavailable, "Pronóstico de ocupación no disponible")
+		return
+	}
+
+	payload := ForecastRequest{Horas: pronostico.HorizonteMaximo}
+	if len(req.Data) > 0 {
+		if err := json.Unmarshal(req.Data, &payload); err != nil {
+			c.sendError(req, ErrCodeInvalidPayload, "horas debe ser un número entre 1 y 3")
+			return
+		}
+		if payload.Horas == 0 {
+			payload.Horas = pronostico.HorizonteMaximo
+		}
+	}
+
+	resultado, err := c.Hub.Pronostico.GetPronostico(context.Background(), payload.Horas)
+	if err != nil {
+		c.sendQueryError(req, err, "Error al obtener pronóstico de ocupación")
+		return
+	}
+
+	c.reply(req, "forecast", resultado)
+}
@@ -0,0 +1,50 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/service/dashboard"
+)
+
+// TicketsActivosRequest datos opcionales del mensaje "get_tickets_activos". Sin datos
+// se envían todos los tickets, los ingresos más recientes primero.
+type TicketsActivosRequest struct {
+	Seccion string `json:"seccion,omitempty"`
+	Placa   string `json:"placa,omitempty"` // prefijo de placa
+	Orden   string `json:"orden,omitempty"` // "ingreso", "placa" o "seccion"
+	Desc    bool   `json:"desc,omitempty"`
+}
+
+// sendTicketsActivos envía tickets activos con su vehículo, espacio, sección, tiempo y
+// cobro acumulado. Un usuario final solo recibe los de sus vehículos.
+func (c *Client) sendTicketsActivos(req Message) {
+	var payload TicketsActivosRequest
+	if len(req.Data) > 0 {
+		if err := json.Unmarshal(req.Data, &payload); err != nil {
+			c.sendError(req, ErrCodeInvalidPayload, "Filtro inválido: se aceptan seccion, placa, orden y desc")
+			return
+		}
+	}
+	filtro := dashboard.FiltroTickets(payload)
+
+	ctx := context.Background()
+
+	if !c.isStaff() {
+		tickets, err := c.Service.GetTicketsActivosByAuthUser(ctx, c.Claims.Sub, filtro)
+		if err != nil {
+			c.sendQueryError(req, err, "Error al obtener tickets activos")
+			return
+		}
+		c.reply(req, "tickets_activos", tickets)
+		return
+	}
+
+	tickets, err := c.Service.GetTicketsActivos(ctx, filtro)
+	if err != nil {
+		c.sendQueryError(req, err, "Error al obtener tickets activos")
+		return
+	}
+
+	c.replySnapshot(req, "tickets_activos", tickets.Data, tickets.FetchedAt, tickets.Stale)
+}
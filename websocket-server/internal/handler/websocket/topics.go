@@ -0,0 +1,106 @@
+package websocket
+
+import (
+	"strings"
+	"sync"
+)
+
+// Tópicos a los que un cliente puede suscribirse con el mensaje "subscribe"
+const (
+	TopicDashboard      = "dashboard"
+	TopicTicketsActivos = "tickets_activos"
+	TopicMultas         = "multas"
+	TopicAlertas        = "alertas"
+	TopicSeccionPrefix  = "seccion:"
+	TopicEspacioPrefix  = "espacio:"
+)
+
+// SubscriptionRequest payload de los mensajes subscribe / unsubscribe
+type SubscriptionRequest struct {
+	Topics []string `json:"topics"`
+}
+
+// TopicSeccion devuelve el tópico de una sección (ej. "seccion:B")
+func TopicSeccion(letra string) string {
+	return TopicSeccionPrefix + strings.ToUpper(letra)
+}
+
+// TopicEspacio devuelve el tópico de un espacio individual
+func TopicEspacio(espacioID string) string {
+	return TopicEspacioPrefix + espacioID
+}
+
+// normalizeTopic valida el nombre de un tópico y lo devuelve en su forma canónica
+func normalizeTopic(topic string) (string, bool) {
+	topic = strings.TrimSpace(topic)
+	switch {
+	case topic == TopicDashboard, topic == TopicTicketsActivos, topic == TopicMultas, topic == TopicAlertas:
+		return topic, true
+	case strings.HasPrefix(topic, TopicSeccionPrefix) && len(topic) > len(TopicSeccionPrefix):
+		return TopicSeccion(strings.TrimPrefix(topic, TopicSeccionPrefix)), true
+	case strings.HasPrefix(topic, TopicEspacioPrefix) && len(topic) > len(TopicEspacioPrefix):
+		return topic, true
+	}
+	return "", false
+}
+
+// subscriptions conjunto de tópicos de un cliente. Mientras el cliente no envíe
+// ningún "subscribe" recibe todos los eventos, como antes de existir los tópicos.
+type subscriptions struct {
+	mu     sync.RWMutex
+	topics map[string]bool
+	active bool
+}
+
+// add suscribe a los tópicos indicados
+func (s *subscriptions) add(topics []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.topics == nil {
+		s.topics = make(map[string]bool)
+	}
+	s.active = true
+	for _, topic := range topics {
+		s.topics[topic] = true
+	}
+}
+
+// remove cancela la suscripción a los tópicos indicados
+func (s *subscriptions) remove(topics []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.active = true
+	for _, topic := range topics {
+		delete(s.topics, topic)
+	}
+}
+
+// list devuelve los tópicos suscritos
+func (s *subscriptions) list() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	topics := make([]string, 0, len(s.topics))
+	for topic := range s.topics {
+		topics = append(topics, topic)
+	}
+	return topics
+}
+
+// matches indica si el cliente debe recibir un evento publicado en alguno de los tópicos
+func (s *subscriptions) matches(topics []string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.active {
+		return true
+	}
+	for _, topic := range topics {
+		if s.topics[topic] {
+			return true
+		}
+	}
+	return false
+}
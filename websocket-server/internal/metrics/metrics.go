@@ -0,0 +1,172 @@
+package metrics
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+// Métricas del Hub WebSocket
+var (
+	ConnectedClients = NewGaugeVec(
+		"ws_connected_clients",
+		"Clientes WebSocket conectados a esta instancia.",
+	)
+	MessagesReceived = NewCounterVec(
+		"ws_messages_received_total",
+		"Mensajes recibidos de los clientes por tipo.",
+		"type",
+	)
+	MessagesSent = NewCounterVec(
+		"ws_messages_sent_total",
+		"Mensajes encolados hacia los clientes por tipo.",
+		"type",
+	)
+	SendDropped = NewCounterVec(
+		"ws_send_dropped_total",
+		"Mensajes descartados porque el canal de envío del cliente estaba lleno.",
+		"type",
+	)
+	BroadcastDuration = NewHistogramVec(
+		"ws_broadcast_duration_seconds",
+		"Tiempo en difundir un evento a los clientes suscritos.",
+		DefaultBuckets,
+		"type",
+	)
+)
+
+// Métricas de las fuentes de datos
+var (
+	RepositoryQueryDuration = NewHistogramVec(
+		"dashboard_repository_query_duration_seconds",
+		"Latencia de las consultas del DashboardRepository.",
+		DefaultBuckets,
+		"query",
+	)
+	RepositoryQueryErrors = NewCounterVec(
+		"dashboard_repository_query_errors_total",
+		"Consultas del DashboardRepository que devolvieron error.",
+		"query",
+	)
+	SnapshotRequests = NewCounterVec(
+		"dashboard_snapshot_requests_total",
+		"Consultas al snapshot compartido por tipo y resultado (hit, shared, miss, stale).",
+		"kind", "result",
+	)
+	RestRequestDuration = NewHistogramVec(
+		"rest_client_request_duration_seconds",
+		"Latencia de las llamadas del RestClient al backend REST.",
+		DefaultBuckets,
+		"method", "endpoint",
+	)
+	RestRequestErrors = NewCounterVec(
+		"rest_client_request_errors_total",
+		"Llamadas del RestClient fallidas (error de red o estado 5xx).",
+		"method", "endpoint",
+	)
+)
+
+// Métricas de negocio tomadas del último dashboard calculado
+var (
+	Espacios = NewGaugeVec(
+		"estacionamiento_espacios",
+		"Espacios del estacionamiento por estado.",
+		"estado",
+	)
+	VehiculosActivos = NewGaugeVec(
+		"estacionamiento_vehiculos_activos",
+		"Vehículos con ticket activo.",
+	)
+	Ocupacion = NewGaugeVec(
+		"estacionamiento_ocupacion_ratio",
+		"Proporción de espacios ocupados (0 a 1).",
+	)
+	Recaudado = NewGaugeVec(
+		"estacionamiento_recaudado",
+		"Dinero recaudado por periodo.",
+		"periodo",
+	)
+	MultasPendientes = NewGaugeVec(
+		"estacionamiento_multas_pendientes",
+		"Multas en estado pendiente.",
+	)
+	MontoMultasPendientes = NewGaugeVec(
+		"estacionamiento_multas_pendientes_monto",
+		"Monto total de las multas pendientes.",
+	)
+)
+
+// RecordDashboard actualiza los medidores de ocupación, ingresos y multas con el snapshot
+func RecordDashboard(data *models.DashboardData) {
+	Espacios.Set(float64(data.EspaciosDisponibles), "disponibles")
+	Espacios.Set(float64(data.EspaciosOcupados), "ocupados")
+	Espacios.Set(float64(data.TotalEspacios), "total")
+	VehiculosActivos.Set(float64(data.VehiculosActivos))
+	if data.TotalEspacios > 0 {
+		Ocupacion.Set(float64(data.EspaciosOcupados) / float64(data.TotalEspacios))
+	}
+	Recaudado.Set(data.DineroRecaudadoHoy, "hoy")
+	Recaudado.Set(data.DineroRecaudadoMes, "mes")
+	MultasPendientes.Set(float64(data.MultasPendientes))
+	MontoMultasPendientes.Set(data.MontoMultasPendientes)
+}
+
+// ObserveQuery registra la latencia y el error de una consulta del repositorio
+func ObserveQuery(query string, start time.Time, err error) {
+	RepositoryQueryDuration.Observe(time.Since(start).Seconds(), query)
+	if err != nil {
+		RepositoryQueryErrors.Inc(query)
+	}
+}
+
+// ===================== RestClient =====================
+
+// idSegment segmentos de ruta que son identificadores (numéricos o UUID)
+var idSegment = regexp.MustCompile(`^([0-9]+|[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})$`)
+
+// endpointLabel reemplaza los identificadores de la ruta por :id para acotar la
+// cardinalidad. Los parámetros libres conocidos (placas) también se colapsan.
+func endpointLabel(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i, segment := range segments {
+		if idSegment.MatchString(segment) {
+			segments[i] = ":id"
+		}
+		if i > 0 && segments[i-1] == "buscar-vehiculo" {
+			segments[i] = ":placa"
+		}
+	}
+	return "/" + strings.Join(segments, "/")
+}
+
+// instrumentedTransport mide cada llamada HTTP del RestClient
+type instrumentedTransport struct {
+	base     http.RoundTripper
+	basePath string
+}
+
+// NewTransport envuelve base para registrar latencia y errores por endpoint.
+// basePath es el prefijo de la URL del backend que se omite en la etiqueta.
+func NewTransport(base http.RoundTripper, basePath string) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &instrumentedTransport{base: base, basePath: strings.TrimSuffix(basePath, "/")}
+}
+
+// RoundTrip ejecuta la llamada y registra sus métricas
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	endpoint := endpointLabel(strings.TrimPrefix(req.URL.Path, t.basePath))
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	RestRequestDuration.Observe(time.Since(start).Seconds(), req.Method, endpoint)
+
+	if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+		RestRequestErrors.Inc(req.Method, endpoint)
+	}
+	return resp, err
+}
@@ -0,0 +1,320 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets límites de los histogramas de latencia, en segundos
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// collector familia de métricas que sabe escribirse en formato de texto de Prometheus
+type collector interface {
+	write(w io.Writer)
+}
+
+// Registry conjunto de métricas expuestas en /metrics
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []collector
+}
+
+// DefaultRegistry registro usado por las métricas del servidor
+var DefaultRegistry = &Registry{}
+
+func (r *Registry) register(c collector) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.collectors = append(r.collectors, c)
+}
+
+// ServeHTTP escribe todas las métricas en formato de texto de Prometheus
+func (r *Registry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	r.mu.RLock()
+	collectors := make([]collector, len(r.collectors))
+	copy(collectors, r.collectors)
+	r.mu.RUnlock()
+
+	for _, c := range collectors {
+		c.write(w)
+	}
+}
+
+// Handler devuelve el handler HTTP del registro por defecto
+func Handler() http.Handler {
+	return DefaultRegistry
+}
+
+// family datos comunes de una familia de métricas con etiquetas
+type family struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (f *family) header(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+}
+
+// labelKey une los valores de etiquetas en una clave de mapa
+func labelKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+// formatLabels arma {a="x",b="y"} con los valores escapados
+func formatLabels(names, values []string, extra ...string) string {
+	if len(names) == 0 && len(extra) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(names)+len(extra)/2)
+	for i, name := range names {
+		parts = append(parts, name+`="`+escapeLabel(values[i])+`"`)
+	}
+	for i := 0; i+1 < len(extra); i += 2 {
+		parts = append(parts, extra[i]+`="`+escapeLabel(extra[i+1])+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func escapeLabel(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	return strings.ReplaceAll(value, `"`, `\"`)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// sortedKeys devuelve las claves de un mapa ordenadas para una salida estable
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ===================== Counter =====================
+
+// CounterVec contador con etiquetas
+type CounterVec struct {
+	family
+	mu     sync.RWMutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labels []string
+	mu     sync.Mutex
+	value  float64
+}
+
+// NewCounterVec registra un contador con las etiquetas indicadas
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{
+		family: family{name: name, help: help, kind: "counter", labels: labels},
+		values: make(map[string]*counterValue),
+	}
+	DefaultRegistry.register(c)
+	return c
+}
+
+// Inc incrementa en 1 el contador de los valores de etiqueta
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add suma delta al contador de los valores de etiqueta
+func (c *CounterVec) Add(delta float64, labelValues ...string) {
+	key := labelKey(labelValues)
+
+	c.mu.RLock()
+	v, ok := c.values[key]
+	c.mu.RUnlock()
+
+	if !ok {
+		c.mu.Lock()
+		if v, ok = c.values[key]; !ok {
+			v = &counterValue{labels: append([]string(nil), labelValues...)}
+			c.values[key] = v
+		}
+		c.mu.Unlock()
+	}
+
+	v.mu.Lock()
+	v.value += delta
+	v.mu.Unlock()
+}
+
+func (c *CounterVec) write(w io.Writer) {
+	c.header(w)
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, key := range sortedKeys(c.values) {
+		v := c.values[key]
+		v.mu.Lock()
+		fmt.Fprintf(w, "%s%s %s\n", c.name, formatLabels(c.labels, v.labels), formatFloat(v.value))
+		v.mu.Unlock()
+	}
+}
+
+// ===================== Gauge =====================
+
+// GaugeVec medidor con etiquetas
+type GaugeVec struct {
+	family
+	mu     sync.RWMutex
+	values map[string]*gaugeValue
+}
+
+type gaugeValue struct {
+	labels []string
+	value  float64
+}
+
+// NewGaugeVec registra un medidor con las etiquetas indicadas
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{
+		family: family{name: name, help: help, kind: "gauge", labels: labels},
+		values: make(map[string]*gaugeValue),
+	}
+	DefaultRegistry.register(g)
+	return g
+}
+
+// Set fija el valor del medidor para los valores de etiqueta
+func (g *GaugeVec) Set(value float64, labelValues ...string) {
+	key := labelKey(labelValues)
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if v, ok := g.values[key]; ok {
+		v.value = value
+		return
+	}
+	g.values[key] = &gaugeValue{labels: append([]string(nil), labelValues...), value: value}
+}
+
+func (g *GaugeVec) write(w io.Writer) {
+	g.header(w)
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	for _, key := range sortedKeys(g.values) {
+		v := g.values[key]
+		fmt.Fprintf(w, "%s%s %s\n", g.name, formatLabels(g.labels, v.labels), formatFloat(v.value))
+	}
+}
+
+// GaugeFunc medidor sin etiquetas cuyo valor se calcula al momento de exponerlo
+type GaugeFunc struct {
+	family
+	fn func() float64
+}
+
+// NewGaugeFunc registra un medidor calculado por fn
+func NewGaugeFunc(name, help string, fn func() float64) *GaugeFunc {
+	g := &GaugeFunc{family: family{name: name, help: help, kind: "gauge"}, fn: fn}
+	DefaultRegistry.register(g)
+	return g
+}
+
+func (g *GaugeFunc) write(w io.Writer) {
+	g.header(w)
+	fmt.Fprintf(w, "%s %s\n", g.name, formatFloat(g.fn()))
+}
+
+// ===================== Histogram =====================
+
+// HistogramVec histograma con etiquetas
+type HistogramVec struct {
+	family
+	buckets []float64
+	mu      sync.RWMutex
+	values  map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labels []string
+	mu     sync.Mutex
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogramVec registra un histograma con los límites y etiquetas indicados
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{
+		family:  family{name: name, help: help, kind: "histogram", labels: labels},
+		buckets: buckets,
+		values:  make(map[string]*histogramValue),
+	}
+	DefaultRegistry.register(h)
+	return h
+}
+
+// Observe registra una observación para los valores de etiqueta
+func (h *HistogramVec) Observe(value float64, labelValues ...string) {
+	key := labelKey(labelValues)
+
+	h.mu.RLock()
+	v, ok := h.values[key]
+	h.mu.RUnlock()
+
+	if !ok {
+		h.mu.Lock()
+		if v, ok = h.values[key]; !ok {
+			v = &histogramValue{
+				labels: append([]string(nil), labelValues...),
+				counts: make([]uint64, len(h.buckets)),
+			}
+			h.values[key] = v
+		}
+		h.mu.Unlock()
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for i, bound := range h.buckets {
+		if value <= bound {
+			v.counts[i]++
+		}
+	}
+	v.count++
+	v.sum += value
+}
+
+func (h *HistogramVec) write(w io.Writer) {
+	h.header(w)
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for _, key := range sortedKeys(h.values) {
+		v := h.values[key]
+		v.mu.Lock()
+		for i, bound := range h.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, v.labels, "le", formatFloat(bound)), v.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", h.name, formatLabels(h.labels, v.labels, "le", "+Inf"), v.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", h.name, formatLabels(h.labels, v.labels), formatFloat(v.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", h.name, formatLabels(h.labels, v.labels), v.count)
+		v.mu.Unlock()
+	}
+}
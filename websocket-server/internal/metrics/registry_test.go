@@ -0,0 +1,61 @@
+package metrics
+
+import (
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "reescribir los archivos golden de testdata")
+
+func TestRegistryGolden(t *testing.T) {
+	contador := NewCounterVec("prueba_mensajes_total", "Mensajes de prueba.", "tipo", "origen")
+	contador.Inc("get_dashboard", "ws")
+	contador.Add(2, "get_dashboard", "ws")
+	contador.Inc(`con "comillas"`, `barra\invertida`)
+	contador.Inc("salto\nde línea", "ws")
+
+	medidor := NewGaugeVec("prueba_ocupacion_ratio", "Ocupación de prueba por sección.", "seccion")
+	medidor.Set(0.5, "B")
+	medidor.Set(0.25, "A")
+	medidor.Set(1, "A")
+
+	calculado := NewGaugeFunc("prueba_clientes", "Clientes de prueba.", func() float64 { return 3 })
+
+	histograma := NewHistogramVec("prueba_duracion_seconds", "Duración de prueba.", []float64{0.1, 0.5, 1}, "evento")
+	histograma.Observe(0.05, "espacio_ocupado")
+	histograma.Observe(0.5, "espacio_ocupado")
+	histograma.Observe(3, "espacio_ocupado")
+	histograma.Observe(0.2, "dashboard_delta")
+
+	sinEtiquetas := NewHistogramVec("prueba_vacio_seconds", "Histograma sin etiquetas.", []float64{1})
+	sinEtiquetas.Observe(2)
+
+	registro := &Registry{}
+	for _, c := range []collector{contador, medidor, calculado, histograma, sinEtiquetas} {
+		registro.register(c)
+	}
+
+	rec := httptest.NewRecorder()
+	registro.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if ct := rec.Header().Get("Content-Type"); ct != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	golden := filepath.Join("testdata", "registry.golden")
+	if *update {
+		if err := os.WriteFile(golden, rec.Body.Bytes(), 0o644); err != nil {
+			t.Fatalf("escribiendo %s: %v", golden, err)
+		}
+	}
+	esperado, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatalf("leyendo %s: %v", golden, err)
+	}
+	if got := rec.Body.String(); got != string(esperado) {
+		t.Errorf("salida de /metrics distinta de %s:\n--- obtenida\n%s\n--- esperada\n%s", golden, got, esperado)
+	}
+}
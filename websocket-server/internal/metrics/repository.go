@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/repository/interfaces"
+)
+
+// dashboardRepository decora un DashboardRepository midiendo cada consulta
+type dashboardRepository struct {
+	next interfaces.DashboardRepository
+}
+
+// InstrumentDashboardRepository envuelve repo para registrar latencia y errores por consulta
+func InstrumentDashboardRepository(repo interfaces.DashboardRepository) interfaces.DashboardRepository {
+	return &dashboardRepository{next: repo}
+}
+
+func (r *dashboardRepository) GetEspaciosStats(ctx context.Context) (disponibles, ocupados, total int, err error) {
+	defer func(start time.Time) { ObserveQuery("espacios_stats", start, err) }(time.Now())
+	return r.next.GetEspaciosStats(ctx)
+}
+
+func (r *dashboardRepository) GetDineroRecaudadoHoy(ctx context.Context) (total float64, err error) {
+	defer func(start time.Time) { ObserveQuery("dinero_recaudado_hoy", start, err) }(time.Now())
+	return r.next.GetDineroRecaudadoHoy(ctx)
+}
+
+func (r *dashboardRepository) GetDineroRecaudadoMes(ctx context.Context) (total float64, err error) {
+	defer func(start time.Time) { ObserveQuery("dinero_recaudado_mes", start, err) }(time.Now())
+	return r.next.GetDineroRecaudadoMes(ctx)
+}
+
+func (r *dashboardRepository) GetVehiculosActivos(ctx context.Context) (count int, err error) {
+	defer func(start time.Time) { ObserveQuery("vehiculos_activos", start, err) }(time.Now())
+	return r.next.GetVehiculosActivos(ctx)
+}
+
+func (r *dashboardRepository) GetEspaciosPorSeccion(ctx context.Context) (secciones []models.EspaciosPorSeccion, err error) {
+	defer func(start time.Time) { ObserveQuery("espacios_por_seccion", start, err) }(time.Now())
+	return r.next.GetEspaciosPorSeccion(ctx)
+}
+
+func (r *dashboardRepository) GetEspaciosDisponibles(ctx context.Context) (espacios []models.EspacioDetalle, err error) {
+	defer func(start time.Time) { ObserveQuery("espacios_disponibles", start, err) }(time.Now())
+	return r.next.GetEspaciosDisponibles(ctx)
+}
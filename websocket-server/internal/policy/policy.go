@@ -0,0 +1,117 @@
+// Package policy define qué ve cada rol en las respuestas del servidor. La comparten
+// el WebSocket y la API JSON para que un "user" reciba lo mismo por ambos canales:
+// sin ingresos ni datos de vehículos o multas de terceros.
+package policy
+
+import (
+	"encoding/json"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/auth"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+var (
+	// RolesTodos roles emitidos por el auth-service
+	RolesTodos = []string{auth.RoleAdmin, auth.RoleOperator, auth.RoleUser}
+
+	// RolesPersonal roles del personal del estacionamiento
+	RolesPersonal = []string{auth.RoleAdmin, auth.RoleOperator}
+)
+
+// CamposFinancieros campos de ingresos que solo ve el personal (admin / operator)
+var CamposFinancieros = []string{"dinero_recaudado_hoy", "dinero_recaudado_mes", "monto_pagado", "monto_multas_pendientes"}
+
+// CamposVehiculo datos de vehículos de terceros que solo ve el personal
+var CamposVehiculo = []string{"vehiculo_placa", "hora_ingreso"}
+
+// CamposMulta detalle de multas de terceros que solo ve el personal
+var CamposMulta = []string{"vehiculo_id", "descripcion", "monto_multa", "multas_pendientes"}
+
+// IsStaff indica si los claims son de admin u operador. Sin autenticación (claims nil)
+// se envía todo.
+func IsStaff(claims *auth.Claims) bool {
+	return claims == nil || claims.HasRole(RolesPersonal...)
+}
+
+// RedactDashboard quita los ingresos del dashboard para quien no es personal
+func RedactDashboard(claims *auth.Claims, data *models.DashboardData) interface{} {
+	if IsStaff(claims) || data == nil {
+		return data
+	}
+	return WithoutFields(data, CamposFinancieros...)
+}
+
+// RedactDashboardDelta quita los ingresos del delta manteniendo la versión, para que
+// el cliente no pierda la secuencia aunque no reciba ningún campo visible
+func RedactDashboardDelta(claims *auth.Claims, delta *models.DashboardDelta) *models.DashboardDelta {
+	if IsStaff(claims) || delta == nil {
+		return delta
+	}
+
+	redacted := *delta
+	redacted.Changes = make(map[string]interface{}, len(delta.Changes))
+	for key, value := range delta.Changes {
+		redacted.Changes[key] = value
+	}
+	for _, key := range CamposFinancieros {
+		delete(redacted.Changes, key)
+	}
+	return &redacted
+}
+
+// RedactSecciones quita placas, horas de ingreso y cobro acumulado de los espacios ocupados
+func RedactSecciones(claims *auth.Claims, secciones []models.EspaciosPorSeccion) []models.EspaciosPorSeccion {
+	if IsStaff(claims) {
+		return secciones
+	}
+
+	redacted := make([]models.EspaciosPorSeccion, len(secciones))
+	for i, seccion := range secciones {
+		redacted[i] = seccion
+		redacted[i].Espacios = make([]models.EspacioDetalle, len(seccion.Espacios))
+		for j, espacio := range seccion.Espacios {
+			espacio.VehiculoPlaca = nil
+			espacio.HoraIngreso = nil
+			espacio.MinutosTranscurridos = nil
+			espacio.MontoAcumulado = nil
+			redacted[i].Espacios[j] = espacio
+		}
+	}
+	return redacted
+}
+
+// RedactEvento quita placa y monto de los eventos de espacios para quien no es personal
+func RedactEvento(claims *auth.Claims, event interface{}) interface{} {
+	if IsStaff(claims) {
+		return event
+	}
+	campos := append(append([]string{}, CamposVehiculo...), CamposFinancieros...)
+	return WithoutFields(event, campos...)
+}
+
+// RedactMulta quita vehículo, detalle y montos de los eventos de multas para quien
+// no es personal
+func RedactMulta(claims *auth.Claims, event *models.MultaEvent) interface{} {
+	if IsStaff(claims) {
+		return event
+	}
+	campos := append(append([]string{}, CamposVehiculo...), CamposMulta...)
+	return WithoutFields(event, append(campos, CamposFinancieros...)...)
+}
+
+// WithoutFields serializa un valor a mapa JSON y elimina las claves indicadas
+func WithoutFields(value interface{}, keys ...string) interface{} {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil
+	}
+	for _, key := range keys {
+		delete(fields, key)
+	}
+	return fields
+}
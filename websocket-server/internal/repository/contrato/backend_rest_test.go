@@ -0,0 +1,189 @@
+package contrato
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// BackendREST backend NestJS de prueba que sirve el estado con los endpoints básicos
+// (sin el módulo /dashboard, que responde 404) y cuenta las peticiones por ruta
+type BackendREST struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	peticiones map[string]int
+}
+
+// NuevoBackendREST levanta el backend con el estado; se cierra al terminar el test
+func NuevoBackendREST(t *testing.T, estado *Estado) *BackendREST {
+	t.Helper()
+
+	b := &BackendREST{peticiones: make(map[string]int)}
+	mux := http.NewServeMux()
+	servir := func(path string, respuesta func(r *http.Request) (int, interface{})) {
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			b.mu.Lock()
+			b.peticiones[path]++
+			b.mu.Unlock()
+
+			status, body := respuesta(r)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(body)
+		})
+	}
+	lista := func(body interface{}) func(*http.Request) (int, interface{}) {
+		return func(*http.Request) (int, interface{}) { return http.StatusOK, body }
+	}
+
+	servir("/espacios", lista(espaciosJSON(estado)))
+	servir("/secciones/with-espacios", lista(seccionesJSON(estado)))
+	servir("/tickets", lista(ticketsJSON(estado)))
+	servir("/vehiculos", lista(vehiculosJSON(estado)))
+	servir("/tipo-vehiculo", lista(tiposJSON(estado)))
+	servir("/tipo-tarifa", lista(tarifasJSON(estado)))
+	servir("/multas", lista(multasJSON(estado)))
+	servir("/detalle-pago", lista(detallesPagoJSON(estado)))
+	servir("/pagos", lista(pagosJSON(estado)))
+	servir("/user-portal/perfil", func(r *http.Request) (int, interface{}) {
+		return perfilJSON(estado, r.Header.Get("x-auth-user-id"))
+	})
+
+	b.Server = httptest.NewServer(mux)
+	t.Cleanup(b.Close)
+	return b
+}
+
+// Peticiones cantidad de peticiones recibidas en path
+func (b *BackendREST) Peticiones(path string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.peticiones[path]
+}
+
+// objeto cuerpo JSON de un recurso del backend (camelCase)
+type objeto = map[string]interface{}
+
+func espacioJSON(estado *Estado, espacio Espacio) objeto {
+	return objeto{"id": espacio.ID, "numero": espacio.Numero, "estado": estado.Disponible(espacio.ID)}
+}
+
+func espaciosJSON(estado *Estado) []objeto {
+	espacios := []objeto{}
+	for _, seccion := range estado.Secciones {
+		for _, espacio := range seccion.Espacios {
+			espacios = append(espacios, espacioJSON(estado, espacio))
+		}
+	}
+	return espacios
+}
+
+func seccionesJSON(estado *Estado) []objeto {
+	secciones := []objeto{}
+	for _, seccion := range estado.Secciones {
+		espacios := []objeto{}
+		for _, espacio := range seccion.Espacios {
+			espacios = append(espacios, espacioJSON(estado, espacio))
+		}
+		secciones = append(secciones, objeto{"id": seccion.ID, "letraSeccion": seccion.Letra, "espacios": espacios})
+	}
+	return secciones
+}
+
+func ticketsJSON(estado *Estado) []objeto {
+	tickets := []objeto{}
+	for _, t := range estado.Tickets {
+		ticket := objeto{
+			"id":            t.ID,
+			"fechaIngreso":  t.Ingreso,
+			"fechaSalida":   t.Salida,
+			"vehiculoId":    t.VehiculoID,
+			"espacioId":     t.EspacioID,
+			"detallePagoId": nil,
+		}
+		if t.DetallePagoID != "" {
+			ticket["detallePagoId"] = t.DetallePagoID
+		}
+		tickets = append(tickets, ticket)
+	}
+	return tickets
+}
+
+func vehiculosJSON(estado *Estado) []objeto {
+	vehiculos := []objeto{}
+	for _, v := range estado.Vehiculos {
+		vehiculos = append(vehiculos, objeto{"id": v.ID, "placa": v.Placa, "marca": v.Marca, "modelo": v.Modelo, "tipoVehiculoId": v.TipoID})
+	}
+	return vehiculos
+}
+
+func tiposJSON(estado *Estado) []objeto {
+	tipos := []objeto{}
+	for _, tipo := range estado.Tipos {
+		tipos = append(tipos, objeto{"id": tipo.ID, "categoria": tipo.Categoria, "tipoTarifaId": tipo.TarifaID})
+	}
+	return tipos
+}
+
+func tarifasJSON(estado *Estado) []objeto {
+	tarifas := []objeto{}
+	for _, t := range estado.Tarifas {
+		tarifas = append(tarifas, objeto{"id": t.ID, "tipoTarifa": t.Nombre, "precioHora": t.PrecioHora, "precioDia": t.PrecioDia})
+	}
+	return tarifas
+}
+
+func multasJSON(estado *Estado) []objeto {
+	multas := []objeto{}
+	for _, m := range estado.Multas {
+		multas = append(multas, objeto{"id": m.ID, "montoTotal": m.Monto, "vehiculoId": m.VehiculoID, "estado": m.Estado})
+	}
+	return multas
+}
+
+// detallesPagoJSON pagos con la fecha como la serializa NestJS (ISO en UTC con milisegundos)
+func detallesPagoJSON(estado *Estado) []objeto {
+	detalles := []objeto{}
+	for _, pago := range estado.Pagos {
+		detalle := objeto{
+			"id":        pago.ID,
+			"metodo":    pago.Metodo,
+			"fechaPago": pago.Fecha.UTC().Format("2006-01-02T15:04:05.000Z"),
+			"pagoTotal": pago.Total,
+			"pagoId":    pago.PagoID,
+		}
+		for _, ticket := range estado.Tickets {
+			if ticket.DetallePagoID == pago.ID {
+				detalle["ticketId"] = ticket.ID
+			}
+		}
+		detalles = append(detalles, detalle)
+	}
+	return detalles
+}
+
+func pagosJSON(estado *Estado) []objeto {
+	pagos := []objeto{}
+	for _, pago := range estado.Pagos {
+		pagos = append(pagos, objeto{"id": pago.PagoID, "tipoTarifaId": pago.TarifaID})
+	}
+	return pagos
+}
+
+// perfilJSON perfil del portal de usuario con los vehículos de la cuenta; 404 si la
+// cuenta no está vinculada a ningún cliente
+func perfilJSON(estado *Estado, authUserID string) (int, interface{}) {
+	vehiculos := []objeto{}
+	for _, v := range estado.Vehiculos {
+		if authUserID != "" && v.AuthUserID == authUserID {
+			vehiculos = append(vehiculos, objeto{"id": v.ID, "placa": v.Placa})
+		}
+	}
+	if len(vehiculos) == 0 {
+		return http.StatusNotFound, objeto{"message": "Cliente no encontrado"}
+	}
+	return http.StatusOK, objeto{"authUserId": authUserID, "vehiculos": vehiculos}
+}
@@ -0,0 +1,288 @@
+// Package contrato suite común que deben cumplir todas las implementaciones de
+// interfaces.DataSource: el estado del estacionamiento sobre el que se ejecuta, los
+// resultados esperados y un backend REST de prueba que sirve ese estado. Solo tiene
+// archivos _test.go (no entra en el binario) y corre la suite contra el cliente REST,
+// la fuente PostgreSQL sobre sqlmock y el failover entre ambas.
+package contrato
+
+import (
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/tarifa"
+)
+
+// Tarifa fila de tipo_tarifa
+type Tarifa struct {
+	ID         string
+	Nombre     string
+	PrecioHora float64
+	PrecioDia  float64
+}
+
+// TipoVehiculo fila de tipo_vehiculo con su tarifa
+type TipoVehiculo struct {
+	ID        string
+	Categoria string
+	TarifaID  string
+}
+
+// Vehiculo fila de vehiculo con el usuario del auth-service de su cliente ("" = sin cuenta)
+type Vehiculo struct {
+	ID         string
+	Placa      string
+	Marca      string
+	Modelo     string
+	TipoID     string
+	AuthUserID string
+}
+
+// Espacio fila de espacio; está ocupado si tiene un ticket abierto
+type Espacio struct {
+	ID     string
+	Numero string
+}
+
+// Seccion fila de seccion con sus espacios
+type Seccion struct {
+	ID       string
+	Letra    string
+	Espacios []Espacio
+}
+
+// Ticket fila de ticket. Salida nil = abierto; DetallePagoID "" = sin pagar.
+type Ticket struct {
+	ID            string
+	VehiculoID    string
+	EspacioID     string
+	Ingreso       time.Time
+	Salida        *time.Time
+	DetallePagoID string
+}
+
+// Multa fila de multa
+type Multa struct {
+	ID         string
+	VehiculoID string
+	Estado     string
+	Monto      float64
+}
+
+// Pago fila de detalle_pago con su pago y la tarifa aplicada
+type Pago struct {
+	ID       string
+	PagoID   string
+	TarifaID string
+	Metodo   string
+	Fecha    time.Time
+	Total    float64
+}
+
+// Estado contenido de la base de datos (o del backend) sobre el que corre la suite.
+// Las fechas son relativas a Ahora para que los totales de hoy y del mes no dependan
+// del día en que se ejecuta.
+type Estado struct {
+	Ahora     time.Time
+	Tarifas   []Tarifa
+	Tipos     []TipoVehiculo
+	Vehiculos []Vehiculo
+	Secciones []Seccion
+	Tickets   []Ticket
+	Multas    []Multa
+	Pagos     []Pago
+}
+
+// NuevoEstado estado de la suite: dos secciones con dos espacios cada una, dos tickets
+// abiertos (t1 y t2) y tres cerrados, multas pendientes de v1 y v2 y tres pagos, dos
+// de ellos de hoy. Los resultados esperados de Run dependen de estos datos.
+func NuevoEstado() *Estado {
+	ahora := time.Now().Truncate(time.Second)
+	hace := func(d time.Duration) time.Time { return ahora.Add(-d) }
+	salida := func(d time.Duration) *time.Time {
+		fecha := hace(d)
+		return &fecha
+	}
+
+	return &Estado{
+		Ahora: ahora,
+		Tarifas: []Tarifa{
+			{ID: "tt1", Nombre: "Normal", PrecioHora: 1.5, PrecioDia: 10},
+			{ID: "tt2", Nombre: "Moto", PrecioHora: 0.5, PrecioDia: 4},
+		},
+		Tipos: []TipoVehiculo{
+			{ID: "tv1", Categoria: "Auto", TarifaID: "tt1"},
+			{ID: "tv2", Categoria: "Moto", TarifaID: "tt2"},
+		},
+		Vehiculos: []Vehiculo{
+			{ID: "v1", Placa: "ABC-123", Marca: "Toyota", Modelo: "Yaris", TipoID: "tv1", AuthUserID: "auth-u1"},
+			{ID: "v2", Placa: "XYZ-9", Marca: "Honda", Modelo: "CB190", TipoID: "tv2", AuthUserID: "auth-u2"},
+			{ID: "v3", Placa: "ABC-999", Marca: "Kia", Modelo: "Rio", TipoID: "tv1", AuthUserID: "auth-u1"},
+			{ID: "v4", Placa: "1ABC-123", Marca: "Mazda", Modelo: "3", TipoID: "tv1"},
+		},
+		Secciones: []Seccion{
+			{ID: "sA", Letra: "A", Espacios: []Espacio{{ID: "eA1", Numero: "A-01"}, {ID: "eA2", Numero: "A-02"}}},
+			{ID: "sB", Letra: "B", Espacios: []Espacio{{ID: "eB1", Numero: "B-01"}, {ID: "eB2", Numero: "B-02"}}},
+		},
+		Tickets: []Ticket{
+			{ID: "t1", VehiculoID: "v1", EspacioID: "eA2", Ingreso: hace(90 * time.Minute)},
+			{ID: "t2", VehiculoID: "v2", EspacioID: "eB1", Ingreso: hace(30 * time.Minute)},
+			{ID: "t3", VehiculoID: "v1", EspacioID: "eA1", Ingreso: hace(26 * time.Hour), Salida: salida(24 * time.Hour), DetallePagoID: "dp1"},
+			{ID: "t4", VehiculoID: "v4", EspacioID: "eB2", Ingreso: hace(50 * time.Hour), Salida: salida(47 * time.Hour)},
+			{ID: "t5", VehiculoID: "v1", EspacioID: "eB1", Ingreso: hace(72 * time.Hour), Salida: salida(71 * time.Hour), DetallePagoID: "dp2"},
+		},
+		Multas: []Multa{
+			{ID: "m1", VehiculoID: "v1", Estado: models.MultaPendiente, Monto: 20},
+			{ID: "m2", VehiculoID: "v1", Estado: models.MultaPagada, Monto: 50},
+			{ID: "m3", VehiculoID: "v2", Estado: models.MultaPendiente, Monto: 15},
+			{ID: "m4", VehiculoID: "v4", Estado: models.MultaAnulada, Monto: 30},
+		},
+		Pagos: []Pago{
+			{ID: "dp1", PagoID: "p1", TarifaID: "tt1", Metodo: "efectivo", Fecha: ahora, Total: 12},
+			{ID: "dp2", PagoID: "p2", TarifaID: "tt1", Metodo: "tarjeta", Fecha: hace(40 * 24 * time.Hour), Total: 8},
+			{ID: "dp3", PagoID: "p3", TarifaID: "tt2", Metodo: "tarjeta", Fecha: ahora, Total: 5},
+		},
+	}
+}
+
+// Disponible indica si el espacio no tiene un ticket abierto
+func (e *Estado) Disponible(espacioID string) bool {
+	return e.TicketAbierto(espacioID) == nil
+}
+
+// TicketAbierto ticket sin salida del espacio, o nil
+func (e *Estado) TicketAbierto(espacioID string) *Ticket {
+	for i, ticket := range e.Tickets {
+		if ticket.EspacioID == espacioID && ticket.Salida == nil {
+			return &e.Tickets[i]
+		}
+	}
+	return nil
+}
+
+// Vehiculo vehículo con el ID indicado
+func (e *Estado) Vehiculo(id string) (Vehiculo, bool) {
+	for _, vehiculo := range e.Vehiculos {
+		if vehiculo.ID == id {
+			return vehiculo, true
+		}
+	}
+	return Vehiculo{}, false
+}
+
+// Categoria categoría del tipo de vehículo
+func (e *Estado) Categoria(tipoID string) string {
+	for _, tipo := range e.Tipos {
+		if tipo.ID == tipoID {
+			return tipo.Categoria
+		}
+	}
+	return ""
+}
+
+// Tarifa tarifa del tipo de vehículo, o nil si no tiene
+func (e *Estado) Tarifa(tipoID string) *Tarifa {
+	for _, tipo := range e.Tipos {
+		if tipo.ID != tipoID {
+			continue
+		}
+		for i, t := range e.Tarifas {
+			if t.ID == tipo.TarifaID {
+				return &e.Tarifas[i]
+			}
+		}
+	}
+	return nil
+}
+
+// NombreTarifa nombre de la tarifa con el ID indicado
+func (e *Estado) NombreTarifa(id string) string {
+	for _, t := range e.Tarifas {
+		if t.ID == id {
+			return t.Nombre
+		}
+	}
+	return ""
+}
+
+// Ubicacion número del espacio y letra de su sección
+func (e *Estado) Ubicacion(espacioID string) (numero, letra string) {
+	for _, seccion := range e.Secciones {
+		for _, espacio := range seccion.Espacios {
+			if espacio.ID == espacioID {
+				return espacio.Numero, seccion.Letra
+			}
+		}
+	}
+	return "", ""
+}
+
+// Detalle ticket con la vista enriquecida de la base de datos (vehículo, tarifa,
+// espacio y sección), sin multas
+func (e *Estado) Detalle(t Ticket) models.Ticket {
+	ticket := models.Ticket{
+		ID:           t.ID,
+		FechaIngreso: t.Ingreso,
+		FechaSalida:  t.Salida,
+		VehiculoID:   t.VehiculoID,
+		EspacioID:    t.EspacioID,
+	}
+	if t.DetallePagoID != "" {
+		detalle := t.DetallePagoID
+		ticket.DetallePagoID = &detalle
+	}
+	if vehiculo, ok := e.Vehiculo(t.VehiculoID); ok {
+		ticket.VehiculoPlaca = vehiculo.Placa
+		ticket.VehiculoMarca = vehiculo.Marca
+		ticket.VehiculoModelo = vehiculo.Modelo
+		ticket.VehiculoCategoria = e.Categoria(vehiculo.TipoID)
+		if t := e.Tarifa(vehiculo.TipoID); t != nil {
+			ticket.Tarifa = &tarifa.Tarifa{PrecioHora: t.PrecioHora, PrecioDia: t.PrecioDia}
+		}
+	}
+	ticket.EspacioNumero, ticket.SeccionLetra = e.Ubicacion(t.EspacioID)
+	return ticket
+}
+
+// TicketsDetalle todos los tickets con la vista enriquecida
+func (e *Estado) TicketsDetalle() []models.Ticket {
+	tickets := make([]models.Ticket, 0, len(e.Tickets))
+	for _, t := range e.Tickets {
+		tickets = append(tickets, e.Detalle(t))
+	}
+	return tickets
+}
+
+// PendientesPorVehiculo cantidad y monto de las multas pendientes de cada vehículo
+func (e *Estado) PendientesPorVehiculo() map[string]models.ResumenMultas {
+	pendientes := make(map[string]models.ResumenMultas)
+	for _, multa := range e.Multas {
+		if multa.Estado != models.MultaPendiente {
+			continue
+		}
+		resumen := pendientes[multa.VehiculoID]
+		resumen.Cantidad++
+		resumen.Monto += multa.Monto
+		pendientes[multa.VehiculoID] = resumen
+	}
+	return pendientes
+}
+
+// PagosDetallados pagos con la tarifa y la sección por las que se desglosan
+func (e *Estado) PagosDetallados() []models.PagoDetallado {
+	pagos := make([]models.PagoDetallado, 0, len(e.Pagos))
+	for _, pago := range e.Pagos {
+		detallado := models.PagoDetallado{
+			Metodo: pago.Metodo,
+			Fecha:  pago.Fecha,
+			Total:  pago.Total,
+			Tarifa: e.NombreTarifa(pago.TarifaID),
+		}
+		for _, ticket := range e.Tickets {
+			if ticket.DetallePagoID == pago.ID {
+				_, detallado.Seccion = e.Ubicacion(ticket.EspacioID)
+			}
+		}
+		pagos = append(pagos, detallado)
+	}
+	return pagos
+}
@@ -0,0 +1,74 @@
+package contrato
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/client"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/repository/interfaces"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/service/dashboard"
+)
+
+// fuenteCaida fuente que falla en todas las consultas
+type fuenteCaida struct{}
+
+var errCaida = errors.New("conexión rechazada")
+
+func (fuenteCaida) Name() string { return "database" }
+
+func (fuenteCaida) GetDashboardData(context.Context) (*models.DashboardData, error) {
+	return nil, errCaida
+}
+
+func (fuenteCaida) GetEspaciosPorSeccion(context.Context) ([]models.EspaciosPorSeccion, error) {
+	return nil, errCaida
+}
+
+func (fuenteCaida) GetEspaciosDisponibles(context.Context) ([]models.EspacioDetalle, error) {
+	return nil, errCaida
+}
+
+func (fuenteCaida) GetTicketsActivos(context.Context) ([]models.Ticket, error) {
+	return nil, errCaida
+}
+
+func (fuenteCaida) GetTicketsActivosByAuthUser(context.Context, string) ([]models.Ticket, error) {
+	return nil, errCaida
+}
+
+func (fuenteCaida) GetDesgloseIngresos(context.Context, time.Time, time.Time) (*models.DesgloseIngresos, error) {
+	return nil, errCaida
+}
+
+func (fuenteCaida) GetFlujoHorario(context.Context, time.Time, time.Time) ([]models.FlujoHora, error) {
+	return nil, errCaida
+}
+
+func (fuenteCaida) BuscarPlaca(context.Context, string, int, int) ([]models.ResultadoPlaca, error) {
+	return nil, errCaida
+}
+
+func (fuenteCaida) BuscarTickets(context.Context, models.FiltroBusquedaTickets, *models.CursorTickets, int, time.Time) ([]models.Ticket, error) {
+	return nil, errCaida
+}
+
+func TestFailoverSourceContrato(t *testing.T) {
+	estado := NuevoEstado()
+	rest := func(t *testing.T) interfaces.DataSource {
+		return client.NewRestClient(NuevoBackendREST(t, estado).URL, time.Minute)
+	}
+
+	t.Run("primaria caída", func(t *testing.T) {
+		Run(t, estado, func(t *testing.T, _ Caso) interfaces.DataSource {
+			return dashboard.NewFailoverSource(fuenteCaida{}, rest(t))
+		})
+	})
+	t.Run("primaria sana", func(t *testing.T) {
+		Run(t, estado, func(t *testing.T, _ Caso) interfaces.DataSource {
+			return dashboard.NewFailoverSource(rest(t), fuenteCaida{})
+		})
+	})
+}
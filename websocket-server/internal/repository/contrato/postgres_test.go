@@ -0,0 +1,335 @@
+package contrato
+
+import (
+	"database/sql/driver"
+	"sort"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/repository/interfaces"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/repository/postgres"
+)
+
+// TestPostgresSourceContrato corre el contrato contra la fuente PostgreSQL sobre
+// sqlmock. Las filas se calculan en Go a partir del estado (con las mismas funciones
+// de paginación y agregación del modelo), así que solo se comprueba el escaneo y el
+// armado de las respuestas, no el SQL: el WHERE/ORDER de BuscarTickets lo cubre
+// TestBuscarTicketsSQL en el paquete postgres.
+func TestPostgresSourceContrato(t *testing.T) {
+	estado := NuevoEstado()
+	Run(t, estado, func(t *testing.T, caso Caso) interfaces.DataSource {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("sqlmock: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		// Cada consulta se reconoce por su SQL y sus argumentos, no por el orden
+		mock.MatchExpectationsInOrder(false)
+		prepararBase(mock, estado, caso)
+
+		return postgres.NewSource(postgres.NewDashboardRepository(db), postgres.NewTicketRepository(db), postgres.NewVehiculoRepository(db), postgres.NewIngresosRepository(db), postgres.NewMultaRepository(db))
+	})
+}
+
+// columnasTicket columnas de la vista de ticket de postgres (selectTicketDetalle)
+var columnasTicket = []string{
+	"id", "fechaIngreso", "fechaSalida", "vehiculoId", "espacioId", "detallePagoId",
+	"placa", "marca", "modelo", "categoria", "numero", "letra_seccion", "precio_hora", "precio_dia",
+}
+
+// prepararBase responde las consultas de los repositorios con lo que devolvería
+// PostgreSQL sobre el estado. Las consultas se reconocen por un fragmento del SQL: el
+// resto de la consulta no se comprueba.
+func prepararBase(mock sqlmock.Sqlmock, estado *Estado, caso Caso) {
+	prepararDashboard(mock, estado)
+	prepararEspacios(mock, estado)
+	prepararTickets(mock, estado)
+
+	pendientes := estado.PendientesPorVehiculo()
+	var cantidad int
+	var monto float64
+	porVehiculo := sqlmock.NewRows([]string{"vehiculoId", "count", "sum"})
+	for _, vehiculoID := range clavesOrdenadas(pendientes) {
+		cantidad += pendientes[vehiculoID].Cantidad
+		monto += pendientes[vehiculoID].Monto
+		porVehiculo.AddRow(vehiculoID, pendientes[vehiculoID].Cantidad, pendientes[vehiculoID].Monto)
+	}
+	mock.ExpectQuery(`SELECT COUNT\(\*\), COALESCE\(SUM\(monto_total\), 0\) FROM multa WHERE estado = \$1$`).
+		WithArgs(models.MultaPendiente).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "sum"}).AddRow(cantidad, monto))
+	mock.ExpectQuery(`GROUP BY "vehiculoId"`).
+		WithArgs(models.MultaPendiente, sqlmock.AnyArg()).
+		WillReturnRows(porVehiculo)
+
+	if !caso.Desde.IsZero() {
+		prepararDesglose(mock, estado, caso.Desde, caso.Hasta)
+		prepararFlujo(mock, estado, caso.Desde, caso.Hasta)
+	}
+
+	if caso.Placa != nil {
+		prepararPlaca(mock, estado, *caso.Placa)
+	}
+
+	if b := caso.Tickets; b != nil {
+		// La página se calcula en Go: el SQL de la búsqueda no se ejecuta
+		pagina := models.PaginarTickets(estado.TicketsDetalle(), b.Filtro, b.Despues, b.Limite, estado.Ahora)
+		mock.ExpectQuery(`ORDER BY t\."fecha(Ingreso|Salida)" DESC, t\.id::text COLLATE "C" DESC LIMIT \$\d+$`).
+			WillReturnRows(filasTickets(pagina))
+	}
+}
+
+// prepararDashboard estadísticas de espacios, recaudación del día y del mes (en la
+// zona horaria local, como CURRENT_DATE) y vehículos activos
+func prepararDashboard(mock sqlmock.Sqlmock, estado *Estado) {
+	disponibles, total := 0, 0
+	for _, seccion := range estado.Secciones {
+		for _, espacio := range seccion.Espacios {
+			total++
+			if estado.Disponible(espacio.ID) {
+				disponibles++
+			}
+		}
+	}
+	mock.ExpectQuery(`COUNT\(\*\) FILTER`).
+		WillReturnRows(sqlmock.NewRows([]string{"disponibles", "ocupados", "total"}).AddRow(disponibles, total-disponibles, total))
+
+	ahora := time.Now()
+	var hoy, mes float64
+	for _, pago := range estado.Pagos {
+		fecha := pago.Fecha.In(ahora.Location())
+		if fecha.Year() == ahora.Year() && fecha.Month() == ahora.Month() {
+			mes += pago.Total
+			if fecha.Day() == ahora.Day() {
+				hoy += pago.Total
+			}
+		}
+	}
+	mock.ExpectQuery(`DATE\(dp\.fecha_pago\) = CURRENT_DATE`).
+		WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(hoy))
+	mock.ExpectQuery(`DATE_TRUNC\('month', dp\.fecha_pago\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"total"}).AddRow(mes))
+
+	activos := 0
+	for _, ticket := range estado.Tickets {
+		if ticket.Salida == nil {
+			activos++
+		}
+	}
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM ticket WHERE "fechaSalida" IS NULL`).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(activos))
+}
+
+// prepararEspacios secciones, espacios de cada sección con el vehículo estacionado y
+// espacios disponibles, ordenados como en las consultas
+func prepararEspacios(mock sqlmock.Sqlmock, estado *Estado) {
+	secciones := append([]Seccion(nil), estado.Secciones...)
+	sort.Slice(secciones, func(i, j int) bool { return secciones[i].Letra < secciones[j].Letra })
+
+	filasSecciones := sqlmock.NewRows([]string{"id", "letra_seccion"})
+	type disponible struct{ id, numero, letra string }
+	var disponibles []disponible
+	for _, seccion := range secciones {
+		filasSecciones.AddRow(seccion.ID, seccion.Letra)
+
+		espacios := append([]Espacio(nil), seccion.Espacios...)
+		sort.Slice(espacios, func(i, j int) bool { return espacios[i].Numero < espacios[j].Numero })
+		filas := sqlmock.NewRows([]string{"id", "numero", "estado", "placa", "fechaIngreso", "precio_hora", "precio_dia"})
+		for _, espacio := range espacios {
+			abierto := estado.TicketAbierto(espacio.ID)
+			if abierto == nil {
+				filas.AddRow(espacio.ID, espacio.Numero, true, nil, nil, nil, nil)
+				disponibles = append(disponibles, disponible{espacio.ID, espacio.Numero, seccion.Letra})
+				continue
+			}
+			detalle := estado.Detalle(*abierto)
+			precioHora, precioDia := precios(detalle)
+			filas.AddRow(espacio.ID, espacio.Numero, false, detalle.VehiculoPlaca, abierto.Ingreso, precioHora, precioDia)
+		}
+		mock.ExpectQuery(`WHERE e\."seccionId" = \$1`).WithArgs(seccion.ID).WillReturnRows(filas)
+	}
+	mock.ExpectQuery(`SELECT DISTINCT s\.id, s\.letra_seccion FROM seccion s`).WillReturnRows(filasSecciones)
+
+	sort.Slice(disponibles, func(i, j int) bool { return disponibles[i].numero < disponibles[j].numero })
+	filasDisponibles := sqlmock.NewRows([]string{"id", "numero", "estado", "letra_seccion"})
+	for _, d := range disponibles {
+		filasDisponibles.AddRow(d.id, d.numero, true, d.letra)
+	}
+	mock.ExpectQuery(`WHERE e\.estado = true`).WillReturnRows(filasDisponibles)
+}
+
+// prepararTickets tickets activos, de todos y de cada usuario del auth-service
+func prepararTickets(mock sqlmock.Sqlmock, estado *Estado) {
+	activos := abiertos(estado, func(Vehiculo) bool { return true })
+	mock.ExpectQuery(`WHERE t\."fechaSalida" IS NULL ORDER BY t\."fechaIngreso" DESC$`).
+		WillReturnRows(filasTickets(activos))
+
+	usuarios := map[string]bool{}
+	for _, vehiculo := range estado.Vehiculos {
+		if vehiculo.AuthUserID == "" || usuarios[vehiculo.AuthUserID] {
+			continue
+		}
+		usuarios[vehiculo.AuthUserID] = true
+		authUserID := vehiculo.AuthUserID
+		tickets := abiertos(estado, func(v Vehiculo) bool { return v.AuthUserID == authUserID })
+		mock.ExpectQuery(`c\.auth_user_id = \$1`).WithArgs(authUserID).WillReturnRows(filasTickets(tickets))
+	}
+	// Cuentas sin cliente vinculado
+	mock.ExpectQuery(`c\.auth_user_id = \$1`).WithArgs(sqlmock.AnyArg()).WillReturnRows(filasTickets(nil))
+}
+
+// abiertos tickets sin salida de los vehículos que cumplen incluir, del más reciente
+// al más antiguo
+func abiertos(estado *Estado, incluir func(Vehiculo) bool) []models.Ticket {
+	var tickets []models.Ticket
+	for _, ticket := range estado.TicketsDetalle() {
+		vehiculo, _ := estado.Vehiculo(ticket.VehiculoID)
+		if ticket.FechaSalida == nil && incluir(vehiculo) {
+			tickets = append(tickets, ticket)
+		}
+	}
+	ordenarPorIngreso(tickets)
+	return tickets
+}
+
+// prepararDesglose grupos de GROUPING SETS de los pagos de [desde, hasta), calculados
+// con NewDesgloseIngresos (no se ejecuta la agregación SQL)
+func prepararDesglose(mock sqlmock.Sqlmock, estado *Estado, desde, hasta time.Time) {
+	desglose := models.NewDesgloseIngresos(desde, hasta, estado.PagosDetallados())
+
+	// Las horas vienen como hora Unix, del inicio de la hora del pago
+	porHora := map[int64]*models.IngresosHora{}
+	var horas []int64
+	for _, pago := range estado.PagosDetallados() {
+		if pago.Fecha.Before(desde) || !pago.Fecha.Before(hasta) {
+			continue
+		}
+		horaUnix := pago.Fecha.Unix() / 3600
+		if porHora[horaUnix] == nil {
+			porHora[horaUnix] = &models.IngresosHora{}
+			horas = append(horas, horaUnix)
+		}
+		porHora[horaUnix].Total += pago.Total
+		porHora[horaUnix].Pagos++
+	}
+
+	filas := sqlmock.NewRows([]string{"grupo", "clave", "sum", "count"})
+	for _, horaUnix := range horas {
+		filas.AddRow("hora", strconv.FormatInt(horaUnix, 10), porHora[horaUnix].Total, porHora[horaUnix].Pagos)
+	}
+	for _, grupos := range []struct {
+		nombre string
+		grupos []models.IngresosGrupo
+	}{{"metodo", desglose.PorMetodo}, {"seccion", desglose.PorSeccion}, {"tarifa", desglose.PorTarifa}} {
+		for _, g := range grupos.grupos {
+			filas.AddRow(grupos.nombre, g.Clave, g.Total, g.Pagos)
+		}
+	}
+	filas.AddRow("total", "", desglose.Total, desglose.Pagos)
+
+	mock.ExpectQuery(`GROUPING SETS`).
+		WithArgs(desde, hasta, models.SinTarifa, models.SinSeccion).
+		WillReturnRows(filas)
+}
+
+// prepararFlujo ingresos y salidas de [desde, hasta) por sección y hora, calculados con
+// AgruparFlujo (no se ejecuta la agregación SQL)
+func prepararFlujo(mock sqlmock.Sqlmock, estado *Estado, desde, hasta time.Time) {
+	movimientos := make([]models.MovimientoTicket, 0, len(estado.Tickets))
+	for _, ticket := range estado.TicketsDetalle() {
+		movimientos = append(movimientos, models.MovimientoTicket{
+			SeccionLetra: ticket.SeccionLetra,
+			FechaIngreso: ticket.FechaIngreso,
+			FechaSalida:  ticket.FechaSalida,
+		})
+	}
+
+	filas := sqlmock.NewRows([]string{"letra_seccion", "hora", "ingresos", "salidas"})
+	for _, f := range models.AgruparFlujo(movimientos, desde, hasta) {
+		filas.AddRow(f.SeccionLetra, f.Hora, f.Ingresos, f.Salidas)
+	}
+	mock.ExpectQuery(`UNION ALL`).WithArgs(desde, hasta).WillReturnRows(filas)
+}
+
+// prepararPlaca vehículos encontrados por placa y sus últimos visitas+1 tickets
+func prepararPlaca(mock sqlmock.Sqlmock, estado *Estado, busqueda BusquedaPlaca) {
+	var encontrados []models.ResultadoPlaca
+	for _, v := range estado.Vehiculos {
+		if models.CoincidePlaca(v.Placa, busqueda.Placa) {
+			encontrados = append(encontrados, models.ResultadoPlaca{VehiculoID: v.ID, Placa: v.Placa})
+		}
+	}
+	models.OrdenarResultadosPlaca(encontrados, busqueda.Placa)
+	if len(encontrados) > busqueda.Limite {
+		encontrados = encontrados[:busqueda.Limite]
+	}
+
+	filas := sqlmock.NewRows([]string{"id", "placa", "marca", "modelo", "categoria"})
+	var ultimos []models.Ticket
+	for _, encontrado := range encontrados {
+		v, _ := estado.Vehiculo(encontrado.VehiculoID)
+		filas.AddRow(v.ID, v.Placa, v.Marca, v.Modelo, estado.Categoria(v.TipoID))
+
+		var tickets []models.Ticket
+		for _, ticket := range estado.TicketsDetalle() {
+			if ticket.VehiculoID == v.ID {
+				tickets = append(tickets, ticket)
+			}
+		}
+		ordenarPorIngreso(tickets)
+		if len(tickets) > busqueda.Visitas+1 {
+			tickets = tickets[:busqueda.Visitas+1]
+		}
+		ultimos = append(ultimos, tickets...)
+	}
+	ordenarPorIngreso(ultimos)
+
+	mock.ExpectQuery(`FROM vehiculo v`).WithArgs(busqueda.Placa, busqueda.Limite).WillReturnRows(filas)
+	mock.ExpectQuery(`ROW_NUMBER\(\)`).WithArgs(sqlmock.AnyArg(), busqueda.Visitas+1).WillReturnRows(filasTickets(ultimos))
+}
+
+// filasTickets filas de la vista de ticket de postgres
+func filasTickets(tickets []models.Ticket) *sqlmock.Rows {
+	filas := sqlmock.NewRows(columnasTicket)
+	for _, ticket := range tickets {
+		var salida, detallePago driver.Value
+		if ticket.FechaSalida != nil {
+			salida = *ticket.FechaSalida
+		}
+		if ticket.DetallePagoID != nil {
+			detallePago = *ticket.DetallePagoID
+		}
+		precioHora, precioDia := precios(ticket)
+		filas.AddRow(ticket.ID, ticket.FechaIngreso, salida, ticket.VehiculoID, ticket.EspacioID, detallePago,
+			ticket.VehiculoPlaca, ticket.VehiculoMarca, ticket.VehiculoModelo, ticket.VehiculoCategoria,
+			ticket.EspacioNumero, ticket.SeccionLetra, precioHora, precioDia)
+	}
+	return filas
+}
+
+// precios columnas precio_hora y precio_dia de la tarifa del ticket (NULL sin tarifa)
+func precios(ticket models.Ticket) (driver.Value, driver.Value) {
+	if ticket.Tarifa == nil {
+		return nil, nil
+	}
+	return ticket.Tarifa.PrecioHora, ticket.Tarifa.PrecioDia
+}
+
+// ordenarPorIngreso del ticket más reciente al más antiguo
+func ordenarPorIngreso(tickets []models.Ticket) {
+	sort.SliceStable(tickets, func(i, j int) bool { return tickets[i].FechaIngreso.After(tickets[j].FechaIngreso) })
+}
+
+// clavesOrdenadas vehículos del mapa en orden, para filas deterministas
+func clavesOrdenadas(porVehiculo map[string]models.ResumenMultas) []string {
+	claves := make([]string, 0, len(porVehiculo))
+	for clave := range porVehiculo {
+		claves = append(claves, clave)
+	}
+	sort.Strings(claves)
+	return claves
+}
@@ -0,0 +1,39 @@
+package contrato
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/client"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/repository/interfaces"
+)
+
+func TestRestClientContrato(t *testing.T) {
+	estado := NuevoEstado()
+	Run(t, estado, func(t *testing.T, _ Caso) interfaces.DataSource {
+		return client.NewRestClient(NuevoBackendREST(t, estado).URL, time.Minute)
+	})
+}
+
+func TestRestClientReutilizaCatalogoYMultas(t *testing.T) {
+	estado := NuevoEstado()
+	backend := NuevoBackendREST(t, estado)
+	rest := client.NewRestClient(backend.URL, time.Minute)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := rest.GetDashboardData(ctx); err != nil {
+			t.Fatalf("GetDashboardData: %v", err)
+		}
+		if _, err := rest.GetTicketsActivos(ctx); err != nil {
+			t.Fatalf("GetTicketsActivos: %v", err)
+		}
+	}
+
+	for _, path := range []string{"/multas", "/vehiculos", "/tipo-vehiculo", "/tipo-tarifa"} {
+		if n := backend.Peticiones(path); n != 1 {
+			t.Errorf("%s consultado %d veces, se esperaba 1", path, n)
+		}
+	}
+}
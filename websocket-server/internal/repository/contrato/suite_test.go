@@ -0,0 +1,465 @@
+package contrato
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/repository/interfaces"
+)
+
+// BusquedaPlaca parámetros de un caso de BuscarPlaca
+type BusquedaPlaca struct {
+	Placa   string
+	Limite  int
+	Visitas int
+}
+
+// BusquedaTickets parámetros de un caso de BuscarTickets (con ahora = Estado.Ahora)
+type BusquedaTickets struct {
+	Filtro  models.FiltroBusquedaTickets
+	Despues *models.CursorTickets
+	Limite  int
+}
+
+// Caso consulta que la suite hará a la fuente recién creada. Las fuentes con
+// respuestas preparadas (sqlmock) lo usan para saber qué filas devolver.
+type Caso struct {
+	Nombre  string
+	Desde   time.Time        // rango de GetDesgloseIngresos y GetFlujoHorario
+	Hasta   time.Time        // (exclusivo)
+	Placa   *BusquedaPlaca   // solo en los casos de BuscarPlaca
+	Tickets *BusquedaTickets // solo en los casos de BuscarTickets
+}
+
+// Run ejecuta la suite contra las fuentes que crea nueva, una por caso, que deben
+// servir el estado de NuevoEstado
+func Run(t *testing.T, estado *Estado, nueva func(t *testing.T, caso Caso) interfaces.DataSource) {
+	ctx := context.Background()
+	caso := func(c Caso, prueba func(t *testing.T, source interfaces.DataSource)) {
+		t.Run(c.Nombre, func(t *testing.T) {
+			prueba(t, nueva(t, c))
+		})
+	}
+
+	caso(Caso{Nombre: "GetDashboardData"}, func(t *testing.T, source interfaces.DataSource) {
+		data, err := source.GetDashboardData(ctx)
+		if err != nil {
+			t.Fatalf("GetDashboardData: %v", err)
+		}
+		esperado := models.DashboardData{
+			EspaciosDisponibles:   2,
+			EspaciosOcupados:      2,
+			TotalEspacios:         4,
+			DineroRecaudadoHoy:    17,
+			DineroRecaudadoMes:    17,
+			VehiculosActivos:      2,
+			MultasPendientes:      2,
+			MontoMultasPendientes: 35,
+		}
+		obtenido := *data
+		obtenido.Timestamp = time.Time{}
+		if obtenido != esperado {
+			t.Errorf("GetDashboardData = %+v, se esperaba %+v", obtenido, esperado)
+		}
+		if data.Timestamp.IsZero() {
+			t.Error("GetDashboardData sin timestamp")
+		}
+	})
+
+	caso(Caso{Nombre: "GetEspaciosPorSeccion"}, func(t *testing.T, source interfaces.DataSource) {
+		secciones, err := source.GetEspaciosPorSeccion(ctx)
+		if err != nil {
+			t.Fatalf("GetEspaciosPorSeccion: %v", err)
+		}
+		if len(secciones) != len(estado.Secciones) {
+			t.Fatalf("GetEspaciosPorSeccion devolvió %d secciones, se esperaban %d", len(secciones), len(estado.Secciones))
+		}
+		for _, seccion := range secciones {
+			if seccion.TotalEspacios != 2 || seccion.EspaciosDisponibles != 1 || seccion.EspaciosOcupados != 1 {
+				t.Errorf("sección %s: total %d, disponibles %d, ocupados %d; se esperaba 2, 1 y 1",
+					seccion.SeccionLetra, seccion.TotalEspacios, seccion.EspaciosDisponibles, seccion.EspaciosOcupados)
+			}
+			if len(seccion.Espacios) != seccion.TotalEspacios {
+				t.Errorf("sección %s con %d espacios, se esperaban %d", seccion.SeccionLetra, len(seccion.Espacios), seccion.TotalEspacios)
+			}
+			for _, espacio := range seccion.Espacios {
+				compararEspacio(t, estado, espacio, seccion.SeccionLetra)
+			}
+		}
+	})
+
+	caso(Caso{Nombre: "GetEspaciosDisponibles"}, func(t *testing.T, source interfaces.DataSource) {
+		espacios, err := source.GetEspaciosDisponibles(ctx)
+		if err != nil {
+			t.Fatalf("GetEspaciosDisponibles: %v", err)
+		}
+		// Ordenados por número
+		esperados := []struct{ id, numero, letra string }{{"eA1", "A-01", "A"}, {"eB2", "B-02", "B"}}
+		if len(espacios) != len(esperados) {
+			t.Fatalf("GetEspaciosDisponibles = %+v, se esperaban %+v", espacios, esperados)
+		}
+		for i, e := range esperados {
+			espacio := espacios[i]
+			if espacio.ID != e.id || espacio.Numero != e.numero || espacio.SeccionLetra != e.letra || !espacio.Estado {
+				t.Errorf("espacio %d = %+v, se esperaba %+v disponible", i, espacio, e)
+			}
+		}
+	})
+
+	caso(Caso{Nombre: "GetTicketsActivos"}, func(t *testing.T, source interfaces.DataSource) {
+		tickets, err := source.GetTicketsActivos(ctx)
+		if err != nil {
+			t.Fatalf("GetTicketsActivos: %v", err)
+		}
+		compararTicketsActivos(t, estado, tickets, "t1", "t2")
+	})
+
+	caso(Caso{Nombre: "GetTicketsActivosByAuthUser"}, func(t *testing.T, source interfaces.DataSource) {
+		// v3 también es de auth-u1 pero no está estacionado
+		tickets, err := source.GetTicketsActivosByAuthUser(ctx, "auth-u1")
+		if err != nil {
+			t.Fatalf("GetTicketsActivosByAuthUser: %v", err)
+		}
+		compararTicketsActivos(t, estado, tickets, "t1")
+
+		tickets, err = source.GetTicketsActivosByAuthUser(ctx, "auth-sin-cliente")
+		if err != nil {
+			t.Fatalf("GetTicketsActivosByAuthUser sin cliente: %v", err)
+		}
+		if len(tickets) != 0 {
+			t.Errorf("GetTicketsActivosByAuthUser sin cliente = %v, se esperaba vacío", idsTickets(tickets))
+		}
+	})
+
+	inicioPagos, finPagos := estado.Ahora.AddDate(0, 0, -60), estado.Ahora.Add(time.Hour)
+	caso(Caso{Nombre: "GetDesgloseIngresos", Desde: inicioPagos, Hasta: finPagos}, func(t *testing.T, source interfaces.DataSource) {
+		desglose, err := source.GetDesgloseIngresos(ctx, inicioPagos, finPagos)
+		if err != nil {
+			t.Fatalf("GetDesgloseIngresos: %v", err)
+		}
+		if !desglose.Desde.Equal(inicioPagos) || !desglose.Hasta.Equal(finPagos) {
+			t.Errorf("rango [%v, %v), se esperaba [%v, %v)", desglose.Desde, desglose.Hasta, inicioPagos, finPagos)
+		}
+		if desglose.Total != 25 || desglose.Pagos != 3 {
+			t.Errorf("total %v en %d pagos, se esperaba 25 en 3", desglose.Total, desglose.Pagos)
+		}
+		compararGrupos(t, "método", desglose.PorMetodo, []models.IngresosGrupo{{Clave: "tarjeta", Total: 13, Pagos: 2}, {Clave: "efectivo", Total: 12, Pagos: 1}})
+		compararGrupos(t, "tarifa", desglose.PorTarifa, []models.IngresosGrupo{{Clave: "Normal", Total: 20, Pagos: 2}, {Clave: "Moto", Total: 5, Pagos: 1}})
+		compararGrupos(t, "sección", desglose.PorSeccion, []models.IngresosGrupo{
+			{Clave: "A", Total: 12, Pagos: 1},
+			{Clave: "B", Total: 8, Pagos: 1},
+			{Clave: models.SinSeccion, Total: 5, Pagos: 1},
+		})
+
+		// Todas las fuentes toman la hora del día con la misma regla (models.HoraDelDia)
+		if len(desglose.PorHora) != 24 {
+			t.Fatalf("%d horas, se esperaban 24", len(desglose.PorHora))
+		}
+		esperadas := models.HorasVacias()
+		for _, pago := range estado.PagosDetallados() {
+			hora := &esperadas[models.HoraDelDia(pago.Fecha.Unix()/3600)]
+			hora.Total += pago.Total
+			hora.Pagos++
+		}
+		var total float64
+		pagos := 0
+		for i, hora := range desglose.PorHora {
+			if hora != esperadas[i] {
+				t.Errorf("hora %d = %+v, se esperaba %+v", i, hora, esperadas[i])
+			}
+			total += hora.Total
+			pagos += hora.Pagos
+		}
+		if total != 25 || pagos != 3 {
+			t.Errorf("las horas suman %v en %d pagos, se esperaba 25 en 3", total, pagos)
+		}
+	})
+
+	inicioFlujo, finFlujo := estado.Ahora.Add(-80*time.Hour), estado.Ahora.Add(time.Hour)
+	caso(Caso{Nombre: "GetFlujoHorario", Desde: inicioFlujo, Hasta: finFlujo}, func(t *testing.T, source interfaces.DataSource) {
+		flujos, err := source.GetFlujoHorario(ctx, inicioFlujo, finFlujo)
+		if err != nil {
+			t.Fatalf("GetFlujoHorario: %v", err)
+		}
+
+		type movimientos struct{ ingresos, salidas int }
+		porSeccion := map[string]movimientos{}
+		for _, f := range flujos {
+			if !f.Hora.Equal(f.Hora.Truncate(time.Hour)) || f.Hora.Before(inicioFlujo.Truncate(time.Hour)) || !f.Hora.Before(finFlujo) {
+				t.Errorf("hora %v fuera de [%v, %v) o no truncada a la hora", f.Hora, inicioFlujo, finFlujo)
+			}
+			m := porSeccion[f.SeccionLetra]
+			m.ingresos += f.Ingresos
+			m.salidas += f.Salidas
+			porSeccion[f.SeccionLetra] = m
+		}
+		esperado := map[string]movimientos{"A": {2, 1}, "B": {3, 2}}
+		if len(porSeccion) != len(esperado) || porSeccion["A"] != esperado["A"] || porSeccion["B"] != esperado["B"] {
+			t.Errorf("flujo por sección = %+v, se esperaba %+v", porSeccion, esperado)
+		}
+	})
+
+	for _, c := range casosPlaca {
+		c := c
+		caso(Caso{Nombre: "BuscarPlaca/" + c.nombre, Placa: &c.busqueda}, func(t *testing.T, source interfaces.DataSource) {
+			resultados, err := source.BuscarPlaca(ctx, c.busqueda.Placa, c.busqueda.Limite, c.busqueda.Visitas)
+			if err != nil {
+				t.Fatalf("BuscarPlaca: %v", err)
+			}
+			compararResultadosPlaca(t, estado, resultados, c.esperados)
+		})
+	}
+
+	for _, c := range casosTickets(estado) {
+		c := c
+		caso(Caso{Nombre: "BuscarTickets/" + c.nombre, Tickets: &c.busqueda}, func(t *testing.T, source interfaces.DataSource) {
+			tickets, err := source.BuscarTickets(ctx, c.busqueda.Filtro, c.busqueda.Despues, c.busqueda.Limite, estado.Ahora)
+			if err != nil {
+				t.Fatalf("BuscarTickets: %v", err)
+			}
+			if ids := idsTickets(tickets); !iguales(ids, c.esperados) {
+				t.Fatalf("BuscarTickets = %v, se esperaba %v", ids, c.esperados)
+			}
+			for _, ticket := range tickets {
+				esperado := estado.Detalle(estado.ticket(ticket.ID))
+				if ticket.VehiculoPlaca != esperado.VehiculoPlaca || ticket.SeccionLetra != esperado.SeccionLetra || ticket.EspacioNumero != esperado.EspacioNumero {
+					t.Errorf("ticket %s en %s %s con placa %q, se esperaba %s %s con %q", ticket.ID,
+						ticket.SeccionLetra, ticket.EspacioNumero, ticket.VehiculoPlaca,
+						esperado.SeccionLetra, esperado.EspacioNumero, esperado.VehiculoPlaca)
+				}
+			}
+		})
+	}
+}
+
+// resultadoPlaca resultado esperado de BuscarPlaca
+type resultadoPlaca struct {
+	placa   string
+	activo  string // ID del ticket abierto ("" = no estacionado)
+	multas  models.ResumenMultas
+	visitas []string
+}
+
+var casosPlaca = []struct {
+	nombre    string
+	busqueda  BusquedaPlaca
+	esperados []resultadoPlaca
+}{
+	{"parcial ordenada por placa", BusquedaPlaca{Placa: "ABC", Limite: 10, Visitas: 1}, []resultadoPlaca{
+		{placa: "1ABC-123", visitas: []string{"t4"}},
+		{placa: "ABC-123", activo: "t1", multas: models.ResumenMultas{Cantidad: 1, Monto: 20}, visitas: []string{"t3"}},
+		{placa: "ABC-999", visitas: []string{}},
+	}},
+	{"exacta primero", BusquedaPlaca{Placa: "ABC123", Limite: 1, Visitas: 5}, []resultadoPlaca{
+		{placa: "ABC-123", activo: "t1", multas: models.ResumenMultas{Cantidad: 1, Monto: 20}, visitas: []string{"t3", "t5"}},
+	}},
+	{"sin resultados", BusquedaPlaca{Placa: "ZZZ", Limite: 10, Visitas: 1}, nil},
+}
+
+// casosTickets búsquedas de tickets y los IDs esperados en orden
+func casosTickets(estado *Estado) []struct {
+	nombre    string
+	busqueda  BusquedaTickets
+	esperados []string
+} {
+	t1 := estado.ticket("t1")
+	desde, hasta := estado.Ahora.Add(-73*time.Hour), estado.Ahora.Add(-26*time.Hour)
+	noPagado := false
+	minimo, maximo := 120, 180
+
+	return []struct {
+		nombre    string
+		busqueda  BusquedaTickets
+		esperados []string
+	}{
+		{"primera página", BusquedaTickets{Filtro: models.FiltroBusquedaTickets{Campo: models.CampoIngreso}, Limite: 2}, []string{"t2", "t1"}},
+		{"página siguiente", BusquedaTickets{
+			Filtro:  models.FiltroBusquedaTickets{Campo: models.CampoIngreso},
+			Despues: &models.CursorTickets{Fecha: t1.Ingreso, ID: t1.ID},
+			Limite:  2,
+		}, []string{"t3", "t4"}},
+		{"salidas de una sección", BusquedaTickets{Filtro: models.FiltroBusquedaTickets{Campo: models.CampoSalida, Seccion: "B"}, Limite: 10}, []string{"t4", "t5"}},
+		{"sin pagar por placa", BusquedaTickets{Filtro: models.FiltroBusquedaTickets{Campo: models.CampoIngreso, Placa: "ABC", Pagado: &noPagado}, Limite: 10}, []string{"t1", "t4"}},
+		{"por duración", BusquedaTickets{Filtro: models.FiltroBusquedaTickets{Campo: models.CampoIngreso, MinMinutos: &minimo, MaxMinutos: &maximo}, Limite: 10}, []string{"t3", "t4"}},
+		// Hasta es exclusivo: t3 ingresó exactamente en hasta
+		{"rango de ingreso", BusquedaTickets{Filtro: models.FiltroBusquedaTickets{Campo: models.CampoIngreso, Desde: &desde, Hasta: &hasta}, Limite: 10}, []string{"t4", "t5"}},
+	}
+}
+
+// ticket ticket del estado con el ID indicado
+func (e *Estado) ticket(id string) Ticket {
+	for _, ticket := range e.Tickets {
+		if ticket.ID == id {
+			return ticket
+		}
+	}
+	return Ticket{}
+}
+
+// compararEspacio compara un espacio de GetEspaciosPorSeccion con el estado. Placa,
+// hora de ingreso y tarifa son opcionales (el REST API sin módulo dashboard no las
+// tiene), pero si vienen deben ser las del ticket abierto.
+func compararEspacio(t *testing.T, estado *Estado, espacio models.EspacioDetalle, letra string) {
+	t.Helper()
+
+	numero, letraEsperada := estado.Ubicacion(espacio.ID)
+	if numero == "" {
+		t.Errorf("espacio desconocido %+v", espacio)
+		return
+	}
+	if espacio.Numero != numero || espacio.SeccionLetra != letraEsperada || letra != letraEsperada {
+		t.Errorf("espacio %s: número %q en %q (grupo %q), se esperaba %q en %q", espacio.ID, espacio.Numero, espacio.SeccionLetra, letra, numero, letraEsperada)
+	}
+
+	abierto := estado.TicketAbierto(espacio.ID)
+	if espacio.Estado != (abierto == nil) {
+		t.Errorf("espacio %s con estado %v, se esperaba %v", espacio.ID, espacio.Estado, abierto == nil)
+	}
+	if abierto == nil {
+		if espacio.VehiculoPlaca != nil || espacio.HoraIngreso != nil {
+			t.Errorf("espacio libre %s con vehículo", espacio.ID)
+		}
+		return
+	}
+
+	detalle := estado.Detalle(*abierto)
+	if espacio.VehiculoPlaca != nil && *espacio.VehiculoPlaca != detalle.VehiculoPlaca {
+		t.Errorf("espacio %s con placa %q, se esperaba %q", espacio.ID, *espacio.VehiculoPlaca, detalle.VehiculoPlaca)
+	}
+	if espacio.HoraIngreso != nil {
+		hora, err := time.Parse(time.RFC3339, *espacio.HoraIngreso)
+		if err != nil || !hora.Equal(abierto.Ingreso) {
+			t.Errorf("espacio %s con hora de ingreso %q, se esperaba %v", espacio.ID, *espacio.HoraIngreso, abierto.Ingreso)
+		}
+	}
+	if espacio.Tarifa != nil && *espacio.Tarifa != *detalle.Tarifa {
+		t.Errorf("espacio %s con tarifa %+v, se esperaba %+v", espacio.ID, *espacio.Tarifa, *detalle.Tarifa)
+	}
+}
+
+// compararTicketsActivos compara, sin importar el orden, los tickets activos con la
+// vista enriquecida del estado y las multas pendientes de su vehículo
+func compararTicketsActivos(t *testing.T, estado *Estado, tickets []models.Ticket, ids ...string) {
+	t.Helper()
+
+	if len(tickets) != len(ids) {
+		t.Fatalf("tickets %v, se esperaban %v", idsTickets(tickets), ids)
+	}
+	pendientes := estado.PendientesPorVehiculo()
+	porID := make(map[string]models.Ticket, len(tickets))
+	for _, ticket := range tickets {
+		porID[ticket.ID] = ticket
+	}
+
+	for _, id := range ids {
+		ticket, ok := porID[id]
+		if !ok {
+			t.Errorf("falta el ticket %s en %v", id, idsTickets(tickets))
+			continue
+		}
+		esperado := estado.Detalle(estado.ticket(id))
+		esperado.MultasPendientes = pendientes[esperado.VehiculoID].Cantidad
+		esperado.MontoMultasPendientes = pendientes[esperado.VehiculoID].Monto
+
+		if ticket.FechaSalida != nil || !ticket.FechaIngreso.Equal(esperado.FechaIngreso) {
+			t.Errorf("ticket %s ingresó %v con salida %v, se esperaba %v sin salida", id, ticket.FechaIngreso, ticket.FechaSalida, esperado.FechaIngreso)
+		}
+		if ticket.Tarifa == nil || *ticket.Tarifa != *esperado.Tarifa {
+			t.Errorf("ticket %s con tarifa %v, se esperaba %+v", id, ticket.Tarifa, *esperado.Tarifa)
+		}
+
+		// Solo los campos que deben coincidir en todas las fuentes
+		ticket.FechaIngreso, esperado.FechaIngreso = time.Time{}, time.Time{}
+		ticket.Tarifa, esperado.Tarifa = nil, nil
+		ticket.MinutosTranscurridos, ticket.MontoAcumulado = nil, nil
+		if ticket != esperado {
+			t.Errorf("ticket %s = %+v, se esperaba %+v", id, ticket, esperado)
+		}
+	}
+}
+
+// compararGrupos compara un desglose de ingresos, en orden
+func compararGrupos(t *testing.T, nombre string, grupos, esperados []models.IngresosGrupo) {
+	t.Helper()
+
+	if len(grupos) != len(esperados) {
+		t.Errorf("por %s = %+v, se esperaba %+v", nombre, grupos, esperados)
+		return
+	}
+	for i := range esperados {
+		if grupos[i] != esperados[i] {
+			t.Errorf("por %s = %+v, se esperaba %+v", nombre, grupos, esperados)
+			return
+		}
+	}
+}
+
+// compararResultadosPlaca compara los vehículos encontrados, en orden
+func compararResultadosPlaca(t *testing.T, estado *Estado, resultados []models.ResultadoPlaca, esperados []resultadoPlaca) {
+	t.Helper()
+
+	if len(resultados) != len(esperados) {
+		placas := make([]string, 0, len(resultados))
+		for _, r := range resultados {
+			placas = append(placas, r.Placa)
+		}
+		t.Fatalf("BuscarPlaca devolvió %v, se esperaban %d vehículos", placas, len(esperados))
+	}
+	for i, esperado := range esperados {
+		r := resultados[i]
+		var vehiculo Vehiculo
+		for _, v := range estado.Vehiculos {
+			if v.Placa == esperado.placa {
+				vehiculo = v
+			}
+		}
+		if r.Placa != esperado.placa || r.VehiculoID != vehiculo.ID || r.Marca != vehiculo.Marca ||
+			r.Modelo != vehiculo.Modelo || r.Categoria != estado.Categoria(vehiculo.TipoID) {
+			t.Errorf("resultado %d = %+v, se esperaba el vehículo %+v", i, r, vehiculo)
+		}
+
+		if esperado.activo == "" {
+			if r.Estacionado || r.TicketActivo != nil {
+				t.Errorf("%s estacionado, se esperaba que no", r.Placa)
+			}
+		} else if !r.Estacionado || r.TicketActivo == nil || r.TicketActivo.ID != esperado.activo {
+			t.Errorf("%s con ticket activo %v, se esperaba %s", r.Placa, r.TicketActivo, esperado.activo)
+		} else {
+			activo := r.TicketActivo
+			if activo.MultasPendientes != esperado.multas.Cantidad || activo.MontoMultasPendientes != esperado.multas.Monto {
+				t.Errorf("%s con %d multas por %v, se esperaban %+v", r.Placa, activo.MultasPendientes, activo.MontoMultasPendientes, esperado.multas)
+			}
+			if activo.EspacioNumero == "" || activo.SeccionLetra == "" {
+				t.Errorf("%s: ticket activo sin espacio ni sección", r.Placa)
+			}
+		}
+
+		if ids := idsTickets(r.Visitas); r.Visitas == nil || !iguales(ids, esperado.visitas) {
+			t.Errorf("%s con visitas %v, se esperaban %v", r.Placa, ids, esperado.visitas)
+		}
+	}
+}
+
+// idsTickets IDs de los tickets en orden
+func idsTickets(tickets []models.Ticket) []string {
+	ids := make([]string, 0, len(tickets))
+	for _, ticket := range tickets {
+		ids = append(ids, ticket.ID)
+	}
+	return ids
+}
+
+// iguales compara dos listas de IDs en orden
+func iguales(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
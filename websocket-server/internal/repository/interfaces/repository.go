@@ -2,6 +2,9 @@ package interfaces
 
 import (
 	"context"
+	"errors"
+	"time"
+
 	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
 )
 
@@ -20,21 +23,121 @@ type DashboardRepository interface {
 	// GetEspaciosPorSeccion obtiene todos los espacios agrupados por sección
 	GetEspaciosPorSeccion(ctx context.Context) ([]models.EspaciosPorSeccion, error)
 
-	// GetEspaciosDisponibles obtiene lista de espacios disponibles
-	GetEspaciosDisponibles(ctx context.Context) ([]models.Espacio, error)
+	// GetEspaciosDisponibles obtiene lista de espacios disponibles con la letra de su sección
+	GetEspaciosDisponibles(ctx context.Context) ([]models.EspacioDetalle, error)
+}
+
+// DataSource fuente de datos del dashboard. La implementan PostgreSQL (repositorios)
+// y el REST API, y ambas deben devolver los mismos datos para el mismo estado:
+//   - GetEspaciosDisponibles solo incluye espacios con estado = true y su SeccionLetra
+//   - GetTicketsActivos solo incluye tickets sin FechaSalida, con placa, marca, modelo,
+//     categoría y tarifa del vehículo, número de espacio y letra de sección
+//   - GetEspaciosPorSeccion incluye contadores y todos los espacios de cada sección
+type DataSource interface {
+	// Name identifica la fuente en logs
+	Name() string
+
+	GetDashboardData(ctx context.Context) (*models.DashboardData, error)
+	GetEspaciosPorSeccion(ctx context.Context) ([]models.EspaciosPorSeccion, error)
+	GetEspaciosDisponibles(ctx context.Context) ([]models.EspacioDetalle, error)
+	GetTicketsActivos(ctx context.Context) ([]models.Ticket, error)
+	GetTicketsActivosByAuthUser(ctx context.Context, authUserID string) ([]models.Ticket, error)
+
+	// GetDesgloseIngresos desglosa la recaudación de [desde, hasta)
+	GetDesgloseIngresos(ctx context.Context, desde, hasta time.Time) (*models.DesgloseIngresos, error)
+
+	// GetFlujoHorario cuenta los ingresos y salidas de tickets de [desde, hasta) por
+	// sección y hora, para el pronóstico de ocupación
+	GetFlujoHorario(ctx context.Context, desde, hasta time.Time) ([]models.FlujoHora, error)
+
+	// BuscarPlaca obtiene hasta limite vehículos cuya placa contiene placa (normalizada
+	// con models.NormalizarPlaca), con la coincidencia exacta primero, su ticket abierto
+	// y sus últimas visitas cerradas
+	BuscarPlaca(ctx context.Context, placa string, limite, visitas int) ([]models.ResultadoPlaca, error)
+
+	// BuscarTickets busca tickets históricos con el filtro ya normalizado (placa con
+	// models.NormalizarPlaca, sección en mayúsculas, campo definido), paginados por
+	// cursor como TicketRepository.BuscarTickets
+	BuscarTickets(ctx context.Context, filtro models.FiltroBusquedaTickets, despues *models.CursorTickets, limite int, ahora time.Time) ([]models.Ticket, error)
 }
 
+// ErrNotSupported la fuente de datos no puede responder la consulta (por ejemplo el
+// graphql-service no expone los datos necesarios)
+var ErrNotSupported = errors.New("consulta no soportada por la fuente de datos")
+
 // TicketRepository define los métodos para tickets
 type TicketRepository interface {
-	// GetTicketsActivos obtiene tickets sin fecha de salida
+	// GetTicketsActivos obtiene tickets sin fecha de salida con su vehículo, tipo de
+	// vehículo, tarifa, espacio y sección en una sola consulta
 	GetTicketsActivos(ctx context.Context) ([]models.Ticket, error)
 
 	// GetTicketByID obtiene un ticket por ID
 	GetTicketByID(ctx context.Context, id string) (*models.Ticket, error)
+
+	// GetTicketsActivosByAuthUser obtiene tickets activos de los vehículos del cliente
+	// vinculado al usuario del auth-service (cliente.auth_user_id)
+	GetTicketsActivosByAuthUser(ctx context.Context, authUserID string) ([]models.Ticket, error)
+
+	// GetFlujoHorario cuenta los ingresos y salidas de [desde, hasta) por sección y hora
+	GetFlujoHorario(ctx context.Context, desde, hasta time.Time) ([]models.FlujoHora, error)
+
+	// GetUltimosPorVehiculo obtiene los últimos porVehiculo tickets (abiertos o
+	// cerrados) de cada vehículo, con la misma vista enriquecida
+	GetUltimosPorVehiculo(ctx context.Context, vehiculoIDs []string, porVehiculo int) ([]models.Ticket, error)
+
+	// BuscarTickets busca tickets abiertos o cerrados que cumplen el filtro y devuelve
+	// como máximo limite, posteriores a despues (nil = desde el principio), en orden
+	// descendente por la fecha del campo filtrado y el ID
+	BuscarTickets(ctx context.Context, filtro models.FiltroBusquedaTickets, despues *models.CursorTickets, limite int, ahora time.Time) ([]models.Ticket, error)
 }
 
 // VehiculoRepository define los métodos para vehículos
 type VehiculoRepository interface {
 	// GetVehiculoByID obtiene un vehículo por ID
 	GetVehiculoByID(ctx context.Context, id string) (*models.Vehiculo, error)
+
+	// BuscarPorPlaca obtiene hasta limite vehículos cuya placa normalizada contiene
+	// placa, con la coincidencia exacta primero
+	BuscarPorPlaca(ctx context.Context, placa string, limite int) ([]models.ResultadoPlaca, error)
+}
+
+// EventoRepository define los métodos para construir eventos de espacios
+type EventoRepository interface {
+	// GetEspacioOcupadoEvent arma el evento de ocupación con el ticket activo del espacio
+	GetEspacioOcupadoEvent(ctx context.Context, espacioID string) (*models.EspacioOcupadoEvent, error)
+
+	// GetEspacioLiberadoEvent arma el evento de liberación con el último ticket cerrado del espacio
+	GetEspacioLiberadoEvent(ctx context.Context, espacioID string) (*models.EspacioLiberadoEvent, error)
+
+	// GetMultaEvent arma el evento de una multa con los pendientes de su vehículo y,
+	// si está estacionado, el espacio de su ticket activo
+	GetMultaEvent(ctx context.Context, multaID string) (*models.MultaEvent, error)
+}
+
+// MultaRepository define los métodos para multas (multa.estado = 'pendiente' | 'pagada' | 'anulada')
+type MultaRepository interface {
+	// GetResumenPendientes obtiene cantidad y monto de todas las multas pendientes
+	GetResumenPendientes(ctx context.Context) (models.ResumenMultas, error)
+
+	// GetPendientesPorVehiculo obtiene las multas pendientes de los vehículos indicados.
+	// Los vehículos sin multas pendientes no aparecen en el mapa.
+	GetPendientesPorVehiculo(ctx context.Context, vehiculoIDs []string) (map[string]models.ResumenMultas, error)
+}
+
+// IngresosRepository define la analítica de recaudación sobre detalle_pago
+type IngresosRepository interface {
+	// GetDesgloseIngresos desglosa los pagos de [desde, hasta) por método, tipo de
+	// tarifa, sección del espacio del ticket y hora del día
+	GetDesgloseIngresos(ctx context.Context, desde, hasta time.Time) (*models.DesgloseIngresos, error)
+}
+
+// OcupacionRepository guarda la serie de ocupación por minuto del historial
+type OcupacionRepository interface {
+	// SaveMinutos guarda los minutos acumulados, combinándolos con los ya guardados
+	// para la misma sección y minuto (por ejemplo desde otra réplica)
+	SaveMinutos(ctx context.Context, minutos []models.OcupacionMinuto) error
+
+	// GetBuckets agrupa la serie de la sección entre desde y hasta en intervalos de
+	// size alineados a desde, ordenados por inicio
+	GetBuckets(ctx context.Context, seccion string, desde, hasta time.Time, size time.Duration) ([]models.OcupacionBucket, error)
 }
@@ -118,10 +118,12 @@ func (r *DashboardRepository) GetEspaciosPorSeccion(ctx context.Context) ([]mode
 				e.numero,
 				e.estado,
 				v.placa,
-				t."fechaIngreso"
+				t."fechaIngreso",
+				tt.precio_hora,
+				tt.precio_dia
 			FROM espacio e
 			LEFT JOIN ticket t ON t."espacioId" = e.id AND t."fechaSalida" IS NULL
-			LEFT JOIN vehiculo v ON v.id = t."vehiculoId"
+			` + joinTarifaVehiculo + `
 			WHERE e."seccionId" = $1
 			ORDER BY e.numero
 		`
@@ -139,6 +141,7 @@ func (r *DashboardRepository) GetEspaciosPorSeccion(ctx context.Context) ([]mode
 			var espacio models.EspacioDetalle
 			var placa sql.NullString
 			var fechaIngreso sql.NullTime
+			var precioHora, precioDia sql.NullFloat64
 
 			if err := espaciosRows.Scan(
 				&espacio.ID,
@@ -146,6 +149,8 @@ func (r *DashboardRepository) GetEspaciosPorSeccion(ctx context.Context) ([]mode
 				&espacio.Estado,
 				&placa,
 				&fechaIngreso,
+				&precioHora,
+				&precioDia,
 			); err != nil {
 				espaciosRows.Close()
 				return nil, fmt.Errorf("error al escanear espacio: %w", err)
@@ -160,6 +165,7 @@ func (r *DashboardRepository) GetEspaciosPorSeccion(ctx context.Context) ([]mode
 			if fechaIngreso.Valid {
 				horaStr := fechaIngreso.Time.Format(time.RFC3339)
 				espacio.HoraIngreso = &horaStr
+				espacio.Tarifa = tarifaDe(precioHora, precioDia)
 			}
 
 			if espacio.Estado {
@@ -194,13 +200,14 @@ func (r *DashboardRepository) GetEspaciosPorSeccion(ctx context.Context) ([]mode
 	return secciones, nil
 }
 
-// GetEspaciosDisponibles obtiene lista de espacios disponibles
-func (r *DashboardRepository) GetEspaciosDisponibles(ctx context.Context) ([]models.Espacio, error) {
+// GetEspaciosDisponibles obtiene lista de espacios disponibles con la letra de su sección
+func (r *DashboardRepository) GetEspaciosDisponibles(ctx context.Context) ([]models.EspacioDetalle, error) {
 	query := `
-		SELECT id, numero, estado, "seccionId"
-		FROM espacio
-		WHERE estado = true
-		ORDER BY numero
+		SELECT e.id, e.numero, e.estado, COALESCE(s.letra_seccion, '')
+		FROM espacio e
+		LEFT JOIN seccion s ON s.id = e."seccionId"
+		WHERE e.estado = true
+		ORDER BY e.numero
 	`
 
 	rows, err := r.db.QueryContext(ctx, query)
@@ -209,10 +216,10 @@ func (r *DashboardRepository) GetEspaciosDisponibles(ctx context.Context) ([]mod
 	}
 	defer rows.Close()
 
-	var espacios []models.Espacio
+	var espacios []models.EspacioDetalle
 	for rows.Next() {
-		var espacio models.Espacio
-		if err := rows.Scan(&espacio.ID, &espacio.Numero, &espacio.Estado, &espacio.SeccionID); err != nil {
+		var espacio models.EspacioDetalle
+		if err := rows.Scan(&espacio.ID, &espacio.Numero, &espacio.Estado, &espacio.SeccionLetra); err != nil {
 			return nil, fmt.Errorf("error al escanear espacio: %w", err)
 		}
 		espacios = append(espacios, espacio)
@@ -0,0 +1,199 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+// EventoRepository implementación PostgreSQL del repositorio de eventos de espacios
+type EventoRepository struct {
+	db *sql.DB
+}
+
+// NewEventoRepository crea una nueva instancia del repositorio
+func NewEventoRepository(db *sql.DB) *EventoRepository {
+	return &EventoRepository{db: db}
+}
+
+// GetEspacioOcupadoEvent obtiene el espacio junto con el ticket activo que lo ocupa
+func (r *EventoRepository) GetEspacioOcupadoEvent(ctx context.Context, espacioID string) (*models.EspacioOcupadoEvent, error) {
+	query := `
+		SELECT
+			e.id,
+			e.numero,
+			COALESCE(s.letra_seccion, ''),
+			v.placa,
+			t."fechaIngreso"
+		FROM espacio e
+		LEFT JOIN seccion s ON s.id = e."seccionId"
+		LEFT JOIN ticket t ON t."espacioId" = e.id AND t."fechaSalida" IS NULL
+		LEFT JOIN vehiculo v ON v.id = t."vehiculoId"
+		WHERE e.id = $1
+		ORDER BY t."fechaIngreso" DESC NULLS LAST
+		LIMIT 1
+	`
+
+	var event models.EspacioOcupadoEvent
+	var placa sql.NullString
+	var fechaIngreso sql.NullTime
+
+	err := r.db.QueryRowContext(ctx, query, espacioID).Scan(
+		&event.EspacioID,
+		&event.Numero,
+		&event.SeccionLetra,
+		&placa,
+		&fechaIngreso,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener evento de espacio ocupado: %w", err)
+	}
+
+	if placa.Valid {
+		event.VehiculoPlaca = placa.String
+	}
+
+	if fechaIngreso.Valid {
+		event.HoraIngreso = fechaIngreso.Time
+	} else {
+		event.HoraIngreso = time.Now()
+	}
+
+	return &event, nil
+}
+
+// GetEspacioLiberadoEvent obtiene el espacio junto con el último ticket cerrado y su pago
+func (r *EventoRepository) GetEspacioLiberadoEvent(ctx context.Context, espacioID string) (*models.EspacioLiberadoEvent, error) {
+	query := `
+		SELECT
+			e.id,
+			e.numero,
+			COALESCE(s.letra_seccion, ''),
+			COALESCE(dp.pago_total, t.monto_calculado, 0) as monto,
+			t."fechaSalida"
+		FROM espacio e
+		LEFT JOIN seccion s ON s.id = e."seccionId"
+		LEFT JOIN LATERAL (
+			SELECT tk."fechaSalida", tk."detallePagoId", tk.monto_calculado
+			FROM ticket tk
+			WHERE tk."espacioId" = e.id AND tk."fechaSalida" IS NOT NULL
+			ORDER BY tk."fechaSalida" DESC
+			LIMIT 1
+		) t ON true
+		LEFT JOIN detalle_pago dp ON dp.id = t."detallePagoId"
+		WHERE e.id = $1
+	`
+
+	var event models.EspacioLiberadoEvent
+	var fechaSalida sql.NullTime
+
+	err := r.db.QueryRowContext(ctx, query, espacioID).Scan(
+		&event.EspacioID,
+		&event.Numero,
+		&event.SeccionLetra,
+		&event.MontoPagado,
+		&fechaSalida,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener evento de espacio liberado: %w", err)
+	}
+
+	if fechaSalida.Valid {
+		event.HoraSalida = fechaSalida.Time
+	} else {
+		event.HoraSalida = time.Now()
+	}
+
+	return &event, nil
+}
+
+// GetMultaEvent obtiene la multa con la placa del vehículo, sus multas pendientes y
+// el espacio del ticket activo si el vehículo está estacionado
+func (r *EventoRepository) GetMultaEvent(ctx context.Context, multaID string) (*models.MultaEvent, error) {
+	query := `
+		SELECT
+			m.id,
+			COALESCE(m.descripcion, ''),
+			COALESCE(m.monto_total, 0),
+			COALESCE(m.estado, $2),
+			m.fecha_multa,
+			m."vehiculoId",
+			v.placa,
+			m."ticketId",
+			activo."espacioId",
+			activo.letra_seccion,
+			COALESCE(pendientes.cantidad, 0),
+			COALESCE(pendientes.monto, 0)
+		FROM multa m
+		LEFT JOIN vehiculo v ON v.id = m."vehiculoId"
+		LEFT JOIN LATERAL (
+			SELECT t."espacioId", s.letra_seccion
+			FROM ticket t
+			LEFT JOIN espacio e ON e.id = t."espacioId"
+			LEFT JOIN seccion s ON s.id = e."seccionId"
+			WHERE t."vehiculoId" = m."vehiculoId" AND t."fechaSalida" IS NULL
+			ORDER BY t."fechaIngreso" DESC
+			LIMIT 1
+		) activo ON true
+		LEFT JOIN LATERAL (
+			SELECT COUNT(*) AS cantidad, SUM(p.monto_total) AS monto
+			FROM multa p
+			WHERE p."vehiculoId" = m."vehiculoId" AND p.estado = $2
+		) pendientes ON true
+		WHERE m.id = $1
+	`
+
+	var event models.MultaEvent
+	var fechaMulta sql.NullTime
+	var vehiculoID, placa, ticketID, espacioID, seccion sql.NullString
+
+	err := r.db.QueryRowContext(ctx, query, multaID, models.MultaPendiente).Scan(
+		&event.MultaID,
+		&event.Descripcion,
+		&event.MontoMulta,
+		&event.Estado,
+		&fechaMulta,
+		&vehiculoID,
+		&placa,
+		&ticketID,
+		&espacioID,
+		&seccion,
+		&event.MultasPendientes,
+		&event.MontoMultasPendientes,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener evento de multa: %w", err)
+	}
+
+	event.VehiculoID = vehiculoID.String
+	event.VehiculoPlaca = placa.String
+	event.TicketID = ticketID.String
+	event.EspacioID = espacioID.String
+	event.SeccionLetra = seccion.String
+
+	if fechaMulta.Valid {
+		event.FechaMulta = fechaMulta.Time
+	} else {
+		event.FechaMulta = time.Now()
+	}
+
+	return &event, nil
+}
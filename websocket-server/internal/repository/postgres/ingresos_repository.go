@@ -0,0 +1,105 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+// IngresosRepository implementación PostgreSQL de la analítica de recaudación
+type IngresosRepository struct {
+	db *sql.DB
+}
+
+// NewIngresosRepository crea una nueva instancia del repositorio
+func NewIngresosRepository(db *sql.DB) *IngresosRepository {
+	return &IngresosRepository{db: db}
+}
+
+// GetDesgloseIngresos desglosa los pagos de [desde, hasta) por método, tarifa, sección
+// y hora del día en una sola consulta (GROUPING SETS)
+func (r *IngresosRepository) GetDesgloseIngresos(ctx context.Context, desde, hasta time.Time) (*models.DesgloseIngresos, error) {
+	// pago."tipoTarifaId" y detalle_pago."pagoId" son varchar en algunas bases: se
+	// comparan como texto contra los uuid. La hora se agrupa como hora Unix y se pasa a
+	// hora del día con models.HoraDelDia, igual que en las demás fuentes.
+	query := `
+		WITH pagos AS (
+			SELECT
+				dp.pago_total AS total,
+				dp.metodo,
+				COALESCE(tt.tipo_tarifa, $3) AS tarifa,
+				COALESCE(s.letra_seccion, $4) AS seccion,
+				floor(EXTRACT(EPOCH FROM dp.fecha_pago::timestamptz) / 3600)::bigint AS hora
+			FROM detalle_pago dp
+			LEFT JOIN pago p ON p.id::text = dp."pagoId"::text
+			LEFT JOIN tipo_tarifa tt ON tt.id::text = p."tipoTarifaId"::text
+			LEFT JOIN ticket t ON t."detallePagoId" = dp.id
+			LEFT JOIN espacio e ON e.id = t."espacioId"
+			LEFT JOIN seccion s ON s.id = e."seccionId"
+			WHERE dp.fecha_pago >= $1 AND dp.fecha_pago < $2
+		)
+		SELECT
+			CASE
+				WHEN GROUPING(metodo) = 0 THEN 'metodo'
+				WHEN GROUPING(tarifa) = 0 THEN 'tarifa'
+				WHEN GROUPING(seccion) = 0 THEN 'seccion'
+				WHEN GROUPING(hora) = 0 THEN 'hora'
+				ELSE 'total'
+			END AS grupo,
+			COALESCE(metodo, tarifa, seccion, hora::text, '') AS clave,
+			COALESCE(SUM(total), 0),
+			COUNT(*)
+		FROM pagos
+		GROUP BY GROUPING SETS ((), (metodo), (tarifa), (seccion), (hora))
+		ORDER BY grupo, SUM(total) DESC NULLS LAST, clave
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, desde, hasta, models.SinTarifa, models.SinSeccion)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener desglose de ingresos: %w", err)
+	}
+	defer rows.Close()
+
+	desglose := &models.DesgloseIngresos{
+		Desde:      desde,
+		Hasta:      hasta,
+		PorMetodo:  []models.IngresosGrupo{},
+		PorTarifa:  []models.IngresosGrupo{},
+		PorSeccion: []models.IngresosGrupo{},
+		PorHora:    models.HorasVacias(),
+	}
+
+	for rows.Next() {
+		var grupo string
+		var g models.IngresosGrupo
+		if err := rows.Scan(&grupo, &g.Clave, &g.Total, &g.Pagos); err != nil {
+			return nil, fmt.Errorf("error al escanear desglose de ingresos: %w", err)
+		}
+
+		switch grupo {
+		case "total":
+			desglose.Total = g.Total
+			desglose.Pagos = g.Pagos
+		case "metodo":
+			desglose.PorMetodo = append(desglose.PorMetodo, g)
+		case "tarifa":
+			desglose.PorTarifa = append(desglose.PorTarifa, g)
+		case "seccion":
+			desglose.PorSeccion = append(desglose.PorSeccion, g)
+		case "hora":
+			horaUnix, err := strconv.ParseInt(g.Clave, 10, 64)
+			if err != nil {
+				continue
+			}
+			hora := &desglose.PorHora[models.HoraDelDia(horaUnix)]
+			hora.Total += g.Total
+			hora.Pagos += g.Pagos
+		}
+	}
+
+	return desglose, rows.Err()
+}
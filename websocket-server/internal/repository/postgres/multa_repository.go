@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+// MultaRepository implementación PostgreSQL del repositorio de multas
+type MultaRepository struct {
+	db *sql.DB
+}
+
+// NewMultaRepository crea una nueva instancia del repositorio
+func NewMultaRepository(db *sql.DB) *MultaRepository {
+	return &MultaRepository{db: db}
+}
+
+// GetResumenPendientes obtiene cantidad y monto de todas las multas pendientes
+func (r *MultaRepository) GetResumenPendientes(ctx context.Context) (models.ResumenMultas, error) {
+	query := `
+		SELECT COUNT(*), COALESCE(SUM(monto_total), 0)
+		FROM multa
+		WHERE estado = $1
+	`
+
+	var resumen models.ResumenMultas
+	err := r.db.QueryRowContext(ctx, query, models.MultaPendiente).Scan(&resumen.Cantidad, &resumen.Monto)
+	if err != nil {
+		return models.ResumenMultas{}, fmt.Errorf("error al obtener multas pendientes: %w", err)
+	}
+
+	return resumen, nil
+}
+
+// GetPendientesPorVehiculo obtiene las multas pendientes de los vehículos indicados
+func (r *MultaRepository) GetPendientesPorVehiculo(ctx context.Context, vehiculoIDs []string) (map[string]models.ResumenMultas, error) {
+	pendientes := make(map[string]models.ResumenMultas)
+	if len(vehiculoIDs) == 0 {
+		return pendientes, nil
+	}
+
+	query := `
+		SELECT "vehiculoId", COUNT(*), COALESCE(SUM(monto_total), 0)
+		FROM multa
+		WHERE estado = $1 AND "vehiculoId"::text = ANY($2)
+		GROUP BY "vehiculoId"
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, models.MultaPendiente, pq.Array(vehiculoIDs))
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener multas pendientes por vehículo: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var vehiculoID string
+		var resumen models.ResumenMultas
+		if err := rows.Scan(&vehiculoID, &resumen.Cantidad, &resumen.Monto); err != nil {
+			return nil, fmt.Errorf("error al escanear multas pendientes: %w", err)
+		}
+		pendientes[vehiculoID] = resumen
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterando multas pendientes: %w", err)
+	}
+
+	return pendientes, nil
+}
@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+// ocupacionPruneInterval cada cuánto se borran los minutos fuera de la retención
+const ocupacionPruneInterval = time.Hour
+
+// OcupacionRepository implementación PostgreSQL del historial de ocupación
+// (tabla ocupacion_historial, ver database/migrations/003_ocupacion_historial.sql)
+type OcupacionRepository struct {
+	db        *sql.DB
+	retention time.Duration
+
+	mu         sync.Mutex
+	lastPruned time.Time
+}
+
+// NewOcupacionRepository crea el repositorio. Los minutos más antiguos que retention se borran.
+func NewOcupacionRepository(db *sql.DB, retention time.Duration) *OcupacionRepository {
+	return &OcupacionRepository{db: db, retention: retention}
+}
+
+// SaveMinutos inserta los minutos o los combina con la fila existente
+func (r *OcupacionRepository) SaveMinutos(ctx context.Context, minutos []models.OcupacionMinuto) error {
+	query := `
+		INSERT INTO ocupacion_historial (seccion, minuto, minimo, maximo, suma, muestras)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (seccion, minuto) DO UPDATE SET
+			minimo = LEAST(ocupacion_historial.minimo, EXCLUDED.minimo),
+			maximo = GREATEST(ocupacion_historial.maximo, EXCLUDED.maximo),
+			suma = ocupacion_historial.suma + EXCLUDED.suma,
+			muestras = ocupacion_historial.muestras + EXCLUDED.muestras
+	`
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error al iniciar transacción del historial: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, m := range minutos {
+		if _, err := tx.ExecContext(ctx, query, m.Seccion, m.Minuto, m.Min, m.Max, m.Suma, m.Muestras); err != nil {
+			return fmt.Errorf("error al guardar ocupación: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error al guardar ocupación: %w", err)
+	}
+
+	r.pruneIfDue(ctx)
+	return nil
+}
+
+// pruneIfDue borra los minutos fuera de la retención como mucho una vez por ocupacionPruneInterval
+func (r *OcupacionRepository) pruneIfDue(ctx context.Context) {
+	r.mu.Lock()
+	if time.Since(r.lastPruned) < ocupacionPruneInterval {
+		r.mu.Unlock()
+		return
+	}
+	r.lastPruned = time.Now()
+	r.mu.Unlock()
+
+	_, err := r.db.ExecContext(ctx, `DELETE FROM ocupacion_historial WHERE minuto < $1`, time.Now().Add(-r.retention))
+	if err != nil {
+		log.Printf("⚠️  Error al depurar historial de ocupación: %v", err)
+	}
+}
+
+// GetBuckets agrupa los minutos de la sección en intervalos de size contados desde desde
+func (r *OcupacionRepository) GetBuckets(ctx context.Context, seccion string, desde, hasta time.Time, size time.Duration) ([]models.OcupacionBucket, error) {
+	query := `
+		SELECT
+			$2::timestamptz + floor(extract(epoch FROM minuto - $2::timestamptz)::float8 / $4::float8) * $4::float8 * interval '1 second' AS inicio,
+			MIN(minimo),
+			MAX(maximo),
+			SUM(suma) / SUM(muestras),
+			SUM(muestras)
+		FROM ocupacion_historial
+		WHERE seccion = $1 AND minuto >= $2 AND minuto < $3
+		GROUP BY inicio
+		ORDER BY inicio
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, seccion, desde, hasta, size.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener historial de ocupación: %w", err)
+	}
+	defer rows.Close()
+
+	buckets := []models.OcupacionBucket{}
+	for rows.Next() {
+		var b models.OcupacionBucket
+		if err := rows.Scan(&b.Inicio, &b.Min, &b.Max, &b.Promedio, &b.Muestras); err != nil {
+			return nil, fmt.Errorf("error al escanear historial de ocupación: %w", err)
+		}
+		buckets = append(buckets, b)
+	}
+
+	return buckets, rows.Err()
+}
@@ -0,0 +1,175 @@
+package postgres
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/repository/interfaces"
+)
+
+// Source fuente de datos del dashboard que consulta PostgreSQL directamente
+type Source struct {
+	dashboardRepo interfaces.DashboardRepository
+	ticketRepo    interfaces.TicketRepository
+	vehiculoRepo  interfaces.VehiculoRepository
+	ingresosRepo  interfaces.IngresosRepository
+	multaRepo     interfaces.MultaRepository
+}
+
+// Source implementa la fuente de datos del dashboard
+var _ interfaces.DataSource = (*Source)(nil)
+
+// NewSource crea la fuente de datos a partir de los repositorios
+func NewSource(dashboardRepo interfaces.DashboardRepository, ticketRepo interfaces.TicketRepository, vehiculoRepo interfaces.VehiculoRepository, ingresosRepo interfaces.IngresosRepository, multaRepo interfaces.MultaRepository) *Source {
+	return &Source{
+		dashboardRepo: dashboardRepo,
+		ticketRepo:    ticketRepo,
+		vehiculoRepo:  vehiculoRepo,
+		ingresosRepo:  ingresosRepo,
+		multaRepo:     multaRepo,
+	}
+}
+
+// Name identifica la fuente en logs
+func (s *Source) Name() string {
+	return "database"
+}
+
+// GetDashboardData obtiene todos los datos del dashboard
+func (s *Source) GetDashboardData(ctx context.Context) (*models.DashboardData, error) {
+	// Obtener estadísticas de espacios
+	disponibles, ocupados, total, err := s.dashboardRepo.GetEspaciosStats(ctx)
+	if err != nil {
+		log.Printf("Error obteniendo estadísticas de espacios: %v", err)
+		return nil, err
+	}
+
+	// Obtener dinero recaudado hoy
+	dineroHoy, err := s.dashboardRepo.GetDineroRecaudadoHoy(ctx)
+	if err != nil {
+		log.Printf("Error obteniendo dinero recaudado hoy: %v", err)
+		return nil, err
+	}
+
+	// Obtener dinero recaudado en el mes
+	dineroMes, err := s.dashboardRepo.GetDineroRecaudadoMes(ctx)
+	if err != nil {
+		log.Printf("Error obteniendo dinero recaudado del mes: %v", err)
+		return nil, err
+	}
+
+	// Obtener vehículos activos
+	vehiculosActivos, err := s.dashboardRepo.GetVehiculosActivos(ctx)
+	if err != nil {
+		log.Printf("Error obteniendo vehículos activos: %v", err)
+		return nil, err
+	}
+
+	// Obtener multas pendientes
+	multas, err := s.multaRepo.GetResumenPendientes(ctx)
+	if err != nil {
+		log.Printf("Error obteniendo multas pendientes: %v", err)
+		return nil, err
+	}
+
+	return &models.DashboardData{
+		EspaciosDisponibles:   disponibles,
+		EspaciosOcupados:      ocupados,
+		TotalEspacios:         total,
+		DineroRecaudadoHoy:    dineroHoy,
+		DineroRecaudadoMes:    dineroMes,
+		VehiculosActivos:      vehiculosActivos,
+		MultasPendientes:      multas.Cantidad,
+		MontoMultasPendientes: multas.Monto,
+		Timestamp:             time.Now(),
+	}, nil
+}
+
+// GetEspaciosPorSeccion obtiene espacios agrupados por sección
+func (s *Source) GetEspaciosPorSeccion(ctx context.Context) ([]models.EspaciosPorSeccion, error) {
+	return s.dashboardRepo.GetEspaciosPorSeccion(ctx)
+}
+
+// GetEspaciosDisponibles obtiene lista de espacios disponibles
+func (s *Source) GetEspaciosDisponibles(ctx context.Context) ([]models.EspacioDetalle, error) {
+	return s.dashboardRepo.GetEspaciosDisponibles(ctx)
+}
+
+// GetTicketsActivos obtiene tickets sin fecha de salida con las multas pendientes de su vehículo
+func (s *Source) GetTicketsActivos(ctx context.Context) ([]models.Ticket, error) {
+	tickets, err := s.ticketRepo.GetTicketsActivos(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.marcarMultas(ctx, tickets)
+}
+
+// GetTicketsActivosByAuthUser obtiene tickets activos de los vehículos del usuario
+func (s *Source) GetTicketsActivosByAuthUser(ctx context.Context, authUserID string) ([]models.Ticket, error) {
+	tickets, err := s.ticketRepo.GetTicketsActivosByAuthUser(ctx, authUserID)
+	if err != nil {
+		return nil, err
+	}
+	return s.marcarMultas(ctx, tickets)
+}
+
+// marcarMultas completa las multas pendientes del vehículo de cada ticket
+func (s *Source) marcarMultas(ctx context.Context, tickets []models.Ticket) ([]models.Ticket, error) {
+	vehiculos := make([]string, 0, len(tickets))
+	for _, ticket := range tickets {
+		vehiculos = append(vehiculos, ticket.VehiculoID)
+	}
+
+	pendientes, err := s.multaRepo.GetPendientesPorVehiculo(ctx, vehiculos)
+	if err != nil {
+		return nil, err
+	}
+
+	models.MarcarMultasPendientes(tickets, pendientes)
+	return tickets, nil
+}
+
+// GetDesgloseIngresos desglosa la recaudación de [desde, hasta)
+func (s *Source) GetDesgloseIngresos(ctx context.Context, desde, hasta time.Time) (*models.DesgloseIngresos, error) {
+	return s.ingresosRepo.GetDesgloseIngresos(ctx, desde, hasta)
+}
+
+// GetFlujoHorario cuenta ingresos y salidas de [desde, hasta) por sección y hora
+func (s *Source) GetFlujoHorario(ctx context.Context, desde, hasta time.Time) ([]models.FlujoHora, error) {
+	return s.ticketRepo.GetFlujoHorario(ctx, desde, hasta)
+}
+
+// BuscarPlaca busca los vehículos por placa con su ticket abierto, las multas
+// pendientes de ese ticket y sus últimas visitas
+func (s *Source) BuscarPlaca(ctx context.Context, placa string, limite, visitas int) ([]models.ResultadoPlaca, error) {
+	resultados, err := s.vehiculoRepo.BuscarPorPlaca(ctx, placa, limite)
+	if err != nil || len(resultados) == 0 {
+		return resultados, err
+	}
+
+	vehiculos := make([]string, 0, len(resultados))
+	for _, resultado := range resultados {
+		vehiculos = append(vehiculos, resultado.VehiculoID)
+	}
+
+	// El ticket abierto, si existe, es el más reciente: uno más que las visitas pedidas
+	tickets, err := s.ticketRepo.GetUltimosPorVehiculo(ctx, vehiculos, visitas+1)
+	if err != nil {
+		return nil, err
+	}
+	models.AsignarTickets(resultados, tickets, visitas)
+
+	pendientes, err := s.multaRepo.GetPendientesPorVehiculo(ctx, vehiculos)
+	if err != nil {
+		return nil, err
+	}
+	models.MarcarMultasActivos(resultados, pendientes)
+	return resultados, nil
+}
+
+// BuscarTickets busca tickets históricos paginados por cursor
+func (s *Source) BuscarTickets(ctx context.Context, filtro models.FiltroBusquedaTickets, despues *models.CursorTickets, limite int, ahora time.Time) ([]models.Ticket, error) {
+	return s.ticketRepo.BuscarTickets(ctx, filtro, despues, limite, ahora)
+}
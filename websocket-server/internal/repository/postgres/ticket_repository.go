@@ -4,8 +4,14 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
 
 	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/tarifa"
 )
 
 // TicketRepository implementación PostgreSQL del repositorio de tickets
@@ -18,13 +24,11 @@ func NewTicketRepository(db *sql.DB) *TicketRepository {
 	return &TicketRepository{db: db}
 }
 
-// GetTicketsActivos obtiene tickets sin fecha de salida
+// GetTicketsActivos obtiene tickets sin fecha de salida con su vehículo y su espacio
 func (r *TicketRepository) GetTicketsActivos(ctx context.Context) ([]models.Ticket, error) {
-	query := `
-		SELECT id, "fechaIngreso", "fechaSalida", "vehiculoId", "espacioId", "detallePagoId"
-		FROM ticket
-		WHERE "fechaSalida" IS NULL
-		ORDER BY "fechaIngreso" DESC
+	query := selectTicketDetalle + `
+		WHERE t."fechaSalida" IS NULL
+		ORDER BY t."fechaIngreso" DESC
 	`
 
 	rows, err := r.db.QueryContext(ctx, query)
@@ -33,11 +37,186 @@ func (r *TicketRepository) GetTicketsActivos(ctx context.Context) ([]models.Tick
 	}
 	defer rows.Close()
 
+	return scanTickets(rows)
+}
+
+// GetTicketsActivosByAuthUser obtiene tickets activos de los vehículos del cliente vinculado
+func (r *TicketRepository) GetTicketsActivosByAuthUser(ctx context.Context, authUserID string) ([]models.Ticket, error) {
+	query := selectTicketDetalle + `
+		INNER JOIN cliente c ON c.id = v."clienteId"
+		WHERE t."fechaSalida" IS NULL AND c.auth_user_id = $1
+		ORDER BY t."fechaIngreso" DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, authUserID)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener tickets activos del usuario: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTickets(rows)
+}
+
+// GetUltimosPorVehiculo obtiene los últimos porVehiculo tickets (abiertos o cerrados)
+// de cada vehículo, del más reciente al más antiguo
+func (r *TicketRepository) GetUltimosPorVehiculo(ctx context.Context, vehiculoIDs []string, porVehiculo int) ([]models.Ticket, error) {
+	if len(vehiculoIDs) == 0 {
+		return []models.Ticket{}, nil
+	}
+
+	query := selectTicketDetalle + `
+		WHERE t.id IN (
+			SELECT id FROM (
+				SELECT id, ROW_NUMBER() OVER (PARTITION BY "vehiculoId" ORDER BY "fechaIngreso" DESC) AS n
+				FROM ticket
+				WHERE "vehiculoId"::text = ANY($1)
+			) ultimos
+			WHERE n <= $2
+		)
+		ORDER BY t."fechaIngreso" DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(vehiculoIDs), porVehiculo)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener tickets por vehículo: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTickets(rows)
+}
+
+// BuscarTickets busca tickets abiertos o cerrados con el filtro (ya normalizado) y
+// devuelve como máximo limite, posteriores a despues en orden descendente por la
+// fecha del campo filtrado y el ID
+func (r *TicketRepository) BuscarTickets(ctx context.Context, filtro models.FiltroBusquedaTickets, despues *models.CursorTickets, limite int, ahora time.Time) ([]models.Ticket, error) {
+	campo := `t."fechaIngreso"`
+	if filtro.Campo == models.CampoSalida {
+		campo = `t."fechaSalida"`
+	}
+
+	var condiciones []string
+	var args []interface{}
+	param := func(value interface{}) string {
+		args = append(args, value)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	if filtro.Campo == models.CampoSalida {
+		condiciones = append(condiciones, campo+" IS NOT NULL")
+	}
+	if filtro.Desde != nil {
+		condiciones = append(condiciones, campo+" >= "+param(*filtro.Desde))
+	}
+	if filtro.Hasta != nil {
+		condiciones = append(condiciones, campo+" < "+param(*filtro.Hasta))
+	}
+	if filtro.Seccion != "" {
+		condiciones = append(condiciones, "upper(s.letra_seccion) = "+param(filtro.Seccion))
+	}
+	if filtro.Placa != "" {
+		condiciones = append(condiciones, `regexp_replace(upper(v.placa), '[^A-Z0-9]', '', 'g') LIKE '%' || `+param(filtro.Placa)+` || '%'`)
+	}
+	if filtro.Pagado != nil {
+		if *filtro.Pagado {
+			condiciones = append(condiciones, `t."detallePagoId" IS NOT NULL`)
+		} else {
+			condiciones = append(condiciones, `t."detallePagoId" IS NULL`)
+		}
+	}
+	if filtro.MinMinutos != nil || filtro.MaxMinutos != nil {
+		// Los tickets abiertos cuentan hasta ahora
+		duracion := `(COALESCE(t."fechaSalida", ` + param(ahora) + `) - t."fechaIngreso")`
+		if filtro.MinMinutos != nil {
+			condiciones = append(condiciones, duracion+" >= make_interval(mins => "+param(*filtro.MinMinutos)+")")
+		}
+		if filtro.MaxMinutos != nil {
+			condiciones = append(condiciones, duracion+" < make_interval(mins => "+param(*filtro.MaxMinutos+1)+")")
+		}
+	}
+	if despues != nil {
+		// COLLATE "C": mismo orden de IDs que la comparación de strings del cursor
+		condiciones = append(condiciones, "("+campo+`, t.id::text COLLATE "C") < (`+param(despues.Fecha)+", "+param(despues.ID)+")")
+	}
+
+	query := selectTicketDetalle
+	if len(condiciones) > 0 {
+		query += "\n\t\tWHERE " + strings.Join(condiciones, "\n\t\t\tAND ")
+	}
+	query += "\n\t\tORDER BY " + campo + ` DESC, t.id::text COLLATE "C" DESC
+		LIMIT ` + param(limite)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar tickets: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTickets(rows)
+}
+
+// GetFlujoHorario cuenta ingresos y salidas de [desde, hasta) por sección y hora
+func (r *TicketRepository) GetFlujoHorario(ctx context.Context, desde, hasta time.Time) ([]models.FlujoHora, error) {
+	query := `
+		SELECT s.letra_seccion, f.hora, SUM(f.ingresos)::int, SUM(f.salidas)::int
+		FROM (
+			SELECT t."espacioId" AS espacio_id, date_trunc('hour', t."fechaIngreso") AS hora, 1 AS ingresos, 0 AS salidas
+			FROM ticket t
+			WHERE t."fechaIngreso" >= $1 AND t."fechaIngreso" < $2
+			UNION ALL
+			SELECT t."espacioId", date_trunc('hour', t."fechaSalida"), 0, 1
+			FROM ticket t
+			WHERE t."fechaSalida" >= $1 AND t."fechaSalida" < $2
+		) f
+		INNER JOIN espacio e ON e.id = f.espacio_id
+		INNER JOIN seccion s ON s.id = e."seccionId"
+		GROUP BY s.letra_seccion, f.hora
+		ORDER BY s.letra_seccion, f.hora
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, desde, hasta)
+	if err != nil {
+		return nil, fmt.Errorf("error al obtener flujo horario de tickets: %w", err)
+	}
+	defer rows.Close()
+
+	flujos := []models.FlujoHora{}
+	for rows.Next() {
+		var f models.FlujoHora
+		if err := rows.Scan(&f.SeccionLetra, &f.Hora, &f.Ingresos, &f.Salidas); err != nil {
+			return nil, fmt.Errorf("error al escanear flujo horario: %w", err)
+		}
+		flujos = append(flujos, f)
+	}
+	return flujos, rows.Err()
+}
+
+// joinTarifaVehiculo une el ticket (alias t) con su vehículo (v) y la tarifa de su tipo
+// de vehículo (tt). "tipoTarifaId" es varchar en algunas bases: se compara como texto.
+const joinTarifaVehiculo = `LEFT JOIN vehiculo v ON v.id = t."vehiculoId"
+		LEFT JOIN tipo_vehiculo tv ON tv.id = v."tipoVehiculoId"
+		LEFT JOIN tipo_tarifa tt ON tt.id::text = tv."tipoTarifaId"::text`
+
+// selectTicketDetalle vista enriquecida del ticket (alias t) en una sola consulta:
+// placa, marca, modelo y categoría del vehículo, número de espacio, letra de sección
+// y precios de la tarifa del vehículo
+const selectTicketDetalle = `
+		SELECT t.id, t."fechaIngreso", t."fechaSalida", t."vehiculoId", t."espacioId", t."detallePagoId",
+			v.placa, v.marca, v.modelo, tv.categoria, e.numero, s.letra_seccion,
+			tt.precio_hora, tt.precio_dia
+		FROM ticket t
+		` + joinTarifaVehiculo + `
+		LEFT JOIN espacio e ON e.id = t."espacioId"
+		LEFT JOIN seccion s ON s.id = e."seccionId"`
+
+// scanTickets escanea filas con las columnas de selectTicketDetalle
+func scanTickets(rows *sql.Rows) ([]models.Ticket, error) {
 	var tickets []models.Ticket
 	for rows.Next() {
 		var ticket models.Ticket
 		var fechaSalida sql.NullTime
 		var detallePagoID sql.NullString
+		var placa, marca, modelo, categoria, numero, seccion sql.NullString
+		var precioHora, precioDia sql.NullFloat64
 
 		if err := rows.Scan(
 			&ticket.ID,
@@ -46,6 +225,14 @@ func (r *TicketRepository) GetTicketsActivos(ctx context.Context) ([]models.Tick
 			&ticket.VehiculoID,
 			&ticket.EspacioID,
 			&detallePagoID,
+			&placa,
+			&marca,
+			&modelo,
+			&categoria,
+			&numero,
+			&seccion,
+			&precioHora,
+			&precioDia,
 		); err != nil {
 			return nil, fmt.Errorf("error al escanear ticket: %w", err)
 		}
@@ -58,6 +245,14 @@ func (r *TicketRepository) GetTicketsActivos(ctx context.Context) ([]models.Tick
 			ticket.DetallePagoID = &detallePagoID.String
 		}
 
+		ticket.VehiculoPlaca = placa.String
+		ticket.VehiculoMarca = marca.String
+		ticket.VehiculoModelo = modelo.String
+		ticket.VehiculoCategoria = categoria.String
+		ticket.EspacioNumero = numero.String
+		ticket.SeccionLetra = seccion.String
+		ticket.Tarifa = tarifaDe(precioHora, precioDia)
+
 		tickets = append(tickets, ticket)
 	}
 
@@ -107,3 +302,11 @@ func (r *TicketRepository) GetTicketByID(ctx context.Context, id string) (*model
 
 	return &ticket, nil
 }
+
+// tarifaDe arma la tarifa a partir de los precios leídos, o nil si el vehículo no tiene
+func tarifaDe(precioHora, precioDia sql.NullFloat64) *tarifa.Tarifa {
+	if !precioHora.Valid || !precioDia.Valid {
+		return nil
+	}
+	return &tarifa.Tarifa{PrecioHora: precioHora.Float64, PrecioDia: precioDia.Float64}
+}
@@ -0,0 +1,144 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+// columnasTicket columnas de selectTicketDetalle
+var columnasTicket = []string{
+	"id", "fechaIngreso", "fechaSalida", "vehiculoId", "espacioId", "detallePagoId",
+	"placa", "marca", "modelo", "categoria", "numero", "letra_seccion", "precio_hora", "precio_dia",
+}
+
+// compactar SQL con los espacios colapsados, para comparar consultas armadas por partes
+func compactar(sql string) string {
+	return strings.Join(strings.Fields(sql), " ")
+}
+
+// TestBuscarTicketsSQL comprueba el WHERE, el ORDER BY y los argumentos que arma
+// BuscarTickets para cada filtro. El contrato (TestPostgresSourceContrato) no lo cubre: ahí sqlmock
+// devuelve filas calculadas en Go y solo se comprueba el escaneo.
+func TestBuscarTicketsSQL(t *testing.T) {
+	desde := time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)
+	hasta := desde.AddDate(0, 0, 7)
+	ahora := hasta.Add(-time.Hour)
+	verdadero, falso := true, false
+	min, max := 30, 120
+	cursor := &models.CursorTickets{Fecha: desde.Add(time.Hour), ID: "t-9"}
+
+	casos := []struct {
+		nombre  string
+		filtro  models.FiltroBusquedaTickets
+		despues *models.CursorTickets
+		sql     string
+		args    []driver.Value
+	}{
+		{
+			nombre: "sin filtro",
+			sql:    `ORDER BY t."fechaIngreso" DESC, t.id::text COLLATE "C" DESC LIMIT $1`,
+			args:   []driver.Value{int64(11)},
+		},
+		{
+			nombre: "rango de ingreso",
+			filtro: models.FiltroBusquedaTickets{Desde: &desde, Hasta: &hasta},
+			sql: `WHERE t."fechaIngreso" >= $1 AND t."fechaIngreso" < $2 ` +
+				`ORDER BY t."fechaIngreso" DESC, t.id::text COLLATE "C" DESC LIMIT $3`,
+			args: []driver.Value{desde, hasta, int64(11)},
+		},
+		{
+			nombre: "rango de salida excluye abiertos",
+			filtro: models.FiltroBusquedaTickets{Campo: models.CampoSalida, Desde: &desde, Hasta: &hasta},
+			sql: `WHERE t."fechaSalida" IS NOT NULL AND t."fechaSalida" >= $1 AND t."fechaSalida" < $2 ` +
+				`ORDER BY t."fechaSalida" DESC, t.id::text COLLATE "C" DESC LIMIT $3`,
+			args: []driver.Value{desde, hasta, int64(11)},
+		},
+		{
+			nombre: "sección y placa",
+			filtro: models.FiltroBusquedaTickets{Seccion: "A", Placa: "ABC1"},
+			sql: `WHERE upper(s.letra_seccion) = $1 ` +
+				`AND regexp_replace(upper(v.placa), '[^A-Z0-9]', '', 'g') LIKE '%' || $2 || '%' ` +
+				`ORDER BY t."fechaIngreso" DESC, t.id::text COLLATE "C" DESC LIMIT $3`,
+			args: []driver.Value{"A", "ABC1", int64(11)},
+		},
+		{
+			nombre: "pagados",
+			filtro: models.FiltroBusquedaTickets{Pagado: &verdadero},
+			sql:    `WHERE t."detallePagoId" IS NOT NULL ORDER BY t."fechaIngreso" DESC, t.id::text COLLATE "C" DESC LIMIT $1`,
+			args:   []driver.Value{int64(11)},
+		},
+		{
+			nombre: "sin pagar",
+			filtro: models.FiltroBusquedaTickets{Pagado: &falso},
+			sql:    `WHERE t."detallePagoId" IS NULL ORDER BY t."fechaIngreso" DESC, t.id::text COLLATE "C" DESC LIMIT $1`,
+			args:   []driver.Value{int64(11)},
+		},
+		{
+			nombre: "duración mínima y máxima",
+			filtro: models.FiltroBusquedaTickets{MinMinutos: &min, MaxMinutos: &max},
+			sql: `WHERE (COALESCE(t."fechaSalida", $1) - t."fechaIngreso") >= make_interval(mins => $2) ` +
+				`AND (COALESCE(t."fechaSalida", $1) - t."fechaIngreso") < make_interval(mins => $3) ` +
+				`ORDER BY t."fechaIngreso" DESC, t.id::text COLLATE "C" DESC LIMIT $4`,
+			// El máximo es inclusivo: < max+1 minutos
+			args: []driver.Value{ahora, int64(30), int64(121), int64(11)},
+		},
+		{
+			nombre: "solo duración máxima",
+			filtro: models.FiltroBusquedaTickets{MaxMinutos: &max},
+			sql: `WHERE (COALESCE(t."fechaSalida", $1) - t."fechaIngreso") < make_interval(mins => $2) ` +
+				`ORDER BY t."fechaIngreso" DESC, t.id::text COLLATE "C" DESC LIMIT $3`,
+			args: []driver.Value{ahora, int64(121), int64(11)},
+		},
+		{
+			nombre:  "cursor",
+			despues: cursor,
+			sql: `WHERE (t."fechaIngreso", t.id::text COLLATE "C") < ($1, $2) ` +
+				`ORDER BY t."fechaIngreso" DESC, t.id::text COLLATE "C" DESC LIMIT $3`,
+			args: []driver.Value{cursor.Fecha, "t-9", int64(11)},
+		},
+		{
+			nombre:  "cursor por salida con filtros",
+			filtro:  models.FiltroBusquedaTickets{Campo: models.CampoSalida, Desde: &desde, Seccion: "B"},
+			despues: cursor,
+			sql: `WHERE t."fechaSalida" IS NOT NULL AND t."fechaSalida" >= $1 AND upper(s.letra_seccion) = $2 ` +
+				`AND (t."fechaSalida", t.id::text COLLATE "C") < ($3, $4) ` +
+				`ORDER BY t."fechaSalida" DESC, t.id::text COLLATE "C" DESC LIMIT $5`,
+			args: []driver.Value{desde, "B", cursor.Fecha, "t-9", int64(11)},
+		},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			// La consulta debe ser selectTicketDetalle seguida exactamente del WHERE/ORDER esperado
+			esperado := compactar(selectTicketDetalle) + " " + caso.sql
+			matcher := sqlmock.QueryMatcherFunc(func(_, actual string) error {
+				if got := compactar(actual); got != esperado {
+					return fmt.Errorf("SQL:\n%s\nse esperaba:\n%s", strings.TrimPrefix(got, compactar(selectTicketDetalle)), caso.sql)
+				}
+				return nil
+			})
+			db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(matcher))
+			if err != nil {
+				t.Fatalf("sqlmock: %v", err)
+			}
+			defer db.Close()
+
+			mock.ExpectQuery("").WithArgs(caso.args...).WillReturnRows(sqlmock.NewRows(columnasTicket))
+
+			if _, err := NewTicketRepository(db).BuscarTickets(context.Background(), caso.filtro, caso.despues, 11, ahora); err != nil {
+				t.Fatalf("BuscarTickets: %v", err)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -46,3 +46,36 @@ func (r *VehiculoRepository) GetVehiculoByID(ctx context.Context, id string) (*m
 
 	return &vehiculo, nil
 }
+
+// BuscarPorPlaca obtiene hasta limite vehículos cuya placa, sin guiones ni espacios,
+// contiene placa (ya normalizada). La coincidencia exacta va primero.
+func (r *VehiculoRepository) BuscarPorPlaca(ctx context.Context, placa string, limite int) ([]models.ResultadoPlaca, error) {
+	query := `
+		SELECT v.id, v.placa, v.marca, v.modelo, tv.categoria
+		FROM vehiculo v
+		LEFT JOIN tipo_vehiculo tv ON tv.id = v."tipoVehiculoId"
+		WHERE regexp_replace(upper(v.placa), '[^A-Z0-9]', '', 'g') LIKE '%' || $1 || '%'
+		ORDER BY regexp_replace(upper(v.placa), '[^A-Z0-9]', '', 'g') = $1 DESC, v.placa
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, placa, limite)
+	if err != nil {
+		return nil, fmt.Errorf("error al buscar vehículos por placa: %w", err)
+	}
+	defer rows.Close()
+
+	resultados := []models.ResultadoPlaca{}
+	for rows.Next() {
+		var resultado models.ResultadoPlaca
+		var marca, modelo, categoria sql.NullString
+		if err := rows.Scan(&resultado.VehiculoID, &resultado.Placa, &marca, &modelo, &categoria); err != nil {
+			return nil, fmt.Errorf("error al escanear vehículo: %w", err)
+		}
+		resultado.Marca = marca.String
+		resultado.Modelo = modelo.String
+		resultado.Categoria = categoria.String
+		resultados = append(resultados, resultado)
+	}
+	return resultados, rows.Err()
+}
@@ -0,0 +1,100 @@
+// Package capacidad vigila la ocupación de cada sección y decide cuándo alertar que
+// está por llenarse o llena. Para no alternar en cada ciclo, una alerta solo se
+// despeja cuando la ocupación baja del umbral menos un margen (histéresis).
+package capacidad
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+// Niveles de una alerta de capacidad, de menor a mayor
+const (
+	NivelNormal      = "normal"
+	NivelAdvertencia = "advertencia"
+	NivelLleno       = "lleno"
+)
+
+var niveles = []string{NivelNormal, NivelAdvertencia, NivelLleno}
+
+// Monitor recuerda el nivel vigente de cada sección. El estado vive en memoria: tras
+// un reinicio las secciones sobre un umbral se alertan una vez más.
+type Monitor struct {
+	umbrales *Umbrales
+
+	mu      sync.Mutex
+	estados map[string]int // sección -> índice en niveles
+}
+
+// NewMonitor crea un monitor con los umbrales indicados
+func NewMonitor(umbrales *Umbrales) *Monitor {
+	return &Monitor{umbrales: umbrales, estados: make(map[string]int)}
+}
+
+// Evaluar devuelve una alerta por cada sección cuyo nivel cambió: al subir en cuanto
+// alcanza el umbral, y al bajar solo cuando queda por debajo del umbral menos el
+// margen. Las secciones que ya no existen se olvidan.
+func (m *Monitor) Evaluar(secciones []models.EspaciosPorSeccion, ahora time.Time) []models.CapacidadAlerta {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	vigentes := make(map[string]bool, len(secciones))
+	var alertas []models.CapacidadAlerta
+
+	for _, seccion := range secciones {
+		letra := strings.ToUpper(seccion.SeccionLetra)
+		if seccion.TotalEspacios == 0 {
+			continue
+		}
+		vigentes[letra] = true
+
+		porcentaje := float64(seccion.EspaciosOcupados) * 100 / float64(seccion.TotalEspacios)
+		umbral := m.umbrales.De(letra)
+		anterior := m.estados[letra]
+		nivel := m.nivel(anterior, porcentaje, umbral)
+		if nivel == anterior {
+			continue
+		}
+		m.estados[letra] = nivel
+
+		alertas = append(alertas, models.CapacidadAlerta{
+			SeccionLetra:        seccion.SeccionLetra,
+			Nivel:               niveles[nivel],
+			NivelAnterior:       niveles[anterior],
+			Porcentaje:          math.Round(porcentaje*10) / 10,
+			EspaciosOcupados:    seccion.EspaciosOcupados,
+			EspaciosDisponibles: seccion.EspaciosDisponibles,
+			TotalEspacios:       seccion.TotalEspacios,
+			UmbralAdvertencia:   umbral.Advertencia,
+			UmbralLleno:         umbral.Lleno,
+			Timestamp:           ahora,
+		})
+	}
+
+	for letra := range m.estados {
+		if !vigentes[letra] {
+			delete(m.estados, letra)
+		}
+	}
+
+	return alertas
+}
+
+// nivel calcula el nuevo nivel a partir del anterior. Sube al alcanzar un umbral y
+// baja de un nivel solo si la ocupación queda por debajo de su umbral menos el margen.
+func (m *Monitor) nivel(anterior int, porcentaje float64, umbral Umbral) int {
+	limites := []float64{0, umbral.Advertencia, umbral.Lleno}
+
+	nivel := anterior
+	for nivel < len(limites)-1 && porcentaje >= limites[nivel+1] {
+		nivel++
+	}
+	for nivel > 0 && porcentaje < limites[nivel]-m.umbrales.margen {
+		nivel--
+	}
+	return nivel
+}
@@ -0,0 +1,138 @@
+package capacidad
+
+import (
+	"testing"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+// seccionPrueba sección con ocupados de total espacios
+func seccionPrueba(letra string, ocupados, total int) models.EspaciosPorSeccion {
+	return models.EspaciosPorSeccion{
+		SeccionLetra:        letra,
+		TotalEspacios:       total,
+		EspaciosOcupados:    ocupados,
+		EspaciosDisponibles: total - ocupados,
+	}
+}
+
+// transicion nivel anterior y nuevo de una alerta ("" = sin alerta)
+type transicion struct{ anterior, nivel string }
+
+func TestMonitorEvaluar(t *testing.T) {
+	// Sección de 100 espacios: ocupados = porcentaje
+	casos := []struct {
+		nombre string
+		pasos  []int
+		want   []transicion
+	}{
+		{
+			nombre: "sube por ambos niveles en un ciclo",
+			pasos:  []int{10, 96},
+			want:   []transicion{{}, {NivelNormal, NivelLleno}},
+		},
+		{
+			nombre: "sube de a un nivel",
+			pasos:  []int{80, 85, 95, 99},
+			want:   []transicion{{NivelNormal, NivelAdvertencia}, {}, {NivelAdvertencia, NivelLleno}, {}},
+		},
+		{
+			nombre: "se mantiene dentro del margen",
+			pasos:  []int{80, 79, 77, 75, 80},
+			want:   []transicion{{NivelNormal, NivelAdvertencia}, {}, {}, {}, {}},
+		},
+		{
+			nombre: "despeja solo por debajo de umbral menos margen",
+			pasos:  []int{80, 75, 74, 79, 80},
+			want:   []transicion{{NivelNormal, NivelAdvertencia}, {}, {NivelAdvertencia, NivelNormal}, {}, {NivelNormal, NivelAdvertencia}},
+		},
+		{
+			nombre: "baja de lleno a advertencia con margen",
+			pasos:  []int{95, 90, 89},
+			want:   []transicion{{NivelNormal, NivelLleno}, {}, {NivelLleno, NivelAdvertencia}},
+		},
+		{
+			nombre: "baja de lleno a normal en un ciclo",
+			pasos:  []int{96, 10},
+			want:   []transicion{{NivelNormal, NivelLleno}, {NivelLleno, NivelNormal}},
+		},
+		{
+			nombre: "de lleno a advertencia sin despejar del todo",
+			pasos:  []int{96, 76},
+			want:   []transicion{{NivelNormal, NivelLleno}, {NivelLleno, NivelAdvertencia}},
+		},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			umbrales, err := ParseUmbrales(Umbral{Advertencia: 80, Lleno: 95}, 5, "")
+			if err != nil {
+				t.Fatalf("ParseUmbrales: %v", err)
+			}
+			m := NewMonitor(umbrales)
+
+			for i, ocupados := range caso.pasos {
+				alertas := m.Evaluar([]models.EspaciosPorSeccion{seccionPrueba("A", ocupados, 100)}, time.Now())
+				var got transicion
+				if len(alertas) > 1 {
+					t.Fatalf("paso %d (%d%%): %d alertas, se esperaba como máximo una", i, ocupados, len(alertas))
+				}
+				if len(alertas) == 1 {
+					got = transicion{alertas[0].NivelAnterior, alertas[0].Nivel}
+				}
+				if got != caso.want[i] {
+					t.Errorf("paso %d (%d%%): alerta %v, se esperaba %v", i, ocupados, got, caso.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMonitorPorSeccion(t *testing.T) {
+	umbrales, err := ParseUmbrales(Umbral{Advertencia: 80, Lleno: 95}, 5, "B=50/60")
+	if err != nil {
+		t.Fatalf("ParseUmbrales: %v", err)
+	}
+	m := NewMonitor(umbrales)
+	ahora := time.Now()
+	secciones := []models.EspaciosPorSeccion{
+		seccionPrueba("A", 7, 9),
+		seccionPrueba("b", 7, 9),
+		seccionPrueba("C", 0, 0), // sin espacios: se ignora
+	}
+
+	// Con 77,8% solo B supera su umbral específico; la letra no distingue mayúsculas
+	alertas := m.Evaluar(secciones, ahora)
+	want := models.CapacidadAlerta{
+		SeccionLetra:        "b",
+		Nivel:               NivelLleno,
+		NivelAnterior:       NivelNormal,
+		Porcentaje:          77.8,
+		EspaciosOcupados:    7,
+		EspaciosDisponibles: 2,
+		TotalEspacios:       9,
+		UmbralAdvertencia:   50,
+		UmbralLleno:         60,
+		Timestamp:           ahora,
+	}
+	if len(alertas) != 1 || alertas[0] != want {
+		t.Fatalf("alertas = %+v, se esperaba solo %+v", alertas, want)
+	}
+
+	if alertas := m.Evaluar(secciones, ahora); len(alertas) != 0 {
+		t.Errorf("sin cambios: alertas %+v, se esperaba ninguna", alertas)
+	}
+
+	// Una sección que desaparece se olvida: si vuelve se alerta de nuevo
+	if alertas := m.Evaluar(secciones[:1], ahora); len(alertas) != 0 {
+		t.Errorf("sin la sección B: alertas %+v, se esperaba ninguna", alertas)
+	}
+	if _, ok := m.estados["B"]; ok {
+		t.Error("la sección B sigue en el estado tras desaparecer")
+	}
+	alertas = m.Evaluar(secciones, ahora)
+	if len(alertas) != 1 || alertas[0].NivelAnterior != NivelNormal || alertas[0].Nivel != NivelLleno {
+		t.Errorf("la sección B vuelve: alertas %+v, se esperaba normal -> lleno", alertas)
+	}
+}
@@ -0,0 +1,78 @@
+package capacidad
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Umbral porcentajes de ocupación de una sección
+type Umbral struct {
+	Advertencia float64 // desde este porcentaje la sección está por llenarse
+	Lleno       float64 // desde este porcentaje la sección se considera llena
+}
+
+// validar verifica que 0 < advertencia <= lleno <= 100
+func (u Umbral) validar() error {
+	if u.Advertencia <= 0 || u.Lleno > 100 || u.Advertencia > u.Lleno {
+		return fmt.Errorf("umbrales %.0f/%.0f: se espera 0 < advertencia <= lleno <= 100", u.Advertencia, u.Lleno)
+	}
+	return nil
+}
+
+// Umbrales umbral por defecto, umbrales por sección y margen de histéresis
+type Umbrales struct {
+	defecto    Umbral
+	porSeccion map[string]Umbral
+	margen     float64
+}
+
+// ParseUmbrales crea los umbrales con el valor por defecto, el margen en puntos
+// porcentuales que la ocupación debe bajar del umbral para despejar la alerta, y los
+// umbrales específicos por sección separados por comas con el formato
+// "<letra>=<advertencia>/<lleno>" (ej. "A=70/90,C=85/98").
+func ParseUmbrales(defecto Umbral, margen float64, porSeccion string) (*Umbrales, error) {
+	if err := defecto.validar(); err != nil {
+		return nil, err
+	}
+	if margen < 0 || margen >= 100 {
+		return nil, fmt.Errorf("margen de histéresis %.0f fuera de rango", margen)
+	}
+
+	u := &Umbrales{defecto: defecto, porSeccion: make(map[string]Umbral), margen: margen}
+	for _, regla := range strings.Split(porSeccion, ",") {
+		regla = strings.TrimSpace(regla)
+		if regla == "" {
+			continue
+		}
+
+		letra, valores, ok := strings.Cut(regla, "=")
+		advertencia, lleno, ok2 := strings.Cut(valores, "/")
+		letra = strings.ToUpper(strings.TrimSpace(letra))
+		if !ok || !ok2 || letra == "" {
+			return nil, fmt.Errorf("umbral %q: se espera <letra>=<advertencia>/<lleno>", regla)
+		}
+
+		var umbral Umbral
+		var err error
+		if umbral.Advertencia, err = strconv.ParseFloat(strings.TrimSpace(advertencia), 64); err != nil {
+			return nil, fmt.Errorf("umbral %q: advertencia inválida", regla)
+		}
+		if umbral.Lleno, err = strconv.ParseFloat(strings.TrimSpace(lleno), 64); err != nil {
+			return nil, fmt.Errorf("umbral %q: lleno inválido", regla)
+		}
+		if err := umbral.validar(); err != nil {
+			return nil, fmt.Errorf("umbral %q: %w", regla, err)
+		}
+		u.porSeccion[letra] = umbral
+	}
+	return u, nil
+}
+
+// De devuelve el umbral de la sección indicada
+func (u *Umbrales) De(seccionLetra string) Umbral {
+	if umbral, ok := u.porSeccion[strings.ToUpper(seccionLetra)]; ok {
+		return umbral
+	}
+	return u.defecto
+}
@@ -0,0 +1,72 @@
+package capacidad
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseUmbrales(t *testing.T) {
+	defecto := Umbral{Advertencia: 80, Lleno: 95}
+
+	umbrales, err := ParseUmbrales(defecto, 5, " a=70/90 ,, C = 85.5 / 98,")
+	if err != nil {
+		t.Fatalf("ParseUmbrales: %v", err)
+	}
+	for _, caso := range []struct {
+		letra string
+		want  Umbral
+	}{
+		{"A", Umbral{70, 90}},
+		{"a", Umbral{70, 90}},
+		{"C", Umbral{85.5, 98}},
+		{"B", defecto},
+	} {
+		if got := umbrales.De(caso.letra); got != caso.want {
+			t.Errorf("De(%q) = %+v, se esperaba %+v", caso.letra, got, caso.want)
+		}
+	}
+	if umbrales.margen != 5 {
+		t.Errorf("margen = %v, se esperaba 5", umbrales.margen)
+	}
+
+	// Advertencia y lleno iguales: la sección pasa directo a lleno
+	if _, err := ParseUmbrales(Umbral{Advertencia: 100, Lleno: 100}, 0, "A=50/50"); err != nil {
+		t.Errorf("umbrales iguales: %v", err)
+	}
+}
+
+func TestParseUmbralesInvalidos(t *testing.T) {
+	defecto := Umbral{Advertencia: 80, Lleno: 95}
+
+	casos := []struct {
+		nombre     string
+		defecto    Umbral
+		margen     float64
+		porSeccion string
+		error      string
+	}{
+		{"defecto invertido", Umbral{Advertencia: 95, Lleno: 80}, 5, "", "se espera 0 < advertencia"},
+		{"defecto sin advertencia", Umbral{Lleno: 80}, 5, "", "se espera 0 < advertencia"},
+		{"defecto sobre 100", Umbral{Advertencia: 80, Lleno: 101}, 5, "", "se espera 0 < advertencia"},
+		{"margen negativo", defecto, -1, "", "margen de histéresis"},
+		{"margen de 100", defecto, 100, "", "margen de histéresis"},
+		{"sin igual", defecto, 5, "A70/90", `umbral "A70/90": se espera`},
+		{"sin barra", defecto, 5, "A=70", `umbral "A=70": se espera`},
+		{"sin letra", defecto, 5, "=70/90", `umbral "=70/90": se espera`},
+		{"advertencia no numérica", defecto, 5, "A=x/90", "advertencia inválida"},
+		{"lleno no numérico", defecto, 5, "A=70/", "lleno inválido"},
+		{"sección invertida", defecto, 5, "A=70/90,B=90/70", `umbral "B=90/70": umbrales 90/70`},
+		{"sección sobre 100", defecto, 5, "A=70/120", "se espera 0 < advertencia"},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			umbrales, err := ParseUmbrales(caso.defecto, caso.margen, caso.porSeccion)
+			if err == nil {
+				t.Fatalf("ParseUmbrales = %+v, se esperaba un error", umbrales)
+			}
+			if !strings.Contains(err.Error(), caso.error) {
+				t.Errorf("error %q, se esperaba que contenga %q", err, caso.error)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+// Tamaño de página de la búsqueda de tickets
+const (
+	LimiteBusquedaDefecto = 50
+	MaxLimiteBusqueda     = 200
+)
+
+// BuscarTickets busca tickets abiertos y cerrados con el filtro, del más reciente al
+// más antiguo según la fecha del campo filtrado. cursor es el SiguienteCursor de la
+// página anterior (vacío = primera página); limite 0 usa LimiteBusquedaDefecto.
+func (s *Service) BuscarTickets(ctx context.Context, filtro models.FiltroBusquedaTickets, cursor string, limite int) (*models.PaginaTickets, error) {
+	filtro, err := normalizarBusqueda(filtro)
+	if err != nil {
+		return nil, err
+	}
+	if limite == 0 {
+		limite = LimiteBusquedaDefecto
+	}
+	if limite < 0 || limite > MaxLimiteBusqueda {
+		return nil, fmt.Errorf("%w: limite debe estar entre 1 y %d", ErrFiltroInvalido, MaxLimiteBusqueda)
+	}
+
+	var despues *models.CursorTickets
+	if cursor != "" {
+		if despues, err = models.ParseCursorTickets(cursor); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrFiltroInvalido, err)
+		}
+	}
+
+	// Un ticket de más para saber si hay otra página
+	ahora := time.Now()
+	tickets, err := s.source.BuscarTickets(ctx, filtro, despues, limite+1, ahora)
+	if err != nil {
+		log.Printf("Error buscando tickets (%s): %v", s.source.Name(), err)
+		return nil, err
+	}
+
+	pagina := &models.PaginaTickets{Limite: limite, Filtro: filtro}
+	if len(tickets) > limite {
+		tickets = tickets[:limite]
+		ultimo := tickets[limite-1]
+		pagina.SiguienteCursor = models.CursorTickets{Fecha: *filtro.Fecha(ultimo), ID: ultimo.ID}.String()
+	}
+	pagina.Tickets = models.TicketsConCobro(tickets, ahora)
+	if pagina.Tickets == nil {
+		pagina.Tickets = []models.Ticket{}
+	}
+	return pagina, nil
+}
+
+// normalizarBusqueda valida el filtro y lo deja en la forma que esperan las fuentes
+func normalizarBusqueda(filtro models.FiltroBusquedaTickets) (models.FiltroBusquedaTickets, error) {
+	switch filtro.Campo {
+	case "":
+		filtro.Campo = models.CampoIngreso
+	case models.CampoIngreso, models.CampoSalida:
+	default:
+		return filtro, fmt.Errorf("%w: campo %q desconocido (ingreso o salida)", ErrFiltroInvalido, filtro.Campo)
+	}
+
+	if filtro.Desde != nil && filtro.Hasta != nil && !filtro.Hasta.After(*filtro.Desde) {
+		return filtro, fmt.Errorf("%w: hasta debe ser posterior a desde", ErrRangoInvalido)
+	}
+	if (filtro.MinMinutos != nil && *filtro.MinMinutos < 0) || (filtro.MaxMinutos != nil && *filtro.MaxMinutos < 0) {
+		return filtro, fmt.Errorf("%w: la duración no puede ser negativa", ErrFiltroInvalido)
+	}
+	if filtro.MinMinutos != nil && filtro.MaxMinutos != nil && *filtro.MinMinutos > *filtro.MaxMinutos {
+		return filtro, fmt.Errorf("%w: min_minutos no puede superar max_minutos", ErrFiltroInvalido)
+	}
+
+	filtro.Seccion = strings.ToUpper(strings.TrimSpace(filtro.Seccion))
+	filtro.Placa = models.NormalizarPlaca(filtro.Placa)
+	return filtro, nil
+}
@@ -0,0 +1,159 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+// fuenteBusqueda fuente que pagina tickets en memoria con PaginarTickets y registra el
+// límite pedido
+type fuenteBusqueda struct {
+	fuenteFalsa
+	tickets []models.Ticket
+	limites []int
+}
+
+func (f *fuenteBusqueda) BuscarTickets(_ context.Context, filtro models.FiltroBusquedaTickets, despues *models.CursorTickets, limite int, ahora time.Time) ([]models.Ticket, error) {
+	f.limites = append(f.limites, limite)
+	return models.PaginarTickets(f.tickets, filtro, despues, limite, ahora), nil
+}
+
+// ticketsBusqueda cinco tickets cerrados, dos de ellos con la misma salida, y uno abierto
+func ticketsBusqueda() []models.Ticket {
+	base := time.Now().Add(-24 * time.Hour).Truncate(time.Hour)
+	salida := func(d time.Duration) *time.Time { s := base.Add(d); return &s }
+	return []models.Ticket{
+		{ID: "t1", FechaIngreso: base, FechaSalida: salida(time.Hour)},
+		{ID: "t2", FechaIngreso: base, FechaSalida: salida(2 * time.Hour)},
+		{ID: "t3", FechaIngreso: base, FechaSalida: salida(2 * time.Hour)},
+		{ID: "t4", FechaIngreso: base.Add(time.Hour), FechaSalida: salida(3 * time.Hour)},
+		{ID: "t5", FechaIngreso: base.Add(2 * time.Hour), FechaSalida: salida(3 * time.Hour)},
+		{ID: "t6", FechaIngreso: base.Add(3 * time.Hour)},
+	}
+}
+
+func TestBuscarTicketsPaginas(t *testing.T) {
+	casos := []struct {
+		nombre string
+		campo  string
+		want   []string
+	}{
+		{"por ingreso", models.CampoIngreso, []string{"t6", "t5", "t4", "t3", "t2", "t1"}},
+		{"por salida sin abiertos", models.CampoSalida, []string{"t5", "t4", "t3", "t2", "t1"}},
+	}
+	for _, caso := range casos {
+		for limite := 1; limite <= 7; limite++ {
+			fuente := &fuenteBusqueda{tickets: ticketsBusqueda()}
+			s := NewService(fuente, time.Minute)
+
+			var ids []string
+			cursor := ""
+			for paginas := 0; ; paginas++ {
+				if paginas > 7 {
+					t.Fatalf("%s con limite %d: el cursor no avanza (%v)", caso.nombre, limite, ids)
+				}
+				pagina, err := s.BuscarTickets(context.Background(), models.FiltroBusquedaTickets{Campo: caso.campo}, cursor, limite)
+				if err != nil {
+					t.Fatalf("BuscarTickets: %v", err)
+				}
+				if len(pagina.Tickets) > limite {
+					t.Fatalf("%d tickets con limite %d", len(pagina.Tickets), limite)
+				}
+				for _, ticket := range pagina.Tickets {
+					ids = append(ids, ticket.ID)
+				}
+				if pagina.SiguienteCursor == "" {
+					break
+				}
+				cursor = pagina.SiguienteCursor
+			}
+
+			if !reflect.DeepEqual(ids, caso.want) {
+				t.Errorf("%s con limite %d: %v, se esperaba %v", caso.nombre, limite, ids, caso.want)
+			}
+			for _, pedido := range fuente.limites {
+				if pedido != limite+1 {
+					t.Errorf("la fuente recibió limite %d, se esperaba %d", pedido, limite+1)
+				}
+			}
+		}
+	}
+}
+
+func TestBuscarTicketsSiguienteCursor(t *testing.T) {
+	s := NewService(&fuenteBusqueda{tickets: ticketsBusqueda()}, time.Minute)
+	filtro := models.FiltroBusquedaTickets{Campo: models.CampoSalida}
+
+	// Con exactamente limite resultados no hay otra página
+	pagina, err := s.BuscarTickets(context.Background(), filtro, "", 5)
+	if err != nil {
+		t.Fatalf("BuscarTickets: %v", err)
+	}
+	if len(pagina.Tickets) != 5 || pagina.SiguienteCursor != "" {
+		t.Errorf("limite 5: %d tickets y cursor %q, se esperaban 5 sin cursor", len(pagina.Tickets), pagina.SiguienteCursor)
+	}
+
+	// Con uno más el cursor apunta al último ticket devuelto por la fecha filtrada
+	pagina, err = s.BuscarTickets(context.Background(), filtro, "", 2)
+	if err != nil {
+		t.Fatalf("BuscarTickets: %v", err)
+	}
+	cursor, err := models.ParseCursorTickets(pagina.SiguienteCursor)
+	if err != nil {
+		t.Fatalf("SiguienteCursor %q: %v", pagina.SiguienteCursor, err)
+	}
+	ultimo := pagina.Tickets[len(pagina.Tickets)-1]
+	if cursor.ID != ultimo.ID || !cursor.Fecha.Equal(*ultimo.FechaSalida) {
+		t.Errorf("cursor = %+v, se esperaba la salida e ID de %s", *cursor, ultimo.ID)
+	}
+
+	// Sin resultados la página es vacía, no nil
+	desde := time.Now().Add(time.Hour)
+	pagina, err = s.BuscarTickets(context.Background(), models.FiltroBusquedaTickets{Desde: &desde}, "", 2)
+	if err != nil {
+		t.Fatalf("BuscarTickets: %v", err)
+	}
+	if pagina.Tickets == nil || len(pagina.Tickets) != 0 || pagina.SiguienteCursor != "" {
+		t.Errorf("sin resultados: %v y cursor %q, se esperaba [] sin cursor", pagina.Tickets, pagina.SiguienteCursor)
+	}
+}
+
+func TestBuscarTicketsInvalida(t *testing.T) {
+	ahora := time.Now()
+	antes := ahora.Add(-time.Hour)
+	negativo, cinco := -1, 5
+
+	casos := []struct {
+		nombre string
+		filtro models.FiltroBusquedaTickets
+		cursor string
+		limite int
+		err    error
+	}{
+		{"cursor ilegible", models.FiltroBusquedaTickets{}, "!!!", 0, ErrFiltroInvalido},
+		{"cursor sin ID", models.FiltroBusquedaTickets{}, models.CursorTickets{Fecha: ahora}.String(), 0, ErrFiltroInvalido},
+		{"limite negativo", models.FiltroBusquedaTickets{}, "", -1, ErrFiltroInvalido},
+		{"limite excesivo", models.FiltroBusquedaTickets{}, "", MaxLimiteBusqueda + 1, ErrFiltroInvalido},
+		{"campo desconocido", models.FiltroBusquedaTickets{Campo: "pago"}, "", 0, ErrFiltroInvalido},
+		{"rango invertido", models.FiltroBusquedaTickets{Desde: &ahora, Hasta: &antes}, "", 0, ErrRangoInvalido},
+		{"duración negativa", models.FiltroBusquedaTickets{MinMinutos: &negativo}, "", 0, ErrFiltroInvalido},
+		{"mínimo mayor que máximo", models.FiltroBusquedaTickets{MinMinutos: &cinco, MaxMinutos: new(int)}, "", 0, ErrFiltroInvalido},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			fuente := &fuenteBusqueda{}
+			_, err := NewService(fuente, time.Minute).BuscarTickets(context.Background(), caso.filtro, caso.cursor, caso.limite)
+			if !errors.Is(err, caso.err) {
+				t.Errorf("err = %v, se esperaba %v", err, caso.err)
+			}
+			if len(fuente.limites) != 0 {
+				t.Error("la búsqueda inválida llegó a la fuente")
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/repository/interfaces"
+)
+
+// failoverCooldown tiempo que se usa directamente la fuente secundaria tras un fallo
+// de la primaria, antes de volver a intentarla
+const failoverCooldown = 30 * time.Second
+
+// FailoverSource fuente de datos que consulta la primaria y, si falla, la secundaria
+// (MODE=hybrid: base de datos con respaldo REST o al revés)
+type FailoverSource struct {
+	primary  interfaces.DataSource
+	fallback interfaces.DataSource
+
+	mu          sync.Mutex
+	primaryDown time.Time // hasta cuándo se salta la primaria
+}
+
+// NewFailoverSource crea una fuente con failover de primary a fallback
+func NewFailoverSource(primary, fallback interfaces.DataSource) *FailoverSource {
+	return &FailoverSource{primary: primary, fallback: fallback}
+}
+
+// Name identifica la fuente en logs
+func (f *FailoverSource) Name() string {
+	return f.primary.Name() + "+" + f.fallback.Name()
+}
+
+// GetDashboardData obtiene los datos del dashboard
+func (f *FailoverSource) GetDashboardData(ctx context.Context) (*models.DashboardData, error) {
+	return withFailover(ctx, f, func(source interfaces.DataSource) (*models.DashboardData, error) {
+		return source.GetDashboardData(ctx)
+	})
+}
+
+// GetEspaciosPorSeccion obtiene espacios agrupados por sección
+func (f *FailoverSource) GetEspaciosPorSeccion(ctx context.Context) ([]models.EspaciosPorSeccion, error) {
+	return withFailover(ctx, f, func(source interfaces.DataSource) ([]models.EspaciosPorSeccion, error) {
+		return source.GetEspaciosPorSeccion(ctx)
+	})
+}
+
+// GetEspaciosDisponibles obtiene lista de espacios disponibles
+func (f *FailoverSource) GetEspaciosDisponibles(ctx context.Context) ([]models.EspacioDetalle, error) {
+	return withFailover(ctx, f, func(source interfaces.DataSource) ([]models.EspacioDetalle, error) {
+		return source.GetEspaciosDisponibles(ctx)
+	})
+}
+
+// GetTicketsActivos obtiene tickets sin fecha de salida
+func (f *FailoverSource) GetTicketsActivos(ctx context.Context) ([]models.Ticket, error) {
+	return withFailover(ctx, f, func(source interfaces.DataSource) ([]models.Ticket, error) {
+		return source.GetTicketsActivos(ctx)
+	})
+}
+
+// GetTicketsActivosByAuthUser obtiene tickets activos de los vehículos del usuario
+func (f *FailoverSource) GetTicketsActivosByAuthUser(ctx context.Context, authUserID string) ([]models.Ticket, error) {
+	return withFailover(ctx, f, func(source interfaces.DataSource) ([]models.Ticket, error) {
+		return source.GetTicketsActivosByAuthUser(ctx, authUserID)
+	})
+}
+
+// GetDesgloseIngresos desglosa la recaudación de [desde, hasta)
+func (f *FailoverSource) GetDesgloseIngresos(ctx context.Context, desde, hasta time.Time) (*models.DesgloseIngresos, error) {
+	return withFailover(ctx, f, func(source interfaces.DataSource) (*models.DesgloseIngresos, error) {
+		return source.GetDesgloseIngresos(ctx, desde, hasta)
+	})
+}
+
+// GetFlujoHorario cuenta ingresos y salidas de [desde, hasta) por sección y hora
+func (f *FailoverSource) GetFlujoHorario(ctx context.Context, desde, hasta time.Time) ([]models.FlujoHora, error) {
+	return withFailover(ctx, f, func(source interfaces.DataSource) ([]models.FlujoHora, error) {
+		return source.GetFlujoHorario(ctx, desde, hasta)
+	})
+}
+
+// BuscarPlaca busca vehículos por placa con su ticket abierto y sus últimas visitas
+func (f *FailoverSource) BuscarPlaca(ctx context.Context, placa string, limite, visitas int) ([]models.ResultadoPlaca, error) {
+	return withFailover(ctx, f, func(source interfaces.DataSource) ([]models.ResultadoPlaca, error) {
+		return source.BuscarPlaca(ctx, placa, limite, visitas)
+	})
+}
+
+// BuscarTickets busca tickets históricos paginados por cursor
+func (f *FailoverSource) BuscarTickets(ctx context.Context, filtro models.FiltroBusquedaTickets, despues *models.CursorTickets, limite int, ahora time.Time) ([]models.Ticket, error) {
+	return withFailover(ctx, f, func(source interfaces.DataSource) ([]models.Ticket, error) {
+		return source.BuscarTickets(ctx, filtro, despues, limite, ahora)
+	})
+}
+
+// withFailover ejecuta call en la primaria y, si falla, en la secundaria. Tras un fallo
+// la primaria se salta durante failoverCooldown para no pagar su timeout en cada consulta.
+func withFailover[T any](ctx context.Context, f *FailoverSource, call func(interfaces.DataSource) (T, error)) (T, error) {
+	if f.primaryAvailable() {
+		result, err := call(f.primary)
+		if err == nil {
+			f.markPrimary(true)
+			return result, nil
+		}
+		if errors.Is(err, context.Canceled) {
+			return result, err
+		}
+		if errors.Is(err, interfaces.ErrNotSupported) {
+			// La primaria está sana, solo no tiene esta consulta
+			return call(f.fallback)
+		}
+		log.Printf("⚠️  Fuente %s falló, usando %s: %v", f.primary.Name(), f.fallback.Name(), err)
+		f.markPrimary(false)
+	}
+
+	return call(f.fallback)
+}
+
+// primaryAvailable indica si hay que intentar la fuente primaria
+func (f *FailoverSource) primaryAvailable() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return time.Now().After(f.primaryDown)
+}
+
+// markPrimary registra el resultado de la fuente primaria
+func (f *FailoverSource) markPrimary(ok bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if ok {
+		if !f.primaryDown.IsZero() {
+			log.Printf("✅ Fuente %s disponible de nuevo", f.primary.Name())
+		}
+		f.primaryDown = time.Time{}
+		return
+	}
+	f.primaryDown = time.Now().Add(failoverCooldown)
+}
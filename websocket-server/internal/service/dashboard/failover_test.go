@@ -0,0 +1,147 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/repository/interfaces"
+)
+
+// fuenteFalsa fuente que responde err en todas las consultas (nil = respuestas vacías)
+// y cuenta las llamadas
+type fuenteFalsa struct {
+	nombre   string
+	err      error
+	llamadas int
+}
+
+func (f *fuenteFalsa) responder() error {
+	f.llamadas++
+	return f.err
+}
+
+func (f *fuenteFalsa) Name() string { return f.nombre }
+
+func (f *fuenteFalsa) GetDashboardData(context.Context) (*models.DashboardData, error) {
+	if err := f.responder(); err != nil {
+		return nil, err
+	}
+	return &models.DashboardData{}, nil
+}
+
+func (f *fuenteFalsa) GetEspaciosPorSeccion(context.Context) ([]models.EspaciosPorSeccion, error) {
+	return nil, f.responder()
+}
+
+func (f *fuenteFalsa) GetEspaciosDisponibles(context.Context) ([]models.EspacioDetalle, error) {
+	return nil, f.responder()
+}
+
+func (f *fuenteFalsa) GetTicketsActivos(context.Context) ([]models.Ticket, error) {
+	return nil, f.responder()
+}
+
+func (f *fuenteFalsa) GetTicketsActivosByAuthUser(context.Context, string) ([]models.Ticket, error) {
+	return nil, f.responder()
+}
+
+func (f *fuenteFalsa) GetDesgloseIngresos(context.Context, time.Time, time.Time) (*models.DesgloseIngresos, error) {
+	return nil, f.responder()
+}
+
+func (f *fuenteFalsa) GetFlujoHorario(context.Context, time.Time, time.Time) ([]models.FlujoHora, error) {
+	return nil, f.responder()
+}
+
+func (f *fuenteFalsa) BuscarPlaca(context.Context, string, int, int) ([]models.ResultadoPlaca, error) {
+	return nil, f.responder()
+}
+
+func (f *fuenteFalsa) BuscarTickets(context.Context, models.FiltroBusquedaTickets, *models.CursorTickets, int, time.Time) ([]models.Ticket, error) {
+	return nil, f.responder()
+}
+
+func TestFailoverSourceCooldown(t *testing.T) {
+	primary := &fuenteFalsa{nombre: "database", err: errors.New("conexión rechazada")}
+	fallback := &fuenteFalsa{nombre: "rest"}
+	source := NewFailoverSource(primary, fallback)
+	ctx := context.Background()
+
+	if _, err := source.GetDashboardData(ctx); err != nil {
+		t.Fatalf("GetDashboardData con la primaria caída: %v", err)
+	}
+	if primary.llamadas != 1 || fallback.llamadas != 1 {
+		t.Fatalf("primaria %d y secundaria %d llamadas, se esperaba 1 y 1", primary.llamadas, fallback.llamadas)
+	}
+	if hasta := time.Until(source.primaryDown); hasta <= failoverCooldown-time.Second || hasta > failoverCooldown {
+		t.Errorf("primaria saltada durante %v, se esperaba %v", hasta, failoverCooldown)
+	}
+
+	// Durante el cooldown la primaria no se intenta
+	primary.err = nil
+	if _, err := source.GetTicketsActivos(ctx); err != nil {
+		t.Fatalf("GetTicketsActivos durante el cooldown: %v", err)
+	}
+	if primary.llamadas != 1 || fallback.llamadas != 2 {
+		t.Fatalf("durante el cooldown: primaria %d y secundaria %d llamadas, se esperaba 1 y 2", primary.llamadas, fallback.llamadas)
+	}
+
+	// Vencido el cooldown se vuelve a la primaria
+	source.mu.Lock()
+	source.primaryDown = time.Now().Add(-time.Millisecond)
+	source.mu.Unlock()
+	if _, err := source.GetDashboardData(ctx); err != nil {
+		t.Fatalf("GetDashboardData tras el cooldown: %v", err)
+	}
+	if primary.llamadas != 2 || fallback.llamadas != 2 {
+		t.Fatalf("tras el cooldown: primaria %d y secundaria %d llamadas, se esperaba 2 y 2", primary.llamadas, fallback.llamadas)
+	}
+	if !source.primaryDown.IsZero() {
+		t.Errorf("la primaria respondió pero sigue marcada caída hasta %v", source.primaryDown)
+	}
+}
+
+func TestFailoverSourceNoSoportada(t *testing.T) {
+	primary := &fuenteFalsa{nombre: "graphql", err: interfaces.ErrNotSupported}
+	fallback := &fuenteFalsa{nombre: "rest"}
+	source := NewFailoverSource(primary, fallback)
+
+	if _, err := source.GetFlujoHorario(context.Background(), time.Now().Add(-time.Hour), time.Now()); err != nil {
+		t.Fatalf("GetFlujoHorario: %v", err)
+	}
+	if fallback.llamadas != 1 {
+		t.Errorf("secundaria %d llamadas, se esperaba 1", fallback.llamadas)
+	}
+	// La primaria está sana: no entra en cooldown
+	if !source.primaryAvailable() {
+		t.Error("la primaria quedó marcada caída por una consulta no soportada")
+	}
+}
+
+func TestFailoverSourceCancelada(t *testing.T) {
+	primary := &fuenteFalsa{nombre: "database", err: context.Canceled}
+	fallback := &fuenteFalsa{nombre: "rest"}
+	source := NewFailoverSource(primary, fallback)
+
+	if _, err := source.GetDashboardData(context.Background()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("GetDashboardData = %v, se esperaba context.Canceled", err)
+	}
+	if fallback.llamadas != 0 || !source.primaryAvailable() {
+		t.Errorf("una consulta cancelada usó la secundaria (%d llamadas) o marcó caída la primaria", fallback.llamadas)
+	}
+}
+
+func TestFailoverSourceAmbasCaidas(t *testing.T) {
+	errFallback := errors.New("REST API no disponible")
+	source := NewFailoverSource(
+		&fuenteFalsa{nombre: "database", err: errors.New("conexión rechazada")},
+		&fuenteFalsa{nombre: "rest", err: errFallback},
+	)
+
+	if _, err := source.GetDashboardData(context.Background()); !errors.Is(err, errFallback) {
+		t.Fatalf("GetDashboardData = %v, se esperaba el error de la secundaria", err)
+	}
+}
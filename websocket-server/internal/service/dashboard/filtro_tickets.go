@@ -0,0 +1,94 @@
+package dashboard
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+// ErrFiltroInvalido el filtro u orden de una consulta no es válido
+var ErrFiltroInvalido = errors.New("filtro inválido")
+
+// Órdenes de la lista de tickets activos
+const (
+	OrdenIngreso = "ingreso" // fecha de ingreso
+	OrdenPlaca   = "placa"
+	OrdenSeccion = "seccion" // sección y número de espacio
+)
+
+// FiltroTickets filtro y orden de la lista de tickets activos. Sin orden se devuelven
+// los ingresos más recientes primero; con orden, ascendente salvo que Desc sea true.
+type FiltroTickets struct {
+	Seccion string // letra de sección, sin distinguir mayúsculas
+	Placa   string // prefijo de placa, ignorando guiones y espacios
+	Orden   string
+	Desc    bool
+}
+
+// validar verifica el orden pedido
+func (f FiltroTickets) validar() error {
+	switch f.Orden {
+	case "", OrdenIngreso, OrdenPlaca, OrdenSeccion:
+		return nil
+	}
+	return fmt.Errorf("%w: orden %q desconocido (ingreso, placa o seccion)", ErrFiltroInvalido, f.Orden)
+}
+
+// aplicar filtra y ordena una copia de los tickets
+func (f FiltroTickets) aplicar(tickets []models.Ticket) []models.Ticket {
+	seccion := strings.ToUpper(strings.TrimSpace(f.Seccion))
+	placa := models.NormalizarPlaca(f.Placa)
+
+	filtrados := make([]models.Ticket, 0, len(tickets))
+	for _, ticket := range tickets {
+		if seccion != "" && strings.ToUpper(ticket.SeccionLetra) != seccion {
+			continue
+		}
+		if placa != "" && !strings.HasPrefix(models.NormalizarPlaca(ticket.VehiculoPlaca), placa) {
+			continue
+		}
+		filtrados = append(filtrados, ticket)
+	}
+
+	orden, desc := f.Orden, f.Desc
+	if orden == "" {
+		orden, desc = OrdenIngreso, true
+	}
+
+	sort.SliceStable(filtrados, func(i, j int) bool {
+		a, b := filtrados[i], filtrados[j]
+		if desc {
+			a, b = b, a
+		}
+		switch orden {
+		case OrdenPlaca:
+			if a.VehiculoPlaca != b.VehiculoPlaca {
+				return a.VehiculoPlaca < b.VehiculoPlaca
+			}
+		case OrdenSeccion:
+			if a.SeccionLetra != b.SeccionLetra {
+				return a.SeccionLetra < b.SeccionLetra
+			}
+			if a.EspacioNumero != b.EspacioNumero {
+				return numeroMenor(a.EspacioNumero, b.EspacioNumero)
+			}
+		}
+		return a.FechaIngreso.Before(b.FechaIngreso)
+	})
+
+	return filtrados
+}
+
+// numeroMenor compara números de espacio numéricamente cuando ambos lo son ("2" < "10")
+func numeroMenor(a, b string) bool {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	if errA == nil && errB == nil {
+		return na < nb
+	}
+	return a < b
+}
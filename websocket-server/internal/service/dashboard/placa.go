@@ -0,0 +1,58 @@
+package dashboard
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+const (
+	// minLargoPlaca letras o dígitos mínimos de una búsqueda por placa
+	minLargoPlaca = 2
+
+	// maxResultadosPlaca vehículos como máximo por búsqueda
+	maxResultadosPlaca = 10
+
+	// VisitasDefecto y MaxVisitas visitas cerradas devueltas por vehículo
+	VisitasDefecto = 5
+	MaxVisitas     = 50
+)
+
+// BuscarPlaca busca vehículos por placa completa o parcial (sin distinguir mayúsculas,
+// guiones ni espacios). Devuelve si cada uno está estacionado, con espacio, sección,
+// ingreso y cobro acumulado, y sus últimas visitas. Con visitas 0 se usan VisitasDefecto.
+func (s *Service) BuscarPlaca(ctx context.Context, placa string, visitas int) (*models.BusquedaPlaca, error) {
+	busqueda := models.NormalizarPlaca(placa)
+	if len([]rune(busqueda)) < minLargoPlaca {
+		return nil, fmt.Errorf("%w: la placa debe tener al menos %d letras o dígitos", ErrFiltroInvalido, minLargoPlaca)
+	}
+	if visitas == 0 {
+		visitas = VisitasDefecto
+	}
+	if visitas < 0 || visitas > MaxVisitas {
+		return nil, fmt.Errorf("%w: visitas debe estar entre 1 y %d", ErrFiltroInvalido, MaxVisitas)
+	}
+
+	resultados, err := s.source.BuscarPlaca(ctx, busqueda, maxResultadosPlaca, visitas)
+	if err != nil {
+		log.Printf("Error buscando placa %s (%s): %v", busqueda, s.source.Name(), err)
+		return nil, err
+	}
+
+	ahora := time.Now()
+	for i := range resultados {
+		if activo := resultados[i].TicketActivo; activo != nil {
+			conCobro := models.TicketsConCobro([]models.Ticket{*activo}, ahora)
+			resultados[i].TicketActivo = &conCobro[0]
+		}
+	}
+
+	return &models.BusquedaPlaca{
+		Placa:      busqueda,
+		Resultados: resultados,
+		Timestamp:  ahora,
+	}, nil
+}
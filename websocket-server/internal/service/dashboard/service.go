@@ -2,151 +2,174 @@ package dashboard
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"time"
 
-	"github.com/josedavid1945/estacionamiento-websocket/internal/client"
 	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
 	"github.com/josedavid1945/estacionamiento-websocket/internal/repository/interfaces"
 )
 
+// ErrRangoInvalido el rango de fechas de una consulta no es válido
+var ErrRangoInvalido = errors.New("rango de fechas inválido")
+
+// maxRangoIngresos rango máximo del desglose de ingresos
+const maxRangoIngresos = 366 * 24 * time.Hour
+
 // Service contiene la lógica de negocio del dashboard
 type Service struct {
-	dashboardRepo interfaces.DashboardRepository
-	ticketRepo    interfaces.TicketRepository
-	vehiculoRepo  interfaces.VehiculoRepository
-	restClient    *client.RestClient
-	useRestAPI    bool
+	source interfaces.DataSource
+
+	// Snapshots compartidos por todos los clientes, uno por tipo de consulta
+	dashboardCache   *snapshotCache[*models.DashboardData]
+	seccionesCache   *snapshotCache[[]models.EspaciosPorSeccion]
+	disponiblesCache *snapshotCache[[]models.EspacioDetalle]
+	ticketsCache     *snapshotCache[[]models.Ticket]
 }
 
-// NewService crea una nueva instancia del servicio
-func NewService(
-	dashboardRepo interfaces.DashboardRepository,
-	ticketRepo interfaces.TicketRepository,
-	vehiculoRepo interfaces.VehiculoRepository,
-) *Service {
-	return &Service{
-		dashboardRepo: dashboardRepo,
-		ticketRepo:    ticketRepo,
-		vehiculoRepo:  vehiculoRepo,
-		restClient:    nil,
-		useRestAPI:    false,
-	}
+// NewService crea una nueva instancia del servicio sobre la fuente de datos indicada
+// (PostgreSQL, REST API o ambas con failover). snapshotTTL es cuánto tiempo se
+// reutiliza el resultado de cada consulta antes de volver a la fuente.
+func NewService(source interfaces.DataSource, snapshotTTL time.Duration) *Service {
+	s := &Service{source: source}
+	s.initSnapshots(snapshotTTL)
+	return s
 }
 
-// NewServiceWithRestAPI crea una instancia del servicio usando REST API
-func NewServiceWithRestAPI(restAPIURL string) *Service {
-	return &Service{
-		dashboardRepo: nil,
-		ticketRepo:    nil,
-		vehiculoRepo:  nil,
-		restClient:    client.NewRestClient(restAPIURL),
-		useRestAPI:    true,
+// initSnapshots crea las cachés de cada tipo de consulta
+func (s *Service) initSnapshots(ttl time.Duration) {
+	s.dashboardCache = newSnapshotCache[*models.DashboardData]("dashboard", ttl)
+	s.seccionesCache = newSnapshotCache[[]models.EspaciosPorSeccion]("espacios_por_seccion", ttl)
+	s.disponiblesCache = newSnapshotCache[[]models.EspacioDetalle]("espacios_disponibles", ttl)
+	s.ticketsCache = newSnapshotCache[[]models.Ticket]("tickets_activos", ttl)
+}
+
+// InvalidateSnapshots descarta los snapshots tras un cambio conocido (por ejemplo
+// un espacio ocupado o liberado) para que la próxima consulta traiga datos nuevos
+func (s *Service) InvalidateSnapshots() {
+	s.dashboardCache.invalidate()
+	s.seccionesCache.invalidate()
+	s.disponiblesCache.invalidate()
+	s.ticketsCache.invalidate()
+}
+
+// GetDashboardData obtiene todos los datos del dashboard desde el snapshot compartido
+func (s *Service) GetDashboardData(ctx context.Context) (Snapshot[*models.DashboardData], error) {
+	return s.dashboardCache.get(ctx, s.loadDashboardData)
+}
+
+// GetEspaciosPorSeccion obtiene espacios agrupados por sección desde el snapshot compartido,
+// con el tiempo y el cobro acumulado de los espacios ocupados calculados al momento
+func (s *Service) GetEspaciosPorSeccion(ctx context.Context) (Snapshot[[]models.EspaciosPorSeccion], error) {
+	snapshot, err := s.seccionesCache.get(ctx, s.loadEspaciosPorSeccion)
+	if err != nil {
+		return snapshot, err
 	}
+	snapshot.Data = models.SeccionesConCobro(snapshot.Data, time.Now())
+	return snapshot, nil
+}
+
+// GetEspaciosDisponibles obtiene la lista de espacios disponibles desde el snapshot compartido
+func (s *Service) GetEspaciosDisponibles(ctx context.Context) (Snapshot[[]models.EspacioDetalle], error) {
+	return s.disponiblesCache.get(ctx, s.loadEspaciosDisponibles)
 }
 
-// GetDashboardData obtiene todos los datos del dashboard
-func (s *Service) GetDashboardData(ctx context.Context) (*models.DashboardData, error) {
-	// Si está configurado para usar REST API
-	if s.useRestAPI && s.restClient != nil {
-		return s.restClient.GetDashboardData()
+// GetTicketsActivos obtiene tickets activos desde el snapshot compartido, filtrados y
+// ordenados según filtro, con el tiempo y el cobro acumulado calculados al momento
+func (s *Service) GetTicketsActivos(ctx context.Context, filtro FiltroTickets) (Snapshot[[]models.Ticket], error) {
+	if err := filtro.validar(); err != nil {
+		return Snapshot[[]models.Ticket]{}, err
 	}
 
-	// Modo database: consultar repositorios directamente
-	// Obtener estadísticas de espacios
-	disponibles, ocupados, total, err := s.dashboardRepo.GetEspaciosStats(ctx)
+	snapshot, err := s.ticketsCache.get(ctx, s.loadTicketsActivos)
 	if err != nil {
-		log.Printf("Error obteniendo estadísticas de espacios: %v", err)
-		return nil, err
+		return snapshot, err
 	}
+	snapshot.Data = filtro.aplicar(models.TicketsConCobro(snapshot.Data, time.Now()))
+	return snapshot, nil
+}
 
-	// Obtener dinero recaudado hoy
-	dineroHoy, err := s.dashboardRepo.GetDineroRecaudadoHoy(ctx)
+// loadDashboardData obtiene todos los datos del dashboard de la fuente
+func (s *Service) loadDashboardData(ctx context.Context) (*models.DashboardData, error) {
+	data, err := s.source.GetDashboardData(ctx)
 	if err != nil {
-		log.Printf("Error obteniendo dinero recaudado hoy: %v", err)
+		log.Printf("Error obteniendo datos del dashboard (%s): %v", s.source.Name(), err)
 		return nil, err
 	}
+	return data, nil
+}
 
-	// Obtener dinero recaudado en el mes
-	dineroMes, err := s.dashboardRepo.GetDineroRecaudadoMes(ctx)
+// loadEspaciosPorSeccion obtiene espacios agrupados por sección de la fuente
+func (s *Service) loadEspaciosPorSeccion(ctx context.Context) ([]models.EspaciosPorSeccion, error) {
+	secciones, err := s.source.GetEspaciosPorSeccion(ctx)
 	if err != nil {
-		log.Printf("Error obteniendo dinero recaudado del mes: %v", err)
+		log.Printf("Error obteniendo espacios por sección (%s): %v", s.source.Name(), err)
 		return nil, err
 	}
+	return secciones, nil
+}
 
-	// Obtener vehículos activos
-	vehiculosActivos, err := s.dashboardRepo.GetVehiculosActivos(ctx)
+// loadEspaciosDisponibles obtiene lista de espacios disponibles de la fuente
+func (s *Service) loadEspaciosDisponibles(ctx context.Context) ([]models.EspacioDetalle, error) {
+	espacios, err := s.source.GetEspaciosDisponibles(ctx)
 	if err != nil {
-		log.Printf("Error obteniendo vehículos activos: %v", err)
+		log.Printf("Error obteniendo espacios disponibles (%s): %v", s.source.Name(), err)
 		return nil, err
 	}
-
-	return &models.DashboardData{
-		EspaciosDisponibles: disponibles,
-		EspaciosOcupados:    ocupados,
-		TotalEspacios:       total,
-		DineroRecaudadoHoy:  dineroHoy,
-		DineroRecaudadoMes:  dineroMes,
-		VehiculosActivos:    vehiculosActivos,
-		Timestamp:           time.Now(),
-	}, nil
+	return espacios, nil
 }
 
-// GetEspaciosPorSeccion obtiene espacios agrupados por sección
-func (s *Service) GetEspaciosPorSeccion(ctx context.Context) ([]models.EspaciosPorSeccion, error) {
-	// Si está configurado para usar REST API
-	if s.useRestAPI && s.restClient != nil {
-		return s.restClient.GetEspaciosPorSeccion()
-	}
-
-	secciones, err := s.dashboardRepo.GetEspaciosPorSeccion(ctx)
+// loadTicketsActivos obtiene tickets activos de la fuente
+func (s *Service) loadTicketsActivos(ctx context.Context) ([]models.Ticket, error) {
+	tickets, err := s.source.GetTicketsActivos(ctx)
 	if err != nil {
-		log.Printf("Error obteniendo espacios por sección: %v", err)
+		log.Printf("Error obteniendo tickets activos (%s): %v", s.source.Name(), err)
 		return nil, err
 	}
-
-	return secciones, nil
+	return tickets, nil
 }
 
-// GetEspaciosDisponibles obtiene lista de espacios disponibles
-func (s *Service) GetEspaciosDisponibles(ctx context.Context) ([]models.EspacioDetalle, error) {
-	// Si está configurado para usar REST API
-	if s.useRestAPI && s.restClient != nil {
-		return s.restClient.GetEspaciosDisponibles()
+// GetTicketsActivosByAuthUser obtiene solo los tickets activos de los vehículos del usuario.
+// No pasa por un snapshot compartido porque el resultado es distinto para cada usuario.
+func (s *Service) GetTicketsActivosByAuthUser(ctx context.Context, authUserID string, filtro FiltroTickets) ([]models.Ticket, error) {
+	if err := filtro.validar(); err != nil {
+		return nil, err
 	}
 
-	espacios, err := s.dashboardRepo.GetEspaciosDisponibles(ctx)
+	tickets, err := s.source.GetTicketsActivosByAuthUser(ctx, authUserID)
 	if err != nil {
-		log.Printf("Error obteniendo espacios disponibles: %v", err)
+		log.Printf("Error obteniendo tickets activos del usuario (%s): %v", s.source.Name(), err)
 		return nil, err
 	}
-
-	// Convertir []Espacio a []EspacioDetalle
-	result := make([]models.EspacioDetalle, len(espacios))
-	for i, e := range espacios {
-		result[i] = models.EspacioDetalle{
-			ID:           e.ID,
-			Numero:       e.Numero,
-			Estado:       e.Estado,
-			SeccionLetra: "", // Se obtendría de una relación si fuera necesario
-		}
-	}
-
-	return result, nil
+	return filtro.aplicar(models.TicketsConCobro(tickets, time.Now())), nil
 }
 
-// GetTicketsActivos obtiene tickets activos con información del vehículo
-func (s *Service) GetTicketsActivos(ctx context.Context) ([]models.Ticket, error) {
-	// Si está configurado para usar REST API
-	if s.useRestAPI && s.restClient != nil {
-		return s.restClient.GetTicketsActivos()
+// GetDesgloseIngresos desglosa la recaudación de [desde, hasta) por método de pago,
+// tipo de tarifa, sección y hora del día. Cada rango es distinto: no usa snapshot.
+func (s *Service) GetDesgloseIngresos(ctx context.Context, desde, hasta time.Time) (*models.DesgloseIngresos, error) {
+	if !hasta.After(desde) {
+		return nil, fmt.Errorf("%w: hasta debe ser posterior a desde", ErrRangoInvalido)
+	}
+	if hasta.Sub(desde) > maxRangoIngresos {
+		return nil, fmt.Errorf("%w: el rango no puede superar un año", ErrRangoInvalido)
 	}
 
-	tickets, err := s.ticketRepo.GetTicketsActivos(ctx)
+	desglose, err := s.source.GetDesgloseIngresos(ctx, desde, hasta)
 	if err != nil {
-		log.Printf("Error obteniendo tickets activos: %v", err)
+		log.Printf("Error obteniendo desglose de ingresos (%s): %v", s.source.Name(), err)
 		return nil, err
 	}
+	return desglose, nil
+}
 
-	return tickets, nil
+// GetFlujoHorario cuenta los ingresos y salidas de tickets de [desde, hasta) por
+// sección y hora, para el pronóstico de ocupación
+func (s *Service) GetFlujoHorario(ctx context.Context, desde, hasta time.Time) ([]models.FlujoHora, error) {
+	flujos, err := s.source.GetFlujoHorario(ctx, desde, hasta)
+	if err != nil {
+		log.Printf("Error obteniendo flujo horario de tickets (%s): %v", s.source.Name(), err)
+		return nil, err
+	}
+	return flujos, nil
 }
@@ -0,0 +1,128 @@
+package dashboard
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/metrics"
+)
+
+// tiempoCarga tiempo máximo de una carga compartida: sin él una fuente colgada dejaría
+// la caché esperando para siempre, porque la carga ignora la cancelación de quien la inició
+var tiempoCarga = 30 * time.Second
+
+// Snapshot resultado de una consulta junto con el momento en que se obtuvo de la fuente.
+// Stale indica que la fuente falló y se está sirviendo el último resultado válido.
+type Snapshot[T any] struct {
+	Data      T
+	FetchedAt time.Time
+	Stale     bool
+}
+
+// Age antigüedad del snapshot
+func (s Snapshot[T]) Age() time.Duration {
+	return time.Since(s.FetchedAt)
+}
+
+// loadCall carga en curso compartida por todos los que piden el snapshot mientras tanto
+type loadCall[T any] struct {
+	done chan struct{}
+	snap Snapshot[T]
+	err  error
+}
+
+// snapshotCache guarda el último resultado de un tipo de consulta durante ttl y
+// agrupa las cargas concurrentes en una sola llamada a la fuente (single-flight)
+type snapshotCache[T any] struct {
+	kind string
+	ttl  time.Duration
+
+	mu         sync.Mutex
+	snap       Snapshot[T]
+	hasSnap    bool // hay un resultado válido, aunque esté vencido o invalidado
+	valid      bool // el resultado puede servirse sin consultar la fuente
+	generation uint64
+	inflight   *loadCall[T]
+}
+
+// newSnapshotCache crea la caché de un tipo de consulta. Con ttl 0 no se reutilizan
+// resultados, pero las cargas simultáneas se siguen agrupando.
+func newSnapshotCache[T any](kind string, ttl time.Duration) *snapshotCache[T] {
+	return &snapshotCache[T]{kind: kind, ttl: ttl}
+}
+
+// get devuelve el snapshot vigente o lo carga con load. Si ya hay una carga en curso
+// espera su resultado en lugar de consultar de nuevo.
+func (c *snapshotCache[T]) get(ctx context.Context, load func(context.Context) (T, error)) (Snapshot[T], error) {
+	c.mu.Lock()
+	if c.valid && time.Since(c.snap.FetchedAt) < c.ttl {
+		snap := c.snap
+		c.mu.Unlock()
+		metrics.SnapshotRequests.Inc(c.kind, "hit")
+		return snap, nil
+	}
+
+	call := c.inflight
+	if call != nil {
+		c.mu.Unlock()
+		metrics.SnapshotRequests.Inc(c.kind, "shared")
+	} else {
+		call = &loadCall[T]{done: make(chan struct{})}
+		c.inflight = call
+		generation := c.generation
+		c.mu.Unlock()
+		metrics.SnapshotRequests.Inc(c.kind, "miss")
+
+		// La carga no depende del contexto de quien la inició: otros clientes
+		// pueden estar esperando el mismo resultado. Solo la limita tiempoCarga.
+		go c.load(context.WithoutCancel(ctx), call, generation, load)
+	}
+
+	select {
+	case <-call.done:
+		return call.snap, call.err
+	case <-ctx.Done():
+		return Snapshot[T]{}, ctx.Err()
+	}
+}
+
+// load ejecuta la consulta y publica el resultado a todos los que esperan. Si la
+// fuente falla y existe un resultado anterior, se entrega ese marcado como stale.
+func (c *snapshotCache[T]) load(ctx context.Context, call *loadCall[T], generation uint64, load func(context.Context) (T, error)) {
+	ctx, cancel := context.WithTimeout(ctx, tiempoCarga)
+	defer cancel()
+	data, err := load(ctx)
+
+	c.mu.Lock()
+	c.inflight = nil
+	switch {
+	case err == nil:
+		call.snap = Snapshot[T]{Data: data, FetchedAt: time.Now()}
+		// Si se invalidó durante la carga el resultado puede no reflejar el cambio:
+		// se entrega a quienes esperaban pero no se guarda como vigente
+		c.snap = call.snap
+		c.hasSnap = true
+		c.valid = generation == c.generation
+	case c.hasSnap:
+		call.snap = c.snap
+		call.snap.Stale = true
+		log.Printf("⚠️  Sirviendo %s de hace %s: %v", c.kind, call.snap.Age().Round(time.Second), err)
+		metrics.SnapshotRequests.Inc(c.kind, "stale")
+	default:
+		call.err = err
+	}
+	c.mu.Unlock()
+
+	close(call.done)
+}
+
+// invalidate obliga a que la próxima consulta vaya a la fuente. El resultado anterior
+// se conserva solo para servirlo como stale si la fuente falla.
+func (c *snapshotCache[T]) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.valid = false
+	c.generation++
+}
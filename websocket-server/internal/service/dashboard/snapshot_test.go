@@ -0,0 +1,226 @@
+package dashboard
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// cargaControlada carga que cuenta las llamadas, lee la fuente al empezar y espera a
+// liberar para responder
+type cargaControlada struct {
+	llamadas atomic.Int32
+	iniciada chan struct{}
+	liberar  chan struct{}
+	valor    atomic.Int32
+	err      error
+}
+
+func nuevaCarga() *cargaControlada {
+	return &cargaControlada{iniciada: make(chan struct{}, 16), liberar: make(chan struct{})}
+}
+
+func (c *cargaControlada) load(context.Context) (int32, error) {
+	c.llamadas.Add(1)
+	valor := c.valor.Load()
+	c.iniciada <- struct{}{}
+	<-c.liberar
+	if c.err != nil {
+		return 0, c.err
+	}
+	return valor, nil
+}
+
+// esperarInicio espera a que empiece una carga
+func (c *cargaControlada) esperarInicio(t *testing.T) {
+	t.Helper()
+	select {
+	case <-c.iniciada:
+	case <-time.After(time.Second):
+		t.Fatal("no se consultó la fuente")
+	}
+}
+
+// inmediata carga que responde sin esperar
+func (c *cargaControlada) inmediata() {
+	close(c.liberar)
+}
+
+func TestSnapshotCacheAgrupaCargasConcurrentes(t *testing.T) {
+	cache := newSnapshotCache[int32]("prueba", time.Minute)
+	carga := nuevaCarga()
+	carga.valor.Store(7)
+
+	const clientes = 10
+	var wg sync.WaitGroup
+	resultados := make(chan int32, clientes)
+	for i := 0; i < clientes; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			snap, err := cache.get(context.Background(), carga.load)
+			if err != nil {
+				t.Errorf("get: %v", err)
+				return
+			}
+			resultados <- snap.Data
+		}()
+	}
+
+	carga.esperarInicio(t)
+	// Dar tiempo a que el resto de los clientes se sume a la carga en curso
+	time.Sleep(20 * time.Millisecond)
+	carga.inmediata()
+	wg.Wait()
+	close(resultados)
+
+	for valor := range resultados {
+		if valor != 7 {
+			t.Errorf("resultado %d, se esperaba 7", valor)
+		}
+	}
+	if n := carga.llamadas.Load(); n != 1 {
+		t.Errorf("%d cargas para %d clientes simultáneos, se esperaba 1", n, clientes)
+	}
+
+	// Dentro del ttl se sirve sin consultar
+	if snap, err := cache.get(context.Background(), carga.load); err != nil || snap.Data != 7 || snap.Stale {
+		t.Errorf("get dentro del ttl = %+v, %v", snap, err)
+	}
+	if n := carga.llamadas.Load(); n != 1 {
+		t.Errorf("%d cargas tras un get dentro del ttl, se esperaba 1", n)
+	}
+}
+
+func TestSnapshotCacheInvalidacionDuranteCarga(t *testing.T) {
+	cache := newSnapshotCache[int32]("prueba", time.Minute)
+	carga := nuevaCarga()
+	carga.valor.Store(1)
+
+	done := make(chan Snapshot[int32])
+	go func() {
+		snap, err := cache.get(context.Background(), carga.load)
+		if err != nil {
+			t.Errorf("get: %v", err)
+		}
+		done <- snap
+	}()
+
+	carga.esperarInicio(t)
+	cache.invalidate()
+	carga.valor.Store(2)
+	carga.inmediata()
+
+	// Quien esperaba recibe el resultado de la carga en curso...
+	if snap := <-done; snap.Data != 1 {
+		t.Errorf("resultado de la carga en curso = %d, se esperaba 1", snap.Data)
+	}
+	// ...pero no queda vigente: la próxima consulta vuelve a la fuente
+	snap, err := cache.get(context.Background(), carga.load)
+	carga.esperarInicio(t)
+	if err != nil || snap.Data != 2 {
+		t.Errorf("get tras la invalidación = %+v, %v; se esperaba el valor nuevo", snap, err)
+	}
+	if n := carga.llamadas.Load(); n != 2 {
+		t.Errorf("%d cargas, se esperaban 2", n)
+	}
+}
+
+func TestSnapshotCacheStaleSiLaFuenteFalla(t *testing.T) {
+	cache := newSnapshotCache[int32]("prueba", 0)
+	carga := nuevaCarga()
+	carga.valor.Store(5)
+	carga.inmediata()
+
+	if _, err := cache.get(context.Background(), carga.load); err != nil {
+		t.Fatalf("primera carga: %v", err)
+	}
+	carga.esperarInicio(t)
+
+	errFuente := errors.New("fuente caída")
+	carga.err = errFuente
+	snap, err := cache.get(context.Background(), carga.load)
+	carga.esperarInicio(t)
+	if err != nil {
+		t.Fatalf("get con la fuente caída = %v, se esperaba el último resultado", err)
+	}
+	if !snap.Stale || snap.Data != 5 {
+		t.Errorf("snapshot = %+v, se esperaba el último valor marcado stale", snap)
+	}
+
+	// Sin resultado anterior el error llega a quien consulta
+	vacia := newSnapshotCache[int32]("prueba", time.Minute)
+	if _, err := vacia.get(context.Background(), carga.load); !errors.Is(err, errFuente) {
+		t.Errorf("get sin resultado anterior = %v, se esperaba el error de la fuente", err)
+	}
+	carga.esperarInicio(t)
+}
+
+func TestSnapshotCacheEsperaCancelada(t *testing.T) {
+	cache := newSnapshotCache[int32]("prueba", time.Minute)
+	carga := nuevaCarga()
+	carga.valor.Store(3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error)
+	go func() {
+		_, err := cache.get(ctx, carga.load)
+		errs <- err
+	}()
+	carga.esperarInicio(t)
+	cancel()
+	if err := <-errs; !errors.Is(err, context.Canceled) {
+		t.Fatalf("get cancelado = %v, se esperaba context.Canceled", err)
+	}
+
+	// La carga sigue y su resultado queda para el resto
+	carga.inmediata()
+	snap, err := cache.get(context.Background(), carga.load)
+	if err != nil || snap.Data != 3 {
+		t.Errorf("get tras la cancelación = %+v, %v", snap, err)
+	}
+	if n := carga.llamadas.Load(); n != 1 {
+		t.Errorf("%d cargas, se esperaba 1", n)
+	}
+}
+
+func TestSnapshotCacheCargaConLimiteDeTiempo(t *testing.T) {
+	anterior := tiempoCarga
+	tiempoCarga = 20 * time.Millisecond
+	t.Cleanup(func() { tiempoCarga = anterior })
+
+	cache := newSnapshotCache[int32]("prueba", time.Minute)
+	colgada := func(ctx context.Context) (int32, error) {
+		// La fuente no responde: solo vuelve cuando vence el contexto
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}
+
+	// Quien inicia la carga cancela su contexto: la carga sigue hasta tiempoCarga
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := cache.get(ctx, colgada); !errors.Is(err, context.Canceled) {
+		t.Fatalf("get cancelado = %v, se esperaba context.Canceled", err)
+	}
+
+	espera, cancelar := context.WithTimeout(context.Background(), time.Second)
+	defer cancelar()
+	if _, err := cache.get(espera, colgada); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("get con fuente colgada = %v, se esperaba el vencimiento de tiempoCarga", err)
+	}
+	if espera.Err() != nil {
+		t.Fatal("la carga no terminó antes del límite de quien esperaba")
+	}
+
+	// Vencida la carga la caché vuelve a consultar la fuente
+	carga := nuevaCarga()
+	carga.valor.Store(5)
+	carga.inmediata()
+	snap, err := cache.get(context.Background(), carga.load)
+	if err != nil || snap.Data != 5 {
+		t.Errorf("get tras el vencimiento = %+v, %v", snap, err)
+	}
+}
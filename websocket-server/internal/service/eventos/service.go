@@ -0,0 +1,166 @@
+package eventos
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/repository/interfaces"
+)
+
+// Publisher recibe los eventos de espacios y multas listos para enviarse a los clientes
+type Publisher interface {
+	PublishEspacioOcupado(event *models.EspacioOcupadoEvent)
+	PublishEspacioLiberado(event *models.EspacioLiberadoEvent)
+	PublishMultaRegistrada(event *models.MultaEvent)
+	PublishMultaPagada(event *models.MultaEvent)
+}
+
+// Source entrega los payloads de las notificaciones de la base de datos
+type Source interface {
+	Listen(ctx context.Context, handle func(payload string))
+}
+
+// Service convierte notificaciones de PostgreSQL en eventos espacio_ocupado / espacio_liberado
+// y multa_registrada / multa_pagada
+type Service struct {
+	source     Source
+	eventoRepo interfaces.EventoRepository
+	publisher  Publisher
+
+	// Último estado publicado por espacio, evita duplicados cuando los triggers de
+	// ticket y de espacio disparan por el mismo movimiento. Las notificaciones de un
+	// movimiento llegan juntas: pasada ventanaDuplicados la entrada se descarta.
+	mu      sync.Mutex
+	ultimos map[string]estadoPublicado
+}
+
+// ventanaDuplicados tiempo durante el que se recuerda el último estado publicado de un espacio
+const ventanaDuplicados = time.Minute
+
+// estadoPublicado estado de un espacio (true = disponible) y cuándo se publicó
+type estadoPublicado struct {
+	disponible bool
+	en         time.Time
+}
+
+// NewService crea una nueva instancia del servicio de eventos
+func NewService(source Source, eventoRepo interfaces.EventoRepository, publisher Publisher) *Service {
+	return &Service{
+		source:     source,
+		eventoRepo: eventoRepo,
+		publisher:  publisher,
+		ultimos:    make(map[string]estadoPublicado),
+	}
+}
+
+// Run procesa notificaciones hasta que el contexto se cancele
+func (s *Service) Run(ctx context.Context) {
+	s.source.Listen(ctx, func(payload string) {
+		s.handleNotification(ctx, payload)
+	})
+}
+
+// handleNotification decodifica, deduplica y publica una notificación
+func (s *Service) handleNotification(ctx context.Context, payload string) {
+	if payload == "" {
+		// Reconexión: no sabemos qué se perdió, olvidar el estado conocido
+		s.mu.Lock()
+		s.ultimos = make(map[string]estadoPublicado)
+		s.mu.Unlock()
+		return
+	}
+
+	var notif models.NotificacionEspacio
+	if err := json.Unmarshal([]byte(payload), &notif); err != nil {
+		log.Printf("Error al parsear notificación de base de datos: %v", err)
+		return
+	}
+
+	if notif.Tabla == "multa" {
+		s.handleMulta(ctx, notif)
+		return
+	}
+
+	if notif.EspacioID == "" {
+		return
+	}
+
+	if !s.marcarEstado(notif.EspacioID, notif.Disponible, time.Now()) {
+		return
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if notif.Disponible {
+		event, err := s.eventoRepo.GetEspacioLiberadoEvent(queryCtx, notif.EspacioID)
+		if err != nil {
+			log.Printf("Error obteniendo evento de espacio liberado: %v", err)
+			return
+		}
+		if event != nil {
+			s.publisher.PublishEspacioLiberado(event)
+		}
+		return
+	}
+
+	event, err := s.eventoRepo.GetEspacioOcupadoEvent(queryCtx, notif.EspacioID)
+	if err != nil {
+		log.Printf("Error obteniendo evento de espacio ocupado: %v", err)
+		return
+	}
+	if event != nil {
+		s.publisher.PublishEspacioOcupado(event)
+	}
+}
+
+// handleMulta publica multa_registrada al insertar una multa y multa_pagada cuando
+// pasa a estado pagada. El trigger solo notifica esos dos cambios.
+func (s *Service) handleMulta(ctx context.Context, notif models.NotificacionEspacio) {
+	if notif.MultaID == "" {
+		return
+	}
+
+	queryCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	event, err := s.eventoRepo.GetMultaEvent(queryCtx, notif.MultaID)
+	if err != nil {
+		log.Printf("Error obteniendo evento de multa: %v", err)
+		return
+	}
+	if event == nil {
+		return
+	}
+
+	switch {
+	case notif.Operacion == "INSERT":
+		s.publisher.PublishMultaRegistrada(event)
+	case notif.Estado == models.MultaPagada:
+		s.publisher.PublishMultaPagada(event)
+	}
+}
+
+// marcarEstado registra el nuevo estado del espacio y devuelve false si ya se había
+// publicado dentro de la ventana. Descarta las entradas vencidas para que el mapa
+// solo guarde los espacios con movimientos recientes.
+func (s *Service) marcarEstado(espacioID string, disponible bool, ahora time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, publicado := range s.ultimos {
+		if ahora.Sub(publicado.en) > ventanaDuplicados {
+			delete(s.ultimos, id)
+		}
+	}
+
+	if anterior, ok := s.ultimos[espacioID]; ok && anterior.disponible == disponible {
+		return false
+	}
+	s.ultimos[espacioID] = estadoPublicado{disponible: disponible, en: ahora}
+	return true
+}
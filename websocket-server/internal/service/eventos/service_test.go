@@ -0,0 +1,37 @@
+package eventos
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMarcarEstadoDeduplicaYPoda(t *testing.T) {
+	s := NewService(nil, nil, nil)
+	ahora := time.Now()
+
+	if !s.marcarEstado("e1", false, ahora) {
+		t.Fatal("el primer movimiento de e1 no se publicó")
+	}
+	// El trigger del espacio llega junto con el del ticket
+	if s.marcarEstado("e1", false, ahora.Add(time.Millisecond)) {
+		t.Error("el mismo movimiento de e1 se publicó dos veces")
+	}
+	if !s.marcarEstado("e1", true, ahora.Add(time.Second)) {
+		t.Error("la salida de e1 no se publicó")
+	}
+	if !s.marcarEstado("e2", false, ahora.Add(time.Second)) {
+		t.Error("el movimiento de e2 no se publicó")
+	}
+
+	// Pasada la ventana las entradas se descartan
+	despues := ahora.Add(time.Second + ventanaDuplicados + time.Millisecond)
+	if !s.marcarEstado("e3", false, despues) {
+		t.Error("el movimiento de e3 no se publicó")
+	}
+	if len(s.ultimos) != 1 {
+		t.Errorf("%d espacios recordados tras la ventana, se esperaba 1 (e3)", len(s.ultimos))
+	}
+	if !s.marcarEstado("e1", true, despues) {
+		t.Error("un estado vencido de e1 siguió bloqueando la publicación")
+	}
+}
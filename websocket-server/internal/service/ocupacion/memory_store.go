@@ -0,0 +1,119 @@
+package ocupacion
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/repository/interfaces"
+)
+
+// MemoryStore historial de ocupación en memoria, para los modos sin base de datos.
+// Se pierde al reiniciar el servidor.
+type MemoryStore struct {
+	retention time.Duration
+
+	mu     sync.RWMutex
+	series map[string][]models.OcupacionMinuto // por sección, ordenadas por minuto
+}
+
+// MemoryStore implementa el repositorio del historial
+var _ interfaces.OcupacionRepository = (*MemoryStore)(nil)
+
+// NewMemoryStore crea el almacén. Los minutos más antiguos que retention se descartan.
+func NewMemoryStore(retention time.Duration) *MemoryStore {
+	return &MemoryStore{
+		retention: retention,
+		series:    make(map[string][]models.OcupacionMinuto),
+	}
+}
+
+// SaveMinutos agrega los minutos a su serie, combinándolos si el minuto ya existe
+func (s *MemoryStore) SaveMinutos(ctx context.Context, minutos []models.OcupacionMinuto) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	limite := time.Now().Add(-s.retention)
+	for _, m := range minutos {
+		serie := s.series[m.Seccion]
+		i := sort.Search(len(serie), func(i int) bool { return !serie[i].Minuto.Before(m.Minuto) })
+		switch {
+		case i < len(serie) && serie[i].Minuto.Equal(m.Minuto):
+			serie[i] = combinar(serie[i], m)
+		default:
+			serie = append(serie, models.OcupacionMinuto{})
+			copy(serie[i+1:], serie[i:])
+			serie[i] = m
+		}
+
+		// Descartar lo que salió de la retención
+		viejos := sort.Search(len(serie), func(i int) bool { return !serie[i].Minuto.Before(limite) })
+		s.series[m.Seccion] = serie[viejos:]
+	}
+	return nil
+}
+
+// GetBuckets agrupa los minutos de la sección en intervalos de size contados desde desde
+func (s *MemoryStore) GetBuckets(ctx context.Context, seccion string, desde, hasta time.Time, size time.Duration) ([]models.OcupacionBucket, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	serie := s.series[seccion]
+	inicio := sort.Search(len(serie), func(i int) bool { return !serie[i].Minuto.Before(desde) })
+
+	buckets := []models.OcupacionBucket{}
+	for _, m := range serie[inicio:] {
+		if !m.Minuto.Before(hasta) {
+			break
+		}
+		buckets = agregarMinuto(buckets, m, desde, size)
+	}
+	return buckets, nil
+}
+
+// combinar une dos acumulados de la misma sección y minuto
+func combinar(a, b models.OcupacionMinuto) models.OcupacionMinuto {
+	if b.Min < a.Min {
+		a.Min = b.Min
+	}
+	if b.Max > a.Max {
+		a.Max = b.Max
+	}
+	a.Suma += b.Suma
+	a.Muestras += b.Muestras
+	return a
+}
+
+// agregarMinuto suma un minuto al bucket que le corresponde, creándolo si no existe.
+// La lista se mantiene ordenada por inicio.
+func agregarMinuto(buckets []models.OcupacionBucket, m models.OcupacionMinuto, desde time.Time, size time.Duration) []models.OcupacionBucket {
+	inicio := desde.Add(m.Minuto.Sub(desde) / size * size)
+
+	i := sort.Search(len(buckets), func(i int) bool { return !buckets[i].Inicio.Before(inicio) })
+	if i < len(buckets) && buckets[i].Inicio.Equal(inicio) {
+		b := &buckets[i]
+		suma := b.Promedio*float64(b.Muestras) + m.Suma
+		b.Muestras += m.Muestras
+		b.Promedio = suma / float64(b.Muestras)
+		if m.Min < b.Min {
+			b.Min = m.Min
+		}
+		if m.Max > b.Max {
+			b.Max = m.Max
+		}
+		return buckets
+	}
+
+	buckets = append(buckets, models.OcupacionBucket{})
+	copy(buckets[i+1:], buckets[i:])
+	buckets[i] = models.OcupacionBucket{
+		Inicio:   inicio,
+		Min:      m.Min,
+		Max:      m.Max,
+		Promedio: m.Suma / float64(m.Muestras),
+		Muestras: m.Muestras,
+	}
+	return buckets
+}
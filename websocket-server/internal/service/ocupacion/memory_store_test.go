@@ -0,0 +1,147 @@
+package ocupacion
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+// muestra minuto con una sola muestra de ocupación
+func muestra(seccion string, minuto time.Time, valor float64) models.OcupacionMinuto {
+	return models.OcupacionMinuto{Seccion: seccion, Minuto: minuto, Min: valor, Max: valor, Suma: valor, Muestras: 1}
+}
+
+// compararBuckets compara inicio, muestras y min/max/promedio con tolerancia
+func compararBuckets(t *testing.T, got, want []models.OcupacionBucket) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%d buckets %+v, se esperaban %d %+v", len(got), got, len(want), want)
+	}
+	cerca := func(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+	for i := range want {
+		g, w := got[i], want[i]
+		if !g.Inicio.Equal(w.Inicio) || g.Muestras != w.Muestras || !cerca(g.Min, w.Min) || !cerca(g.Max, w.Max) || !cerca(g.Promedio, w.Promedio) {
+			t.Errorf("bucket %d = %+v, se esperaba %+v", i, g, w)
+		}
+	}
+}
+
+func TestMemoryStoreGetBuckets(t *testing.T) {
+	ctx := context.Background()
+	base := time.Now().Add(-48 * time.Hour).Truncate(24 * time.Hour)
+	store := NewMemoryStore(7 * 24 * time.Hour)
+
+	// Fuera de orden y con un minuto guardado en dos veces
+	err := store.SaveMinutos(ctx, []models.OcupacionMinuto{
+		muestra("A", base.Add(65*time.Minute), 1.0),
+		muestra("A", base, 0.2),
+		muestra("B", base.Add(time.Minute), 0.9),
+		muestra("A", base.Add(7*time.Minute), 0.4),
+		muestra("A", base.Add(3*time.Minute), 0.5),
+	})
+	if err != nil {
+		t.Fatalf("SaveMinutos: %v", err)
+	}
+	if err := store.SaveMinutos(ctx, []models.OcupacionMinuto{muestra("A", base.Add(3*time.Minute), 0.7)}); err != nil {
+		t.Fatalf("SaveMinutos: %v", err)
+	}
+
+	casos := []struct {
+		nombre  string
+		seccion string
+		desde   time.Time
+		hasta   time.Time
+		size    time.Duration
+		want    []models.OcupacionBucket
+	}{
+		{
+			nombre: "5m", seccion: "A", desde: base, hasta: base.Add(24 * time.Hour), size: 5 * time.Minute,
+			want: []models.OcupacionBucket{
+				{Inicio: base, Min: 0.2, Max: 0.7, Promedio: 1.4 / 3, Muestras: 3},
+				{Inicio: base.Add(5 * time.Minute), Min: 0.4, Max: 0.4, Promedio: 0.4, Muestras: 1},
+				{Inicio: base.Add(65 * time.Minute), Min: 1.0, Max: 1.0, Promedio: 1.0, Muestras: 1},
+			},
+		},
+		{
+			nombre: "1h", seccion: "A", desde: base, hasta: base.Add(24 * time.Hour), size: time.Hour,
+			want: []models.OcupacionBucket{
+				{Inicio: base, Min: 0.2, Max: 0.7, Promedio: 1.8 / 4, Muestras: 4},
+				{Inicio: base.Add(time.Hour), Min: 1.0, Max: 1.0, Promedio: 1.0, Muestras: 1},
+			},
+		},
+		{
+			nombre: "1d", seccion: "A", desde: base, hasta: base.Add(24 * time.Hour), size: 24 * time.Hour,
+			want: []models.OcupacionBucket{
+				{Inicio: base, Min: 0.2, Max: 1.0, Promedio: 2.8 / 5, Muestras: 5},
+			},
+		},
+		{
+			// Los intervalos se cuentan desde desde, no desde la hora en punto
+			nombre: "desde desalineado", seccion: "A", desde: base.Add(2 * time.Minute), hasta: base.Add(time.Hour), size: 5 * time.Minute,
+			want: []models.OcupacionBucket{
+				{Inicio: base.Add(2 * time.Minute), Min: 0.5, Max: 0.7, Promedio: 0.6, Muestras: 2},
+				{Inicio: base.Add(7 * time.Minute), Min: 0.4, Max: 0.4, Promedio: 0.4, Muestras: 1},
+			},
+		},
+		{
+			nombre: "hasta exclusivo", seccion: "A", desde: base, hasta: base.Add(65 * time.Minute), size: time.Hour,
+			want: []models.OcupacionBucket{
+				{Inicio: base, Min: 0.2, Max: 0.7, Promedio: 1.8 / 4, Muestras: 4},
+			},
+		},
+		{
+			nombre: "otra sección", seccion: "B", desde: base, hasta: base.Add(24 * time.Hour), size: time.Hour,
+			want: []models.OcupacionBucket{
+				{Inicio: base, Min: 0.9, Max: 0.9, Promedio: 0.9, Muestras: 1},
+			},
+		},
+		{
+			nombre: "sección sin datos", seccion: "C", desde: base, hasta: base.Add(24 * time.Hour), size: time.Hour,
+			want:   []models.OcupacionBucket{},
+		},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			buckets, err := store.GetBuckets(ctx, caso.seccion, caso.desde, caso.hasta, caso.size)
+			if err != nil {
+				t.Fatalf("GetBuckets: %v", err)
+			}
+			if buckets == nil {
+				t.Fatal("GetBuckets = nil, se esperaba una lista")
+			}
+			compararBuckets(t, buckets, caso.want)
+		})
+	}
+}
+
+func TestMemoryStoreRetencion(t *testing.T) {
+	ctx := context.Background()
+	ahora := time.Now().Truncate(time.Minute)
+	store := NewMemoryStore(time.Hour)
+
+	err := store.SaveMinutos(ctx, []models.OcupacionMinuto{
+		muestra("A", ahora.Add(-2*time.Hour), 0.1),
+		muestra("A", ahora.Add(-30*time.Minute), 0.5),
+	})
+	if err != nil {
+		t.Fatalf("SaveMinutos: %v", err)
+	}
+	// Un minuto atrasado fuera de la retención tampoco se conserva
+	if err := store.SaveMinutos(ctx, []models.OcupacionMinuto{muestra("A", ahora.Add(-90*time.Minute), 0.3)}); err != nil {
+		t.Fatalf("SaveMinutos: %v", err)
+	}
+
+	buckets, err := store.GetBuckets(ctx, "A", ahora.Add(-3*time.Hour), ahora.Add(time.Minute), 24*time.Hour)
+	if err != nil {
+		t.Fatalf("GetBuckets: %v", err)
+	}
+	compararBuckets(t, buckets, []models.OcupacionBucket{
+		{Inicio: ahora.Add(-3 * time.Hour), Min: 0.5, Max: 0.5, Promedio: 0.5, Muestras: 1},
+	})
+	if n := len(store.series["A"]); n != 1 {
+		t.Errorf("%d minutos en la serie, se esperaba 1 tras descartar los viejos", n)
+	}
+}
@@ -0,0 +1,179 @@
+package ocupacion
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+	"github.com/josedavid1945/estacionamiento-websocket/internal/repository/interfaces"
+)
+
+// BucketSizes tamaños de intervalo aceptados en las consultas del historial
+var BucketSizes = map[string]time.Duration{
+	"5m": 5 * time.Minute,
+	"1h": time.Hour,
+	"1d": 24 * time.Hour,
+}
+
+// maxBuckets intervalos como máximo por consulta (una semana de 5 minutos)
+const maxBuckets = 7 * 24 * 12
+
+// ErrConsultaInvalida rango o tamaño de intervalo no aceptados
+var ErrConsultaInvalida = errors.New("consulta de historial inválida")
+
+// SeccionesLoader obtiene la ocupación actual de cada sección
+type SeccionesLoader func(ctx context.Context) ([]models.EspaciosPorSeccion, error)
+
+// Service registra la ocupación de los snapshots del dashboard en una serie por
+// minuto (total y por sección) y la consulta agrupada en intervalos
+type Service struct {
+	repo      interfaces.OcupacionRepository
+	secciones SeccionesLoader
+
+	// Minuto en curso: se acumula en memoria y se guarda al pasar al siguiente
+	mu               sync.Mutex
+	minuto           time.Time
+	abiertos         map[string]*models.OcupacionMinuto
+	ultimaMuestra    time.Time
+	ultimasSecciones time.Time
+}
+
+// NewService crea el servicio. secciones puede ser nil para registrar solo el total.
+func NewService(repo interfaces.OcupacionRepository, secciones SeccionesLoader) *Service {
+	return &Service{
+		repo:      repo,
+		secciones: secciones,
+		abiertos:  make(map[string]*models.OcupacionMinuto),
+	}
+}
+
+// Record registra la ocupación de un snapshot del dashboard obtenido en at. La de cada
+// sección se muestrea como mucho una vez por minuto para no multiplicar las consultas.
+func (s *Service) Record(ctx context.Context, at time.Time, ocupados, total int) {
+	if total == 0 {
+		return
+	}
+
+	s.mu.Lock()
+	if !at.After(s.ultimaMuestra) {
+		// Mismo snapshot servido de nuevo desde la caché
+		s.mu.Unlock()
+		return
+	}
+	s.ultimaMuestra = at
+	minuto := at.Truncate(time.Minute)
+	muestrearSecciones := s.secciones != nil && minuto.After(s.ultimasSecciones)
+	if muestrearSecciones {
+		s.ultimasSecciones = minuto
+	}
+	s.mu.Unlock()
+
+	muestras := map[string]float64{models.SeccionTotal: float64(ocupados) / float64(total)}
+	if muestrearSecciones {
+		secciones, err := s.secciones(ctx)
+		if err != nil {
+			log.Printf("⚠️  Historial de ocupación sin secciones en este minuto: %v", err)
+		}
+		for _, seccion := range secciones {
+			if seccion.TotalEspacios > 0 {
+				muestras[seccion.SeccionLetra] = float64(seccion.EspaciosOcupados) / float64(seccion.TotalEspacios)
+			}
+		}
+	}
+
+	s.add(ctx, minuto, muestras)
+}
+
+// add acumula las muestras en el minuto en curso y guarda el anterior si se cerró
+func (s *Service) add(ctx context.Context, minuto time.Time, muestras map[string]float64) {
+	s.mu.Lock()
+	var cerrados []models.OcupacionMinuto
+	if minuto.After(s.minuto) {
+		cerrados = s.takeAbiertos()
+		s.minuto = minuto
+	}
+
+	// Una muestra atrasada se cuenta en el minuto en curso
+	for seccion, valor := range muestras {
+		m, ok := s.abiertos[seccion]
+		if !ok {
+			s.abiertos[seccion] = &models.OcupacionMinuto{
+				Seccion: seccion, Minuto: s.minuto, Min: valor, Max: valor, Suma: valor, Muestras: 1,
+			}
+			continue
+		}
+		*m = combinar(*m, models.OcupacionMinuto{Min: valor, Max: valor, Suma: valor, Muestras: 1})
+	}
+	s.mu.Unlock()
+
+	s.save(ctx, cerrados)
+}
+
+// takeAbiertos devuelve el minuto en curso y lo vacía. Requiere s.mu.
+func (s *Service) takeAbiertos() []models.OcupacionMinuto {
+	minutos := make([]models.OcupacionMinuto, 0, len(s.abiertos))
+	for _, m := range s.abiertos {
+		minutos = append(minutos, *m)
+	}
+	s.abiertos = make(map[string]*models.OcupacionMinuto)
+	return minutos
+}
+
+// save guarda los minutos cerrados en el repositorio
+func (s *Service) save(ctx context.Context, minutos []models.OcupacionMinuto) {
+	if len(minutos) == 0 {
+		return
+	}
+	if err := s.repo.SaveMinutos(ctx, minutos); err != nil {
+		log.Printf("⚠️  Error guardando historial de ocupación: %v", err)
+	}
+}
+
+// Flush guarda el minuto en curso, al apagar el servidor
+func (s *Service) Flush(ctx context.Context) {
+	s.mu.Lock()
+	minutos := s.takeAbiertos()
+	s.mu.Unlock()
+
+	s.save(ctx, minutos)
+}
+
+// GetHistorial devuelve la ocupación de la sección (SeccionTotal para todo el
+// estacionamiento) entre desde y hasta, agrupada en intervalos de bucket
+func (s *Service) GetHistorial(ctx context.Context, seccion, bucket string, desde, hasta time.Time) (*models.HistorialOcupacion, error) {
+	size, ok := BucketSizes[bucket]
+	if !ok {
+		return nil, fmt.Errorf("%w: bucket debe ser 5m, 1h o 1d", ErrConsultaInvalida)
+	}
+	if !hasta.After(desde) {
+		return nil, fmt.Errorf("%w: hasta debe ser posterior a desde", ErrConsultaInvalida)
+	}
+	if hasta.Sub(desde)/size > maxBuckets {
+		return nil, fmt.Errorf("%w: el rango supera %d intervalos de %s", ErrConsultaInvalida, maxBuckets, bucket)
+	}
+
+	buckets, err := s.repo.GetBuckets(ctx, seccion, desde, hasta, size)
+	if err != nil {
+		return nil, err
+	}
+
+	// El minuto en curso todavía no está en el repositorio
+	s.mu.Lock()
+	abierto, ok := s.abiertos[seccion]
+	if ok && !abierto.Minuto.Before(desde) && abierto.Minuto.Before(hasta) {
+		buckets = agregarMinuto(buckets, *abierto, desde, size)
+	}
+	s.mu.Unlock()
+
+	return &models.HistorialOcupacion{
+		Seccion: seccion,
+		Bucket:  bucket,
+		Desde:   desde,
+		Hasta:   hasta,
+		Buckets: buckets,
+	}, nil
+}
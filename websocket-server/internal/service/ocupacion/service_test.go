@@ -0,0 +1,103 @@
+package ocupacion
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+func TestGetHistorialInvalido(t *testing.T) {
+	s := NewService(NewMemoryStore(time.Hour), nil)
+	ahora := time.Now()
+
+	casos := []struct {
+		nombre string
+		bucket string
+		desde  time.Time
+		hasta  time.Time
+	}{
+		{"bucket desconocido", "15m", ahora.Add(-time.Hour), ahora},
+		{"bucket vacío", "", ahora.Add(-time.Hour), ahora},
+		{"rango vacío", "5m", ahora, ahora},
+		{"rango invertido", "1h", ahora, ahora.Add(-time.Hour)},
+		{"demasiados intervalos", "5m", ahora.Add(-8 * 24 * time.Hour), ahora},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			if _, err := s.GetHistorial(context.Background(), models.SeccionTotal, caso.bucket, caso.desde, caso.hasta); !errors.Is(err, ErrConsultaInvalida) {
+				t.Errorf("err = %v, se esperaba ErrConsultaInvalida", err)
+			}
+		})
+	}
+
+	// Una semana de 5 minutos es el máximo; con intervalos de un día el rango puede ser mayor
+	if _, err := s.GetHistorial(context.Background(), models.SeccionTotal, "5m", ahora.Add(-7*24*time.Hour), ahora); err != nil {
+		t.Errorf("una semana de 5m: %v", err)
+	}
+	if _, err := s.GetHistorial(context.Background(), models.SeccionTotal, "1d", ahora.Add(-30*24*time.Hour), ahora); err != nil {
+		t.Errorf("30 días de 1d: %v", err)
+	}
+}
+
+func TestServiceRecord(t *testing.T) {
+	ctx := context.Background()
+	minuto := time.Now().Add(-10 * time.Minute).Truncate(time.Minute)
+	cargas := 0
+	secciones := func(context.Context) ([]models.EspaciosPorSeccion, error) {
+		cargas++
+		return []models.EspaciosPorSeccion{
+			{SeccionLetra: "A", TotalEspacios: 4, EspaciosOcupados: 1},
+			{SeccionLetra: "B", TotalEspacios: 0}, // sin espacios: no se registra
+		}, nil
+	}
+	store := NewMemoryStore(time.Hour)
+	s := NewService(store, secciones)
+
+	s.Record(ctx, minuto.Add(10*time.Second), 1, 10)
+	s.Record(ctx, minuto.Add(10*time.Second), 9, 10) // el mismo snapshot desde la caché
+	s.Record(ctx, minuto.Add(40*time.Second), 3, 10)
+	s.Record(ctx, minuto.Add(30*time.Second), 5, 0) // sin espacios
+	if cargas != 1 {
+		t.Errorf("secciones cargadas %d veces en el minuto, se esperaba 1", cargas)
+	}
+
+	// El minuto en curso se ve en el historial antes de guardarse
+	desde, hasta := minuto.Add(-time.Hour), minuto.Add(time.Hour)
+	historial, err := s.GetHistorial(ctx, models.SeccionTotal, "5m", desde, hasta)
+	if err != nil {
+		t.Fatalf("GetHistorial: %v", err)
+	}
+	inicio := desde.Add(minuto.Sub(desde) / (5 * time.Minute) * 5 * time.Minute)
+	compararBuckets(t, historial.Buckets, []models.OcupacionBucket{
+		{Inicio: inicio, Min: 0.1, Max: 0.3, Promedio: 0.2, Muestras: 2},
+	})
+	if buckets, _ := store.GetBuckets(ctx, models.SeccionTotal, desde, hasta, time.Hour); len(buckets) != 0 {
+		t.Errorf("minuto abierto ya guardado: %+v", buckets)
+	}
+
+	// Al pasar al minuto siguiente se guarda el anterior, con la sección muestreada
+	s.Record(ctx, minuto.Add(70*time.Second), 2, 10)
+	seccion, err := s.GetHistorial(ctx, "A", "1h", desde, hasta)
+	if err != nil {
+		t.Fatalf("GetHistorial: %v", err)
+	}
+	compararBuckets(t, seccion.Buckets, []models.OcupacionBucket{
+		{Inicio: desde.Add(time.Hour), Min: 0.25, Max: 0.25, Promedio: 0.25, Muestras: 2},
+	})
+	if cargas != 2 {
+		t.Errorf("secciones cargadas %d veces en dos minutos, se esperaba 2", cargas)
+	}
+	if buckets, _ := store.GetBuckets(ctx, "B", desde, hasta, time.Hour); len(buckets) != 0 {
+		t.Errorf("sección sin espacios registrada: %+v", buckets)
+	}
+
+	// Flush guarda el minuto en curso
+	s.Flush(ctx)
+	total, _ := store.GetBuckets(ctx, models.SeccionTotal, desde, hasta, time.Hour)
+	compararBuckets(t, total, []models.OcupacionBucket{
+		{Inicio: desde.Add(time.Hour), Min: 0.1, Max: 0.3, Promedio: 0.2, Muestras: 3},
+	})
+}
@@ -0,0 +1,103 @@
+// Package permanencia detecta vehículos que superan su permanencia máxima y decide
+// cuándo alertar: al cruzar el límite y en cada paso de escalamiento.
+package permanencia
+
+import (
+	"sync"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+// Monitor evalúa los tickets activos contra las reglas y recuerda el último nivel
+// alertado de cada ticket. El estado vive en memoria: tras un reinicio cada ticket
+// excedido se alerta una vez más en su nivel actual.
+type Monitor struct {
+	reglas       *Reglas
+	escalamiento []time.Duration
+
+	mu          sync.Mutex
+	notificados map[string]int // ticket -> último nivel alertado
+}
+
+// NewMonitor crea un monitor. escalamiento son los tiempos excedidos (ordenados) tras
+// los cuales se repite la alerta con un nivel más.
+func NewMonitor(reglas *Reglas, escalamiento []time.Duration) *Monitor {
+	return &Monitor{
+		reglas:       reglas,
+		escalamiento: escalamiento,
+		notificados:  make(map[string]int),
+	}
+}
+
+// Evaluar devuelve las alertas a enviar para los tickets activos en el instante ahora.
+// Cada ticket genera una alerta al superar su límite (nivel 0) y otra al alcanzar cada
+// paso de escalamiento; si se saltan pasos (por ejemplo tras un corte) solo se envía el
+// nivel alcanzado. Los tickets que ya no están activos se olvidan.
+func (m *Monitor) Evaluar(tickets []models.Ticket, ahora time.Time) []models.OverstayAlert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	activos := make(map[string]bool, len(tickets))
+	var alertas []models.OverstayAlert
+
+	for _, ticket := range tickets {
+		if ticket.FechaSalida != nil {
+			continue
+		}
+		activos[ticket.ID] = true
+
+		maximo, regla, ok := m.reglas.Limite(ticket)
+		if !ok {
+			// Ya no aplica ninguna regla: si vuelve a aplicar se alerta de nuevo
+			delete(m.notificados, ticket.ID)
+			continue
+		}
+		excedido := ahora.Sub(ticket.FechaIngreso) - maximo
+		if excedido < 0 {
+			// Dentro del límite (o el límite cambió): volver a alertar si lo supera
+			delete(m.notificados, ticket.ID)
+			continue
+		}
+
+		nivel := m.nivel(excedido)
+		if anterior, alertado := m.notificados[ticket.ID]; alertado && anterior >= nivel {
+			continue
+		}
+		m.notificados[ticket.ID] = nivel
+
+		alertas = append(alertas, models.OverstayAlert{
+			TicketID:         ticket.ID,
+			VehiculoPlaca:    ticket.VehiculoPlaca,
+			EspacioID:        ticket.EspacioID,
+			EspacioNumero:    ticket.EspacioNumero,
+			SeccionLetra:     ticket.SeccionLetra,
+			HoraIngreso:      ticket.FechaIngreso,
+			MaximoMinutos:    int(maximo / time.Minute),
+			MinutosExcedidos: int(excedido / time.Minute),
+			Nivel:            nivel,
+			Regla:            regla,
+			Timestamp:        ahora,
+		})
+	}
+
+	for ticketID := range m.notificados {
+		if !activos[ticketID] {
+			delete(m.notificados, ticketID)
+		}
+	}
+
+	return alertas
+}
+
+// nivel cantidad de pasos de escalamiento alcanzados con el tiempo excedido
+func (m *Monitor) nivel(excedido time.Duration) int {
+	nivel := 0
+	for _, paso := range m.escalamiento {
+		if excedido < paso {
+			break
+		}
+		nivel++
+	}
+	return nivel
+}
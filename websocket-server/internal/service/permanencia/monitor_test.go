@@ -0,0 +1,157 @@
+package permanencia
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+// nuevoMonitorPrueba monitor con límite de 2h en la sección A y escalamiento 1h, 4h
+func nuevoMonitorPrueba(t *testing.T) *Monitor {
+	t.Helper()
+	reglas, err := ParseReglas("", "seccion:A=2h")
+	if err != nil {
+		t.Fatalf("ParseReglas: %v", err)
+	}
+	return NewMonitor(reglas, []time.Duration{time.Hour, 4 * time.Hour})
+}
+
+// niveles niveles de las alertas del ticket t1 (nil = sin alertas)
+func niveles(alertas []models.OverstayAlert) []int {
+	var n []int
+	for _, alerta := range alertas {
+		n = append(n, alerta.Nivel)
+	}
+	return n
+}
+
+func TestMonitorEscalamiento(t *testing.T) {
+	ingreso := time.Date(2026, 7, 15, 8, 0, 0, 0, time.UTC)
+	ticket := models.Ticket{ID: "t1", SeccionLetra: "A", FechaIngreso: ingreso}
+
+	casos := []struct {
+		nombre string
+		pasos  []time.Duration // tiempo desde el ingreso en cada evaluación
+		want   [][]int
+	}{
+		{
+			nombre: "una vez por cruce",
+			pasos:  []time.Duration{time.Hour, 2 * time.Hour, 2*time.Hour + time.Minute, 2*time.Hour + 30*time.Minute},
+			want:   [][]int{nil, {0}, nil, nil},
+		},
+		{
+			nombre: "repite en cada paso",
+			pasos:  []time.Duration{2 * time.Hour, 3 * time.Hour, 4 * time.Hour, 6 * time.Hour, 7 * time.Hour},
+			want:   [][]int{{0}, {1}, nil, {2}, nil},
+		},
+		{
+			nombre: "pasos saltados solo alertan el alcanzado",
+			pasos:  []time.Duration{time.Hour, 10 * time.Hour, 11 * time.Hour},
+			want:   [][]int{nil, {2}, nil},
+		},
+		{
+			nombre: "del cruce al último paso",
+			pasos:  []time.Duration{2 * time.Hour, 6 * time.Hour},
+			want:   [][]int{{0}, {2}},
+		},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			m := nuevoMonitorPrueba(t)
+			for i, paso := range caso.pasos {
+				got := niveles(m.Evaluar([]models.Ticket{ticket}, ingreso.Add(paso)))
+				if !reflect.DeepEqual(got, caso.want[i]) {
+					t.Errorf("a las %v: niveles %v, se esperaba %v", paso, got, caso.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestMonitorAlerta(t *testing.T) {
+	ingreso := time.Date(2026, 7, 15, 8, 0, 0, 0, time.UTC)
+	ticket := models.Ticket{
+		ID: "t1", VehiculoPlaca: "ABC123", EspacioID: "e1", EspacioNumero: "A1",
+		SeccionLetra: "a", FechaIngreso: ingreso,
+	}
+	ahora := ingreso.Add(3*time.Hour + 90*time.Second)
+
+	alertas := nuevoMonitorPrueba(t).Evaluar([]models.Ticket{ticket}, ahora)
+	want := models.OverstayAlert{
+		TicketID: "t1", VehiculoPlaca: "ABC123", EspacioID: "e1", EspacioNumero: "A1",
+		SeccionLetra: "a", HoraIngreso: ingreso,
+		MaximoMinutos: 120, MinutosExcedidos: 61, Nivel: 1, Regla: "seccion:A",
+		Timestamp: ahora,
+	}
+	if len(alertas) != 1 || alertas[0] != want {
+		t.Errorf("alertas = %+v, se esperaba %+v", alertas, want)
+	}
+}
+
+func TestMonitorRearma(t *testing.T) {
+	ingreso := time.Date(2026, 7, 15, 8, 0, 0, 0, time.UTC)
+	ahora := ingreso.Add(3 * time.Hour)
+	enA := models.Ticket{ID: "t1", SeccionLetra: "A", FechaIngreso: ingreso}
+
+	t.Run("límite mayor", func(t *testing.T) {
+		m := nuevoMonitorPrueba(t)
+		if got := niveles(m.Evaluar([]models.Ticket{enA}, ahora)); !reflect.DeepEqual(got, []int{1}) {
+			t.Fatalf("niveles %v, se esperaba [1]", got)
+		}
+
+		// El límite sube y el ticket queda dentro: al volver a excederlo se alerta otra vez
+		m.reglas.porSeccion["A"] = 5 * time.Hour
+		if got := niveles(m.Evaluar([]models.Ticket{enA}, ahora)); got != nil {
+			t.Fatalf("dentro del nuevo límite: niveles %v, se esperaba ninguno", got)
+		}
+		m.reglas.porSeccion["A"] = 2 * time.Hour
+		if got := niveles(m.Evaluar([]models.Ticket{enA}, ahora)); !reflect.DeepEqual(got, []int{1}) {
+			t.Errorf("límite restaurado: niveles %v, se esperaba [1]", got)
+		}
+	})
+
+	t.Run("sin regla", func(t *testing.T) {
+		m := nuevoMonitorPrueba(t)
+		if got := niveles(m.Evaluar([]models.Ticket{enA}, ahora)); !reflect.DeepEqual(got, []int{1}) {
+			t.Fatalf("niveles %v, se esperaba [1]", got)
+		}
+
+		// Ninguna regla aplica al ticket: se olvida y al volver a aplicar se alerta
+		enB := enA
+		enB.SeccionLetra = "B"
+		if got := niveles(m.Evaluar([]models.Ticket{enB}, ahora)); got != nil {
+			t.Fatalf("sin regla: niveles %v, se esperaba ninguno", got)
+		}
+		if _, ok := m.notificados["t1"]; ok {
+			t.Error("el ticket sin regla sigue en notificados")
+		}
+		if got := niveles(m.Evaluar([]models.Ticket{enA}, ahora)); !reflect.DeepEqual(got, []int{1}) {
+			t.Errorf("regla de nuevo: niveles %v, se esperaba [1]", got)
+		}
+	})
+}
+
+func TestMonitorOlvidaCerrados(t *testing.T) {
+	ingreso := time.Date(2026, 7, 15, 8, 0, 0, 0, time.UTC)
+	ahora := ingreso.Add(3 * time.Hour)
+	t1 := models.Ticket{ID: "t1", SeccionLetra: "A", FechaIngreso: ingreso}
+	t2 := models.Ticket{ID: "t2", SeccionLetra: "A", FechaIngreso: ingreso}
+
+	m := nuevoMonitorPrueba(t)
+	if got := m.Evaluar([]models.Ticket{t1, t2}, ahora); len(got) != 2 {
+		t.Fatalf("%d alertas, se esperaban 2", len(got))
+	}
+
+	// t1 sale (con fecha de salida) y t2 deja de estar en la lista
+	salida := ahora
+	cerrado := t1
+	cerrado.FechaSalida = &salida
+	if got := m.Evaluar([]models.Ticket{cerrado}, ahora); len(got) != 0 {
+		t.Fatalf("tickets cerrados: alertas %+v, se esperaba ninguna", got)
+	}
+	if len(m.notificados) != 0 {
+		t.Errorf("notificados = %v, se esperaba vacío", m.notificados)
+	}
+}
@@ -0,0 +1,126 @@
+package permanencia
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+// Alcances de una regla de permanencia máxima
+const (
+	AlcanceGlobal    = "global"
+	AlcanceSeccion   = "seccion"
+	AlcanceCategoria = "categoria"
+)
+
+// Reglas permanencia máxima global, por sección y por categoría de vehículo
+type Reglas struct {
+	global       time.Duration
+	porSeccion   map[string]time.Duration
+	porCategoria map[string]time.Duration
+}
+
+// ParseReglas interpreta la permanencia máxima global (ej. "24h", vacío = sin límite
+// global) y las reglas específicas separadas por comas, con el formato
+// "seccion:<letra>=<duración>" o "categoria:<nombre>=<duración>"
+// (ej. "seccion:A=4h,categoria:Moto=12h").
+func ParseReglas(global, especificas string) (*Reglas, error) {
+	r := &Reglas{
+		porSeccion:   make(map[string]time.Duration),
+		porCategoria: make(map[string]time.Duration),
+	}
+
+	if global = strings.TrimSpace(global); global != "" {
+		maximo, err := parseMaximo(global)
+		if err != nil {
+			return nil, fmt.Errorf("permanencia máxima global: %w", err)
+		}
+		r.global = maximo
+	}
+
+	for _, regla := range strings.Split(especificas, ",") {
+		regla = strings.TrimSpace(regla)
+		if regla == "" {
+			continue
+		}
+
+		clave, valor, ok := strings.Cut(regla, "=")
+		if !ok {
+			return nil, fmt.Errorf("regla %q: se espera <alcance>:<valor>=<duración>", regla)
+		}
+		alcance, nombre, ok := strings.Cut(clave, ":")
+		nombre = strings.ToUpper(strings.TrimSpace(nombre))
+		if !ok || nombre == "" {
+			return nil, fmt.Errorf("regla %q: se espera <alcance>:<valor>=<duración>", regla)
+		}
+		maximo, err := parseMaximo(strings.TrimSpace(valor))
+		if err != nil {
+			return nil, fmt.Errorf("regla %q: %w", regla, err)
+		}
+
+		switch strings.TrimSpace(alcance) {
+		case AlcanceSeccion:
+			r.porSeccion[nombre] = maximo
+		case AlcanceCategoria:
+			r.porCategoria[nombre] = maximo
+		default:
+			return nil, fmt.Errorf("regla %q: alcance desconocido (seccion o categoria)", regla)
+		}
+	}
+
+	return r, nil
+}
+
+// parseMaximo interpreta una duración positiva
+func parseMaximo(value string) (time.Duration, error) {
+	maximo, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("duración inválida %q", value)
+	}
+	if maximo <= 0 {
+		return 0, fmt.Errorf("la duración debe ser positiva: %q", value)
+	}
+	return maximo, nil
+}
+
+// ParseEscalamiento interpreta los pasos de escalamiento separados por comas: tiempo
+// excedido tras el cual se repite la alerta (ej. "1h,4h,24h"). Se devuelven ordenados.
+func ParseEscalamiento(value string) ([]time.Duration, error) {
+	var pasos []time.Duration
+	for _, paso := range strings.Split(value, ",") {
+		paso = strings.TrimSpace(paso)
+		if paso == "" {
+			continue
+		}
+		duracion, err := parseMaximo(paso)
+		if err != nil {
+			return nil, fmt.Errorf("escalamiento: %w", err)
+		}
+		pasos = append(pasos, duracion)
+	}
+	sort.Slice(pasos, func(i, j int) bool { return pasos[i] < pasos[j] })
+	return pasos, nil
+}
+
+// Vacias indica que no hay ninguna regla configurada
+func (r *Reglas) Vacias() bool {
+	return r.global == 0 && len(r.porSeccion) == 0 && len(r.porCategoria) == 0
+}
+
+// Limite devuelve la permanencia máxima del ticket y la regla que la define. Si
+// aplican varias se usa la más estricta. ok es false si ninguna aplica.
+func (r *Reglas) Limite(ticket models.Ticket) (maximo time.Duration, regla string, ok bool) {
+	if r.global > 0 {
+		maximo, regla, ok = r.global, AlcanceGlobal, true
+	}
+	if m, found := r.porSeccion[strings.ToUpper(ticket.SeccionLetra)]; found && (!ok || m < maximo) {
+		maximo, regla, ok = m, AlcanceSeccion+":"+strings.ToUpper(ticket.SeccionLetra), true
+	}
+	if m, found := r.porCategoria[strings.ToUpper(ticket.VehiculoCategoria)]; found && (!ok || m < maximo) {
+		maximo, regla, ok = m, AlcanceCategoria+":"+ticket.VehiculoCategoria, true
+	}
+	return maximo, regla, ok
+}
@@ -0,0 +1,115 @@
+package permanencia
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+func TestParseReglas(t *testing.T) {
+	reglas, err := ParseReglas(" 24h ", "seccion:a=4h, categoria:Moto = 12h,,")
+	if err != nil {
+		t.Fatalf("ParseReglas: %v", err)
+	}
+	if reglas.global != 24*time.Hour {
+		t.Errorf("global = %v, se esperaba 24h", reglas.global)
+	}
+	if want := map[string]time.Duration{"A": 4 * time.Hour}; !reflect.DeepEqual(reglas.porSeccion, want) {
+		t.Errorf("porSeccion = %v, se esperaba %v", reglas.porSeccion, want)
+	}
+	if want := map[string]time.Duration{"MOTO": 12 * time.Hour}; !reflect.DeepEqual(reglas.porCategoria, want) {
+		t.Errorf("porCategoria = %v, se esperaba %v", reglas.porCategoria, want)
+	}
+
+	vacias, err := ParseReglas("", "")
+	if err != nil || !vacias.Vacias() {
+		t.Errorf("sin reglas: %+v, %v; se esperaban reglas vacías", vacias, err)
+	}
+	if reglas.Vacias() {
+		t.Error("Vacias() = true con reglas configuradas")
+	}
+}
+
+func TestParseReglasInvalidas(t *testing.T) {
+	casos := []struct {
+		nombre      string
+		global      string
+		especificas string
+		error       string
+	}{
+		{"global inválida", "un día", "", "permanencia máxima global: duración inválida"},
+		{"global negativa", "-1h", "", "la duración debe ser positiva"},
+		{"sin igual", "", "seccion:A", "se espera <alcance>:<valor>=<duración>"},
+		{"sin alcance", "", "A=4h", "se espera <alcance>:<valor>=<duración>"},
+		{"sin nombre", "", "seccion:=4h", "se espera <alcance>:<valor>=<duración>"},
+		{"alcance desconocido", "", "zona:A=4h", "alcance desconocido"},
+		{"duración inválida", "", "seccion:A=4", `regla "seccion:A=4": duración inválida`},
+		{"duración cero", "", "categoria:Moto=0s", "la duración debe ser positiva"},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			reglas, err := ParseReglas(caso.global, caso.especificas)
+			if err == nil {
+				t.Fatalf("ParseReglas = %+v, se esperaba un error", reglas)
+			}
+			if !strings.Contains(err.Error(), caso.error) {
+				t.Errorf("error %q, se esperaba que contenga %q", err, caso.error)
+			}
+		})
+	}
+}
+
+func TestReglasLimite(t *testing.T) {
+	casos := []struct {
+		nombre      string
+		global      string
+		especificas string
+		ticket      models.Ticket
+		maximo      time.Duration
+		regla       string
+		ok          bool
+	}{
+		{"sin reglas", "", "", models.Ticket{SeccionLetra: "A"}, 0, "", false},
+		{"ninguna aplica", "", "seccion:B=1h", models.Ticket{SeccionLetra: "A"}, 0, "", false},
+		{"global", "24h", "", models.Ticket{SeccionLetra: "A"}, 24 * time.Hour, AlcanceGlobal, true},
+		{"sección más estricta que global", "24h", "seccion:A=4h", models.Ticket{SeccionLetra: "a"}, 4 * time.Hour, "seccion:A", true},
+		{"global más estricta que sección", "2h", "seccion:A=4h", models.Ticket{SeccionLetra: "A"}, 2 * time.Hour, AlcanceGlobal, true},
+		{"categoría más estricta", "24h", "seccion:A=4h,categoria:Moto=1h", models.Ticket{SeccionLetra: "A", VehiculoCategoria: "moto"}, time.Hour, "categoria:moto", true},
+		{"sección más estricta que categoría", "", "seccion:A=1h,categoria:Moto=4h", models.Ticket{SeccionLetra: "A", VehiculoCategoria: "Moto"}, time.Hour, "seccion:A", true},
+		{"empate conserva la primera", "4h", "seccion:A=4h", models.Ticket{SeccionLetra: "A"}, 4 * time.Hour, AlcanceGlobal, true},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			reglas, err := ParseReglas(caso.global, caso.especificas)
+			if err != nil {
+				t.Fatalf("ParseReglas: %v", err)
+			}
+			maximo, regla, ok := reglas.Limite(caso.ticket)
+			if maximo != caso.maximo || regla != caso.regla || ok != caso.ok {
+				t.Errorf("Limite = %v, %q, %v; se esperaba %v, %q, %v", maximo, regla, ok, caso.maximo, caso.regla, caso.ok)
+			}
+		})
+	}
+}
+
+func TestParseEscalamiento(t *testing.T) {
+	pasos, err := ParseEscalamiento(" 24h, 1h,,4h ")
+	if err != nil {
+		t.Fatalf("ParseEscalamiento: %v", err)
+	}
+	if want := []time.Duration{time.Hour, 4 * time.Hour, 24 * time.Hour}; !reflect.DeepEqual(pasos, want) {
+		t.Errorf("pasos = %v, se esperaba %v", pasos, want)
+	}
+
+	if pasos, err := ParseEscalamiento(""); err != nil || len(pasos) != 0 {
+		t.Errorf("vacío: %v, %v; se esperaba sin pasos", pasos, err)
+	}
+	for _, invalido := range []string{"1h,x", "1h,-2h", "0s"} {
+		if _, err := ParseEscalamiento(invalido); err == nil || !strings.HasPrefix(err.Error(), "escalamiento:") {
+			t.Errorf("ParseEscalamiento(%q) err = %v, se esperaba un error de escalamiento", invalido, err)
+		}
+	}
+}
@@ -0,0 +1,246 @@
+// Package pronostico estima la ocupación de cada sección para las próximas horas a
+// partir del flujo histórico de tickets (ingresos y salidas) por día de la semana y
+// hora. Cada franja se pronostica con el promedio de las últimas semanas suavizado
+// exponencialmente: las semanas recientes pesan más que las antiguas.
+package pronostico
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+const (
+	// Modelo nombre del modelo informado en cada pronóstico
+	Modelo = "promedio_estacional_exponencial"
+
+	// HorizonteMaximo horas como máximo que se pronostican
+	HorizonteMaximo = 3
+
+	// alfa factor de suavizado: peso de la semana más reciente frente a las anteriores
+	alfa = 0.5
+
+	// vigencia tiempo que se reutiliza un pronóstico antes de recalcularlo
+	vigencia = 15 * time.Minute
+
+	// reintento espera tras un error antes de volver a consultar la fuente, para no
+	// repetir la consulta del historial en cada ciclo del dashboard
+	reintento = time.Minute
+
+	// tiempoCarga tiempo máximo de un recálculo, que bloquea al resto de consultas
+	tiempoCarga = 30 * time.Second
+
+	semana = 7 * 24 * time.Hour
+)
+
+// ErrHorizonteInvalido horizonte fuera de 1 a HorizonteMaximo horas
+var ErrHorizonteInvalido = errors.New("horizonte de pronóstico inválido")
+
+// FlujoLoader obtiene los ingresos y salidas de [desde, hasta) por sección y hora
+type FlujoLoader func(ctx context.Context, desde, hasta time.Time) ([]models.FlujoHora, error)
+
+// SeccionesLoader obtiene la ocupación actual de cada sección
+type SeccionesLoader func(ctx context.Context) ([]models.EspaciosPorSeccion, error)
+
+// franja día de la semana y hora (hora local)
+type franja struct {
+	dia  time.Weekday
+	hora int
+}
+
+// franjaDe franja a la que pertenece un instante
+func franjaDe(t time.Time) franja {
+	t = t.In(time.Local)
+	return franja{t.Weekday(), t.Hour()}
+}
+
+// tasa ingresos y salidas esperados en una franja
+type tasa struct {
+	ingresos float64
+	salidas  float64
+}
+
+// Service calcula y reutiliza durante unos minutos el pronóstico de todas las secciones
+type Service struct {
+	flujo     FlujoLoader
+	secciones SeccionesLoader
+	semanas   int
+
+	mu        sync.Mutex
+	ultimo    *models.Pronostico
+	ultimoErr error
+	errorEn   time.Time
+}
+
+// NewService crea el servicio. semanas es cuántas semanas de historial se promedian.
+func NewService(flujo FlujoLoader, secciones SeccionesLoader, semanas int) *Service {
+	if semanas <= 0 {
+		semanas = 4
+	}
+	return &Service{flujo: flujo, secciones: secciones, semanas: semanas}
+}
+
+// GetPronostico devuelve la ocupación esperada de cada sección para las próximas
+// horas (de 1 a HorizonteMaximo)
+func (s *Service) GetPronostico(ctx context.Context, horas int) (*models.Pronostico, error) {
+	if horas < 1 || horas > HorizonteMaximo {
+		return nil, fmt.Errorf("%w: horas debe estar entre 1 y %d", ErrHorizonteInvalido, HorizonteMaximo)
+	}
+
+	completo, err := s.Actual(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Copia recortada: el pronóstico completo es compartido
+	pronostico := *completo
+	pronostico.Secciones = make([]models.PronosticoSeccion, len(completo.Secciones))
+	for i, seccion := range completo.Secciones {
+		if len(seccion.Horas) > horas {
+			seccion.Horas = seccion.Horas[:horas]
+		}
+		pronostico.Secciones[i] = seccion
+	}
+	return &pronostico, nil
+}
+
+// Actual devuelve el pronóstico vigente a HorizonteMaximo horas, recalculándolo si
+// ya venció. No debe modificarse: es compartido entre consultas.
+func (s *Service) Actual(ctx context.Context) (*models.Pronostico, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ahora := time.Now()
+	if s.ultimo != nil && ahora.Sub(s.ultimo.GeneradoEn) < vigencia {
+		return s.ultimo, nil
+	}
+	if s.ultimoErr != nil && ahora.Sub(s.errorEn) < reintento {
+		return nil, s.ultimoErr
+	}
+
+	// El recálculo no depende del contexto de quien lo inició: el resto de las
+	// consultas espera el mismo resultado con el lock tomado
+	cargaCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tiempoCarga)
+	defer cancel()
+
+	pronostico, err := s.cargar(cargaCtx, ahora)
+	if err != nil {
+		// Un corte por tiempo no dice nada de la fuente: no se recuerda el error
+		if !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+			s.ultimoErr, s.errorEn = err, ahora
+		}
+		return nil, err
+	}
+	s.ultimo, s.ultimoErr = pronostico, nil
+	return pronostico, nil
+}
+
+// cargar consulta la ocupación actual y el flujo de las últimas semanas y calcula
+func (s *Service) cargar(ctx context.Context, ahora time.Time) (*models.Pronostico, error) {
+	secciones, err := s.secciones(ctx)
+	if err != nil {
+		return nil, err
+	}
+	inicio := ahora.Truncate(time.Hour)
+	flujos, err := s.flujo(ctx, inicio.Add(-time.Duration(s.semanas)*semana), inicio)
+	if err != nil {
+		return nil, err
+	}
+	return s.calcular(ahora, secciones, s.patron(flujos, inicio)), nil
+}
+
+// patron calcula por sección y franja los ingresos y salidas esperados: promedio de
+// las semanas anteriores a inicio ponderado con alfa·(1-alfa)^k para la semana k
+// (0 = la más reciente de esa franja). Una franja sin movimientos en una semana
+// cuenta como cero.
+func (s *Service) patron(flujos []models.FlujoHora, inicio time.Time) map[string]map[franja]tasa {
+	var pesoTotal float64
+	for k := 0; k < s.semanas; k++ {
+		pesoTotal += alfa * math.Pow(1-alfa, float64(k))
+	}
+
+	patron := make(map[string]map[franja]tasa)
+	for _, f := range flujos {
+		if !f.Hora.Before(inicio) {
+			continue
+		}
+		// Semanas completas hacia atrás desde la próxima vez que ocurre la franja a
+		// partir de inicio: la hora en curso de hace 7 días es la semana 0
+		k := int((inicio.Sub(f.Hora) - 1) / semana)
+		if k < 0 || k >= s.semanas {
+			continue
+		}
+		peso := alfa * math.Pow(1-alfa, float64(k)) / pesoTotal
+
+		porFranja, ok := patron[f.SeccionLetra]
+		if !ok {
+			porFranja = make(map[franja]tasa)
+			patron[f.SeccionLetra] = porFranja
+		}
+		t := porFranja[franjaDe(f.Hora)]
+		t.ingresos += peso * float64(f.Ingresos)
+		t.salidas += peso * float64(f.Salidas)
+		porFranja[franjaDe(f.Hora)] = t
+	}
+	return patron
+}
+
+// calcular parte de la ocupación actual de cada sección y le suma hora a hora el
+// flujo esperado, acotado a la capacidad. De la hora en curso solo se cuenta lo que
+// falta para terminarla.
+func (s *Service) calcular(ahora time.Time, secciones []models.EspaciosPorSeccion, patron map[string]map[franja]tasa) *models.Pronostico {
+	pronostico := &models.Pronostico{
+		GeneradoEn:       ahora,
+		Modelo:           Modelo,
+		SemanasHistorial: s.semanas,
+		Secciones:        make([]models.PronosticoSeccion, 0, len(secciones)),
+	}
+
+	inicio := ahora.Truncate(time.Hour)
+	for _, seccion := range secciones {
+		if seccion.TotalEspacios == 0 {
+			continue
+		}
+		total := float64(seccion.TotalEspacios)
+		ocupados := float64(seccion.EspaciosOcupados)
+
+		resultado := models.PronosticoSeccion{
+			SeccionLetra:     seccion.SeccionLetra,
+			TotalEspacios:    seccion.TotalEspacios,
+			EspaciosOcupados: seccion.EspaciosOcupados,
+			Horas:            make([]models.PronosticoHora, 0, HorizonteMaximo),
+		}
+		for k := 0; k < HorizonteMaximo; k++ {
+			desde := inicio.Add(time.Duration(k) * time.Hour)
+			hasta := desde.Add(time.Hour)
+			fraccion := 1.0
+			if k == 0 {
+				fraccion = hasta.Sub(ahora).Hours()
+			}
+
+			t := patron[seccion.SeccionLetra][franjaDe(desde)]
+			ingresos, salidas := t.ingresos*fraccion, t.salidas*fraccion
+			ocupados = math.Min(math.Max(ocupados+ingresos-salidas, 0), total)
+
+			resultado.Horas = append(resultado.Horas, models.PronosticoHora{
+				Hora:              hasta,
+				IngresosEsperados: redondear(ingresos),
+				SalidasEsperadas:  redondear(salidas),
+				Ocupados:          int(math.Round(ocupados)),
+				Porcentaje:        redondear(ocupados * 100 / total),
+			})
+		}
+		pronostico.Secciones = append(pronostico.Secciones, resultado)
+	}
+	return pronostico
+}
+
+// redondear a un decimal
+func redondear(valor float64) float64 {
+	return math.Round(valor*10) / 10
+}
@@ -0,0 +1,205 @@
+package pronostico
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/josedavid1945/estacionamiento-websocket/internal/domain/models"
+)
+
+// fuentePrueba cuenta las consultas y responde err (nil = una sección sin flujo)
+type fuentePrueba struct {
+	consultas int
+	err       error
+}
+
+func (f *fuentePrueba) service() *Service {
+	return NewService(
+		func(ctx context.Context, _, _ time.Time) ([]models.FlujoHora, error) {
+			return nil, ctx.Err()
+		},
+		func(ctx context.Context) ([]models.EspaciosPorSeccion, error) {
+			f.consultas++
+			if f.err != nil {
+				return nil, f.err
+			}
+			return []models.EspaciosPorSeccion{{SeccionLetra: "A", TotalEspacios: 10, EspaciosOcupados: 4}}, nil
+		},
+		4,
+	)
+}
+
+func TestActualIgnoraCancelacionDelLlamador(t *testing.T) {
+	fuente := &fuentePrueba{}
+	s := fuente.service()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	pronostico, err := s.Actual(ctx)
+	if err != nil {
+		t.Fatalf("Actual con el contexto del llamador cancelado = %v, se esperaba el recálculo", err)
+	}
+	if len(pronostico.Secciones) != 1 || pronostico.Secciones[0].EspaciosOcupados != 4 {
+		t.Errorf("pronóstico = %+v", pronostico)
+	}
+
+	// Vigente: no se vuelve a consultar
+	if _, err := s.Actual(context.Background()); err != nil || fuente.consultas != 1 {
+		t.Errorf("segunda consulta: err %v, %d consultas a la fuente, se esperaba 1", err, fuente.consultas)
+	}
+}
+
+func TestActualRecuerdaErroresDeLaFuente(t *testing.T) {
+	errFuente := errors.New("fuente caída")
+	fuente := &fuentePrueba{err: errFuente}
+	s := fuente.service()
+
+	for i := 0; i < 2; i++ {
+		if _, err := s.Actual(context.Background()); !errors.Is(err, errFuente) {
+			t.Fatalf("Actual = %v, se esperaba el error de la fuente", err)
+		}
+	}
+	if fuente.consultas != 1 {
+		t.Errorf("%d consultas durante la espera de reintento, se esperaba 1", fuente.consultas)
+	}
+}
+
+func TestActualNoRecuerdaCortesPorTiempo(t *testing.T) {
+	for _, errCorte := range []error{context.Canceled, context.DeadlineExceeded} {
+		fuente := &fuentePrueba{err: errCorte}
+		s := fuente.service()
+
+		if _, err := s.Actual(context.Background()); !errors.Is(err, errCorte) {
+			t.Fatalf("Actual = %v, se esperaba %v", err, errCorte)
+		}
+		fuente.err = nil
+		if _, err := s.Actual(context.Background()); err != nil {
+			t.Errorf("tras %v: Actual = %v, se esperaba recalcular", errCorte, err)
+		}
+		if fuente.consultas != 2 {
+			t.Errorf("tras %v: %d consultas, se esperaban 2", errCorte, fuente.consultas)
+		}
+	}
+}
+
+func TestCalcularPronostico(t *testing.T) {
+	// Sin cambios de horario en las semanas anteriores
+	inicio := time.Date(2026, 7, 15, 10, 0, 0, 0, time.Local)
+	ahora := inicio.Add(30 * time.Minute)
+	hace := func(semanas int, hora int) time.Time {
+		return inicio.Add(time.Duration(hora)*time.Hour - time.Duration(semanas)*semana)
+	}
+	flujo := func(semanas, hora, ingresos, salidas int) models.FlujoHora {
+		return models.FlujoHora{SeccionLetra: "A", Hora: hace(semanas, hora), Ingresos: ingresos, Salidas: salidas}
+	}
+	constante := func(ingresos, salidas int) []models.FlujoHora {
+		var flujos []models.FlujoHora
+		for semanas := 1; semanas <= 4; semanas++ {
+			for hora := 0; hora < HorizonteMaximo; hora++ {
+				flujos = append(flujos, flujo(semanas, hora, ingresos, salidas))
+			}
+		}
+		return flujos
+	}
+
+	casos := []struct {
+		nombre   string
+		ocupados int
+		flujos   []models.FlujoHora
+		esperado []models.PronosticoHora // sin Hora
+	}{
+		{
+			nombre:   "mismo flujo todas las semanas",
+			ocupados: 4,
+			flujos:   constante(8, 2),
+			// De la hora en curso solo falta la mitad
+			esperado: []models.PronosticoHora{
+				{IngresosEsperados: 4, SalidasEsperadas: 1, Ocupados: 7, Porcentaje: 35},
+				{IngresosEsperados: 8, SalidasEsperadas: 2, Ocupados: 13, Porcentaje: 65},
+				{IngresosEsperados: 8, SalidasEsperadas: 2, Ocupados: 19, Porcentaje: 95},
+			},
+		},
+		{
+			nombre:   "solo la semana más reciente",
+			ocupados: 4,
+			// Peso 0.5 / 0.9375 de la semana 0
+			flujos: []models.FlujoHora{flujo(1, 0, 15, 0), flujo(1, 1, 15, 0)},
+			esperado: []models.PronosticoHora{
+				{IngresosEsperados: 4, Ocupados: 8, Porcentaje: 40},
+				{IngresosEsperados: 8, Ocupados: 16, Porcentaje: 80},
+				{Ocupados: 16, Porcentaje: 80},
+			},
+		},
+		{
+			nombre:   "solo la semana más antigua",
+			ocupados: 4,
+			// Peso 0.0625 / 0.9375 de la semana 3
+			flujos: []models.FlujoHora{flujo(4, 0, 30, 0), flujo(4, 2, 30, 0)},
+			esperado: []models.PronosticoHora{
+				{IngresosEsperados: 1, Ocupados: 5, Porcentaje: 25},
+				{Ocupados: 5, Porcentaje: 25},
+				{IngresosEsperados: 2, Ocupados: 7, Porcentaje: 35},
+			},
+		},
+		{
+			nombre:   "fuera del historial",
+			ocupados: 4,
+			flujos:   []models.FlujoHora{flujo(5, 0, 30, 0), flujo(0, 1, 30, 0)},
+			esperado: []models.PronosticoHora{
+				{Ocupados: 4, Porcentaje: 20},
+				{Ocupados: 4, Porcentaje: 20},
+				{Ocupados: 4, Porcentaje: 20},
+			},
+		},
+		{
+			nombre:   "acotado a la capacidad",
+			ocupados: 18,
+			flujos:   constante(10, 40),
+			esperado: []models.PronosticoHora{
+				{IngresosEsperados: 5, SalidasEsperadas: 20, Ocupados: 3, Porcentaje: 15},
+				{IngresosEsperados: 10, SalidasEsperadas: 40, Ocupados: 0, Porcentaje: 0},
+				{IngresosEsperados: 10, SalidasEsperadas: 40, Ocupados: 0, Porcentaje: 0},
+			},
+		},
+	}
+
+	for _, caso := range casos {
+		t.Run(caso.nombre, func(t *testing.T) {
+			s := NewService(
+				func(_ context.Context, desde, hasta time.Time) ([]models.FlujoHora, error) {
+					if !desde.Equal(inicio.Add(-4*semana)) || !hasta.Equal(inicio) {
+						t.Errorf("flujo de [%s, %s), se esperaba las 4 semanas anteriores a %s", desde, hasta, inicio)
+					}
+					return caso.flujos, nil
+				},
+				func(context.Context) ([]models.EspaciosPorSeccion, error) {
+					return []models.EspaciosPorSeccion{
+						{SeccionLetra: "A", TotalEspacios: 20, EspaciosOcupados: caso.ocupados},
+						{SeccionLetra: "B"}, // sin espacios: no se pronostica
+					}, nil
+				},
+				4,
+			)
+
+			pronostico, err := s.cargar(context.Background(), ahora)
+			if err != nil {
+				t.Fatalf("cargar: %v", err)
+			}
+			if len(pronostico.Secciones) != 1 || pronostico.Secciones[0].SeccionLetra != "A" {
+				t.Fatalf("secciones = %+v, se esperaba solo A", pronostico.Secciones)
+			}
+			horas := pronostico.Secciones[0].Horas
+			if len(horas) != len(caso.esperado) {
+				t.Fatalf("%d horas, se esperaban %d", len(horas), len(caso.esperado))
+			}
+			for i, esperado := range caso.esperado {
+				esperado.Hora = inicio.Add(time.Duration(i+1) * time.Hour)
+				if horas[i] != esperado {
+					t.Errorf("hora %d = %+v, se esperaba %+v", i, horas[i], esperado)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Listener escucha notificaciones de PostgreSQL (LISTEN/NOTIFY) en un canal
+type Listener struct {
+	channel  string
+	listener *pq.Listener
+}
+
+// NewListener abre una conexión dedicada y se suscribe al canal indicado
+func NewListener(databaseURL, channel string) (*Listener, error) {
+	reportProblem := func(ev pq.ListenerEventType, err error) {
+		switch ev {
+		case pq.ListenerEventDisconnected:
+			log.Printf("⚠️  Conexión LISTEN perdida en canal %s: %v", channel, err)
+		case pq.ListenerEventReconnected:
+			log.Printf("✅ Conexión LISTEN restablecida en canal %s", channel)
+		case pq.ListenerEventConnectionAttemptFailed:
+			log.Printf("Error reconectando LISTEN en canal %s: %v", channel, err)
+		}
+	}
+
+	l := pq.NewListener(databaseURL, 2*time.Second, time.Minute, reportProblem)
+	if err := l.Listen(channel); err != nil {
+		l.Close()
+		return nil, fmt.Errorf("error al escuchar canal %s: %w", channel, err)
+	}
+
+	log.Printf("👂 Escuchando notificaciones en canal %s", channel)
+	return &Listener{channel: channel, listener: l}, nil
+}
+
+// Listen entrega el payload de cada notificación hasta que el contexto se cancele.
+// Tras una reconexión pq envía una notificación nil; se reporta como payload vacío
+// para que el consumidor pueda resincronizar su estado.
+func (l *Listener) Listen(ctx context.Context, handle func(payload string)) {
+	ping := time.NewTicker(90 * time.Second)
+	defer ping.Stop()
+
+	for {
+		select {
+		case n := <-l.listener.Notify:
+			if n == nil {
+				handle("")
+				continue
+			}
+			handle(n.Extra)
+		case <-ping.C:
+			// Verificar que la conexión siga viva
+			go l.listener.Ping()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Close cierra la conexión de escucha
+func (l *Listener) Close() error {
+	if l == nil || l.listener == nil {
+		return nil
+	}
+	log.Printf("Cerrando escucha del canal %s...", l.channel)
+	return l.listener.Close()
+}
@@ -11,6 +11,23 @@ import (
 
 // Connect establece conexión con PostgreSQL
 func Connect(databaseURL string) (*sql.DB, error) {
+	db, err := Open(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verificar conexión
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("error al conectar a la base de datos: %w", err)
+	}
+
+	log.Println("✅ Conexión a PostgreSQL establecida correctamente")
+	return db, nil
+}
+
+// Open crea el pool de conexiones sin verificar que la base de datos responda,
+// para modos que pueden arrancar con la base de datos caída (MODE=hybrid)
+func Open(databaseURL string) (*sql.DB, error) {
 	db, err := sql.Open("postgres", databaseURL)
 	if err != nil {
 		return nil, fmt.Errorf("error al abrir conexión a la base de datos: %w", err)
@@ -21,12 +38,6 @@ func Connect(databaseURL string) (*sql.DB, error) {
 	db.SetMaxIdleConns(5)
 	db.SetConnMaxLifetime(5 * time.Minute)
 
-	// Verificar conexión
-	if err := db.Ping(); err != nil {
-		return nil, fmt.Errorf("error al conectar a la base de datos: %w", err)
-	}
-
-	log.Println("✅ Conexión a PostgreSQL establecida correctamente")
 	return db, nil
 }
 